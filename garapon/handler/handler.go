@@ -3,21 +3,39 @@
 package handler
 
 import (
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
 	"net/http"
 
 	"garapon/model"
 	"garapon/service"
 )
 
+// HeaderKioskKey carries the kiosk key on /api/offline/* requests.
+const HeaderKioskKey = "X-Garapon-Kiosk-Key"
+
 // Handler holds a reference to the LotteryService and exposes HTTP methods.
 type Handler struct {
-	svc service.LotteryService
+	svc      service.LotteryService
+	kioskKey string
 }
 
+// Option configures a Handler.
+type Option func(*Handler)
+
+// WithKioskKey enables the offline API for kiosks presenting key in
+// HeaderKioskKey. Without it the offline endpoints are disabled, since
+// offline draws are reported by the client and cannot be checked otherwise.
+func WithKioskKey(key string) Option { return func(h *Handler) { h.kioskKey = key } }
+
 // New constructs a Handler with the provided LotteryService.
-func New(svc service.LotteryService) *Handler {
-	return &Handler{svc: svc}
+func New(svc service.LotteryService, opts ...Option) *Handler {
+	h := &Handler{svc: svc}
+	for _, o := range opts {
+		o(h)
+	}
+	return h
 }
 
 // RegisterRoutes registers all API and UI routes on the given mux.
@@ -27,8 +45,16 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("/api/history", h.History)
 	mux.HandleFunc("/api/stats", h.Stats)
 	mux.HandleFunc("/api/prizes", h.Prizes)
+	mux.HandleFunc("/api/offline/tickets", h.ReserveTickets)
+	mux.HandleFunc("/api/offline/reconcile", h.Reconcile)
+	mux.HandleFunc("/manifest.webmanifest", h.Manifest)
+	mux.HandleFunc("/sw.js", h.ServiceWorker)
+	mux.HandleFunc("/icon.svg", h.Icon)
 }
 
+// maxBodyBytes limits the size of JSON request bodies.
+const maxBodyBytes = 1 << 20
+
 // writeJSON encodes v as JSON and writes it with the given status code.
 func (h *Handler) writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
@@ -56,6 +82,30 @@ func (h *Handler) requireMethod(w http.ResponseWriter, r *http.Request, method s
 	return false
 }
 
+// requireKiosk checks the kiosk key of an offline API request, writing 403
+// (offline disabled) or 401 and returning false on failure.
+func (h *Handler) requireKiosk(w http.ResponseWriter, r *http.Request) bool {
+	if h.kioskKey == "" {
+		h.writeError(w, http.StatusForbidden, "オフライン抽選は無効です（サーバーにキオスクキーが設定されていません）")
+		return false
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(HeaderKioskKey)), []byte(h.kioskKey)) != 1 {
+		h.writeError(w, http.StatusUnauthorized, "キオスクキーが不正です")
+		return false
+	}
+	return true
+}
+
+// readJSON decodes the request body into v, writing 400 and returning false on failure.
+func (h *Handler) readJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodyBytes))
+	if err := dec.Decode(v); err != nil {
+		h.writeError(w, http.StatusBadRequest, "リクエストボディが不正です: "+err.Error())
+		return false
+	}
+	return true
+}
+
 // Home serves the main HTML page.
 func (h *Handler) Home(w http.ResponseWriter, r *http.Request) {
 	if r.URL.Path != "/" {
@@ -103,3 +153,74 @@ func (h *Handler) Prizes(w http.ResponseWriter, r *http.Request) {
 	}
 	h.writeJSON(w, http.StatusOK, h.svc.Prizes())
 }
+
+// ReserveTickets handles POST /api/offline/tickets — reserves a batch of
+// ticket numbers for a kiosk to use while offline.
+func (h *Handler) ReserveTickets(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMethod(w, r, http.MethodPost) || !h.requireKiosk(w, r) {
+		return
+	}
+	var req model.TicketBatchRequest
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	batch, err := h.svc.ReserveTickets(req.Count)
+	if errors.Is(err, service.ErrTooManyBatches) {
+		h.writeError(w, http.StatusTooManyRequests, err.Error())
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusCreated, batch)
+}
+
+// Reconcile handles POST /api/offline/reconcile — merges draws made offline
+// into the service history.
+func (h *Handler) Reconcile(w http.ResponseWriter, r *http.Request) {
+	if !h.requireMethod(w, r, http.MethodPost) || !h.requireKiosk(w, r) {
+		return
+	}
+	var req model.ReconcileRequest
+	if !h.readJSON(w, r, &req) {
+		return
+	}
+	result, err := h.svc.Reconcile(req.BatchID, req.Draws)
+	if errors.Is(err, service.ErrBatchNotFound) {
+		h.writeError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		h.writeError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	h.writeJSON(w, http.StatusOK, result)
+}
+
+// Manifest handles GET /manifest.webmanifest — the PWA web app manifest.
+func (h *Handler) Manifest(w http.ResponseWriter, r *http.Request) {
+	h.serveAsset(w, r, "application/manifest+json; charset=utf-8", manifestJSON)
+}
+
+// ServiceWorker handles GET /sw.js — the offline service worker.
+func (h *Handler) ServiceWorker(w http.ResponseWriter, r *http.Request) {
+	// Always revalidate so kiosks pick up new versions of the worker promptly.
+	w.Header().Set("Cache-Control", "no-cache")
+	h.serveAsset(w, r, "text/javascript; charset=utf-8", serviceWorkerJS)
+}
+
+// Icon handles GET /icon.svg — the app icon referenced by the manifest.
+func (h *Handler) Icon(w http.ResponseWriter, r *http.Request) {
+	h.serveAsset(w, r, "image/svg+xml", iconSVG)
+}
+
+// serveAsset writes a static in-memory asset for GET requests.
+func (h *Handler) serveAsset(w http.ResponseWriter, r *http.Request, contentType, body string) {
+	if !h.requireMethod(w, r, http.MethodGet) {
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(body)) //nolint:errcheck
+}
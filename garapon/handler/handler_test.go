@@ -23,6 +23,10 @@ type mockService struct {
 	history    []model.DrawResult
 	stats      model.Stats
 	prizes     model.PrizesInfo
+	batch      model.TicketBatch
+	batchErr   error
+	reconcile  model.ReconcileResult
+	recErr     error
 }
 
 var _ service.LotteryService = (*mockService)(nil) // compile-time check
@@ -31,6 +35,12 @@ func (m *mockService) Draw() (model.DrawResult, error) { return m.drawResult, m.
 func (m *mockService) History() []model.DrawResult     { return m.history }
 func (m *mockService) Stats() model.Stats              { return m.stats }
 func (m *mockService) Prizes() model.PrizesInfo        { return m.prizes }
+func (m *mockService) ReserveTickets(int) (model.TicketBatch, error) {
+	return m.batch, m.batchErr
+}
+func (m *mockService) Reconcile(string, []model.OfflineDraw) (model.ReconcileResult, error) {
+	return m.reconcile, m.recErr
+}
 
 // defaultMock returns a mock that returns a valid 参加賞 result.
 func defaultMock() *mockService {
@@ -319,6 +329,169 @@ func TestHome_UnknownPath_Returns404(t *testing.T) {
 	}
 }
 
+// ============================================================
+// POST /api/offline/* — オフライン抽選
+// ============================================================
+
+const testKioskKey = "kiosk-secret"
+
+func postJSON(handle http.HandlerFunc, path, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+	req.Header.Set(HeaderKioskKey, testKioskKey)
+	w := httptest.NewRecorder()
+	handle(w, req)
+	return w
+}
+
+func TestReserveTickets_POST_Returns201(t *testing.T) {
+	mock := defaultMock()
+	mock.batch = model.TicketBatch{ID: "b1", Tickets: []int{10, 11, 12}}
+	h := New(mock, WithKioskKey(testKioskKey))
+	w := postJSON(h.ReserveTickets, "/api/offline/tickets", `{"count":3}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("ステータス: got %d, want %d", w.Code, http.StatusCreated)
+	}
+	var batch model.TicketBatch
+	if err := json.Unmarshal(w.Body.Bytes(), &batch); err != nil {
+		t.Fatalf("JSONパースエラー: %v", err)
+	}
+	if batch.ID != "b1" || len(batch.Tickets) != 3 {
+		t.Errorf("バッチ不正: %+v", batch)
+	}
+}
+
+func TestReserveTickets_InvalidBody_Returns400(t *testing.T) {
+	h := New(defaultMock(), WithKioskKey(testKioskKey))
+	w := postJSON(h.ReserveTickets, "/api/offline/tickets", `{count:`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータス: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestReserveTickets_ServiceError_Returns400(t *testing.T) {
+	mock := defaultMock()
+	mock.batchErr = errors.New("予約枚数は 1〜200 の範囲で指定してください")
+	h := New(mock, WithKioskKey(testKioskKey))
+	w := postJSON(h.ReserveTickets, "/api/offline/tickets", `{"count":0}`)
+	if w.Code != http.StatusBadRequest {
+		t.Errorf("ステータス: got %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
+
+func TestReconcile_POST_Returns200(t *testing.T) {
+	mock := defaultMock()
+	mock.reconcile = model.ReconcileResult{
+		Accepted: []model.DrawResult{mock.drawResult},
+		Rejected: []model.RejectedDraw{{TicketNum: 9, Reason: "使用済みの整理券です"}},
+	}
+	h := New(mock, WithKioskKey(testKioskKey))
+	w := postJSON(h.Reconcile, "/api/offline/reconcile",
+		`{"batch_id":"b1","draws":[{"ticket_num":1,"grade":"参加賞","drawn_at":"2026-01-02T10:00:00Z"}]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("ステータス: got %d, want %d", w.Code, http.StatusOK)
+	}
+	var res model.ReconcileResult
+	if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+		t.Fatalf("JSONパースエラー: %v", err)
+	}
+	if len(res.Accepted) != 1 || len(res.Rejected) != 1 {
+		t.Errorf("結果不正: %+v", res)
+	}
+}
+
+func TestOffline_RequiresKioskKey(t *testing.T) {
+	mock := defaultMock()
+	cases := []struct {
+		name string
+		h    *Handler
+		key  string
+		want int
+	}{
+		{"キー未設定のサーバー", New(mock), testKioskKey, http.StatusForbidden},
+		{"キーなし", New(mock, WithKioskKey(testKioskKey)), "", http.StatusUnauthorized},
+		{"誤ったキー", New(mock, WithKioskKey(testKioskKey)), "guess", http.StatusUnauthorized},
+	}
+	for _, tc := range cases {
+		for _, handle := range []http.HandlerFunc{tc.h.ReserveTickets, tc.h.Reconcile} {
+			req := httptest.NewRequest(http.MethodPost, "/api/offline/tickets", strings.NewReader(`{"count":3}`))
+			if tc.key != "" {
+				req.Header.Set(HeaderKioskKey, tc.key)
+			}
+			w := httptest.NewRecorder()
+			handle(w, req)
+			if w.Code != tc.want {
+				t.Errorf("%s: ステータス got %d, want %d", tc.name, w.Code, tc.want)
+			}
+		}
+	}
+}
+
+func TestReconcile_UnknownBatch_Returns404(t *testing.T) {
+	mock := defaultMock()
+	mock.recErr = service.ErrBatchNotFound
+	h := New(mock, WithKioskKey(testKioskKey))
+	w := postJSON(h.Reconcile, "/api/offline/reconcile", `{"batch_id":"nope","draws":[]}`)
+	if w.Code != http.StatusNotFound {
+		t.Errorf("ステータス: got %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestReserveTickets_TooManyBatches_Returns429(t *testing.T) {
+	mock := defaultMock()
+	mock.batchErr = service.ErrTooManyBatches
+	h := New(mock, WithKioskKey(testKioskKey))
+	w := postJSON(h.ReserveTickets, "/api/offline/tickets", `{"count":10}`)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("ステータス: got %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+}
+
+// ============================================================
+// PWA アセット
+// ============================================================
+
+func TestPWAAssets_ContentTypes(t *testing.T) {
+	h := New(defaultMock())
+	cases := []struct {
+		handle http.HandlerFunc
+		path   string
+		want   string
+	}{
+		{h.Manifest, "/manifest.webmanifest", "application/manifest+json"},
+		{h.ServiceWorker, "/sw.js", "text/javascript"},
+		{h.Icon, "/icon.svg", "image/svg+xml"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		w := httptest.NewRecorder()
+		tc.handle(w, req)
+		if ct := w.Header().Get("Content-Type"); !strings.Contains(ct, tc.want) {
+			t.Errorf("%s Content-Type: got %q, want %q", tc.path, ct, tc.want)
+		}
+	}
+}
+
+func TestManifest_IsValidJSON(t *testing.T) {
+	var m map[string]any
+	if err := json.Unmarshal([]byte(manifestJSON), &m); err != nil {
+		t.Fatalf("manifest が JSON として不正: %v", err)
+	}
+	if m["start_url"] != "/" {
+		t.Errorf("start_url: got %v, want /", m["start_url"])
+	}
+}
+
+func TestHome_RegistersServiceWorker(t *testing.T) {
+	h := New(defaultMock())
+	w := do(h, http.MethodGet, "/")
+	body := w.Body.String()
+	for _, want := range []string{"/manifest.webmanifest", "serviceWorker.register('/sw.js')", "/api/offline/reconcile"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("HTMLに %q が含まれていない", want)
+		}
+	}
+}
+
 // ============================================================
 // RegisterRoutes — 統合確認
 // ============================================================
@@ -338,6 +511,11 @@ func TestRegisterRoutes_AllEndpointsReachable(t *testing.T) {
 		{http.MethodGet, "/api/history", http.StatusOK},
 		{http.MethodGet, "/api/stats", http.StatusOK},
 		{http.MethodGet, "/api/prizes", http.StatusOK},
+		{http.MethodGet, "/manifest.webmanifest", http.StatusOK},
+		{http.MethodGet, "/sw.js", http.StatusOK},
+		{http.MethodGet, "/icon.svg", http.StatusOK},
+		{http.MethodGet, "/api/offline/tickets", http.StatusMethodNotAllowed},
+		{http.MethodGet, "/api/offline/reconcile", http.StatusMethodNotAllowed},
 	}
 
 	for _, tc := range endpoints {
@@ -0,0 +1,72 @@
+package handler
+
+// manifestJSON is the PWA web app manifest served at GET /manifest.webmanifest.
+const manifestJSON = `{
+  "name": "商店街ガラガラポン抽選会",
+  "short_name": "ガラガラポン",
+  "lang": "ja",
+  "start_url": "/",
+  "scope": "/",
+  "display": "fullscreen",
+  "orientation": "any",
+  "background_color": "#1a1a2e",
+  "theme_color": "#1a1a2e",
+  "icons": [
+    {"src": "/icon.svg", "sizes": "any", "type": "image/svg+xml", "purpose": "any maskable"}
+  ]
+}
+`
+
+// serviceWorkerJS is served at GET /sw.js.
+//
+// Caching strategy:
+//   - App shell (/, manifest, icon): network-first, falling back to cache.
+//   - GET /api/prizes: network-first, so the last known prize table survives
+//     a dropped connection and the kiosk can keep drawing offline.
+//   - All other /api/* requests always go to the network; the page itself
+//     decides how to fall back (see drawOffline in indexHTML).
+//   - /?kiosk=<key> is never cached, so the kiosk key stays out of the cache.
+const serviceWorkerJS = `'use strict';
+const CACHE = 'garapon-v1';
+const SHELL = ['/', '/manifest.webmanifest', '/icon.svg'];
+
+self.addEventListener('install', e => {
+    e.waitUntil(caches.open(CACHE).then(c => c.addAll(SHELL)).then(() => self.skipWaiting()));
+});
+
+self.addEventListener('activate', e => {
+    e.waitUntil(
+        caches.keys()
+            .then(keys => Promise.all(keys.filter(k => k !== CACHE).map(k => caches.delete(k))))
+            .then(() => self.clients.claim())
+    );
+});
+
+self.addEventListener('fetch', e => {
+    const url = new URL(e.request.url);
+    if (e.request.method !== 'GET' || url.origin !== self.location.origin) return;
+    if (url.pathname.startsWith('/api/') && url.pathname !== '/api/prizes') return;
+    if (url.searchParams.has('kiosk')) return; // キオスクキーを含む URL はキャッシュしない
+
+    e.respondWith(
+        fetch(e.request)
+            .then(res => {
+                if (res.ok) {
+                    const copy = res.clone();
+                    caches.open(CACHE).then(c => c.put(e.request, copy));
+                }
+                return res;
+            })
+            .catch(() => caches.match(e.request).then(hit => hit || Response.error()))
+    );
+});
+`
+
+// iconSVG is the app icon referenced by the manifest.
+const iconSVG = `<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 512 512">
+<rect width="512" height="512" rx="96" fill="#1a1a2e"/>
+<circle cx="256" cy="240" r="150" fill="#444" stroke="#888" stroke-width="16"/>
+<circle cx="256" cy="240" r="56" fill="#FFD700"/>
+<rect x="236" y="390" width="40" height="70" rx="12" fill="#ccc"/>
+</svg>
+`
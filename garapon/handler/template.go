@@ -8,6 +8,9 @@ const indexHTML = `<!DOCTYPE html>
     <meta charset="UTF-8">
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>🎰 商店街ガラガラポン抽選会</title>
+    <meta name="theme-color" content="#1a1a2e">
+    <link rel="manifest" href="/manifest.webmanifest">
+    <link rel="icon" href="/icon.svg" type="image/svg+xml">
     <style>
         *{margin:0;padding:0;box-sizing:border-box;}
         body{font-family:'Hiragino Kaku Gothic Pro','Meiryo',sans-serif;
@@ -89,6 +92,8 @@ const indexHTML = `<!DOCTYPE html>
         .result-grade.show{opacity:1;transform:scale(1);}
         .result-name{font-size:1.4em;color:#ddd;margin-bottom:8px;}
         .result-desc{font-size:1.1em;color:#aaa;}
+        .result-offline{margin-top:8px;font-size:0.9em;color:#f9a;}
+        .stat-chip.offline{background:rgba(255,80,80,0.2);}
         .wait-msg{color:#555;font-size:1.1em;}
 
         /* ---- Prize table ---- */
@@ -172,6 +177,7 @@ const indexHTML = `<!DOCTYPE html>
         <button class="draw-btn" id="drawBtn" onclick="startDraw()">🎲 ガラガラ回す！</button>
         <div class="stats-bar">
             <div class="stat-chip">総抽選数: <span id="totalDraws">0</span>回</div>
+            <div class="stat-chip" id="netStatus">🟢 オンライン</div>
         </div>
     </div>
 
@@ -237,10 +243,18 @@ async function fetchPrizes() {
         const changed = currentPrizes.length > 0 &&
             currentPrizes.some((p, i) => p.weight !== info.prizes[i].weight);
         currentPrizes = info.prizes;
+        saveLS(LS_PRIZES, currentPrizes);
         renderPrizeTable();
         populateDrum();
         if (changed) flashPrizeTable();
-    } catch(e) { console.error('景品取得エラー:', e); }
+    } catch(e) {
+        console.error('景品取得エラー:', e);
+        if (!currentPrizes.length) {
+            currentPrizes = loadLS(LS_PRIZES, []);
+            renderPrizeTable();
+            populateDrum();
+        }
+    }
 }
 
 function renderPrizeTable() {
@@ -308,7 +322,12 @@ async function startDraw() {
 
     let result;
     try {
-        result = await apiFetch('/api/draw');
+        try {
+            result = await apiFetch('/api/draw');
+        } catch(e) {
+            if (!isNetworkError(e)) throw e;
+            result = drawOffline();
+        }
     } catch(e) {
         resultPanel.innerHTML = ` + "`" + `<p style="color:#f66;">エラー: ${e.message}</p>` + "`" + `;
         drum.classList.remove('spinning');
@@ -329,7 +348,8 @@ async function startDraw() {
         resultPanel.innerHTML = ` + "`" + `
             <div class="result-grade ${gradeClass(grade)}" id="rg">${grade}</div>
             <div class="result-name">${result.prize.name}</div>
-            <div class="result-desc">${result.prize.description}</div>` + "`" + `;
+            <div class="result-desc">${result.prize.description}</div>` + "`" + ` +
+            (result.offline ? '<div class="result-offline">📴 オフライン抽選（復旧後に自動送信）</div>' : '');
         setTimeout(() => { const rg=document.getElementById('rg'); if(rg) rg.classList.add('show'); }, 50);
 
         if (['特等','1等','2等'].includes(grade)) {
@@ -390,10 +410,148 @@ function launchConfetti(count) {
     }
 }
 
+/* ---------- Offline (PWA) ---------- */
+// While connected, the kiosk keeps a pool of server-reserved ticket numbers.
+// When /api/draw is unreachable it draws locally from the last known prize
+// table using one of those tickets, queues the result in localStorage and
+// reconciles the queue into the server history once connectivity returns.
+// The offline API needs the kiosk key configured on the server; open the
+// page once as /?kiosk=<key> to store it on the kiosk.
+const LS_KIOSK_KEY = 'garapon.kioskKey';
+const LS_BATCHES = 'garapon.batches';   // [{id, tickets:[n,...]}] unused tickets
+const LS_PENDING = 'garapon.pending';   // [{batch_id, ticket_num, grade, drawn_at}]
+const LS_PRIZES  = 'garapon.prizes';    // last known prize table
+const TICKET_LOW_WATER  = 10;
+const TICKET_BATCH_SIZE = 30;
+
+function loadLS(key, def) {
+    try { const v = JSON.parse(localStorage.getItem(key)); return v === null ? def : v; }
+    catch(e) { return def; }
+}
+function saveLS(key, v) { localStorage.setItem(key, JSON.stringify(v)); }
+
+// fetch() rejects with TypeError only when the request never reached the server.
+function isNetworkError(e) { return e instanceof TypeError; }
+
+function availableTickets() {
+    return loadLS(LS_BATCHES, []).reduce((n, b) => n + b.tickets.length, 0);
+}
+
+(function storeKioskKey() {
+    const url = new URL(location.href);
+    const key = url.searchParams.get('kiosk');
+    if (key === null) return;
+    saveLS(LS_KIOSK_KEY, key);
+    url.searchParams.delete('kiosk');
+    history.replaceState(null, '', url.pathname + url.search + url.hash);
+})();
+
+function postJSON(path, body) {
+    return apiFetch(path, {
+        method: 'POST',
+        headers: {'Content-Type': 'application/json', 'X-Garapon-Kiosk-Key': loadLS(LS_KIOSK_KEY, '')},
+        body: JSON.stringify(body),
+    });
+}
+
+async function ensureTickets() {
+    if (!loadLS(LS_KIOSK_KEY, '')) return; // キオスク以外の端末ではオフライン抽選しない
+    if (!navigator.onLine || availableTickets() >= TICKET_LOW_WATER) return;
+    try {
+        const batch = await postJSON('/api/offline/tickets', {count: TICKET_BATCH_SIZE});
+        const batches = loadLS(LS_BATCHES, []);
+        batches.push({id: batch.id, tickets: batch.tickets});
+        saveLS(LS_BATCHES, batches);
+    } catch(e) { console.error('整理券予約エラー:', e); }
+    updateNetStatus();
+}
+
+function takeTicket() {
+    const batches = loadLS(LS_BATCHES, []).filter(b => b.tickets.length > 0);
+    if (!batches.length) return null;
+    const ticket = {batch_id: batches[0].id, ticket_num: batches[0].tickets.shift()};
+    saveLS(LS_BATCHES, batches);
+    return ticket;
+}
+
+function drawOffline() {
+    if (!currentPrizes.length) throw new Error('景品テーブルが未取得のためオフライン抽選できません');
+    const ticket = takeTicket();
+    if (!ticket) throw new Error('オフライン用の整理券がありません');
+
+    const total = currentPrizes.reduce((s, p) => s + p.weight, 0);
+    let n = Math.floor(Math.random() * total);
+    let selected = currentPrizes[currentPrizes.length - 1];
+    for (const p of currentPrizes) {
+        if (n < p.weight) { selected = p; break; }
+        n -= p.weight;
+    }
+
+    const drawnAt = new Date().toISOString();
+    const pending = loadLS(LS_PENDING, []);
+    pending.push({batch_id: ticket.batch_id, ticket_num: ticket.ticket_num, grade: selected.grade, drawn_at: drawnAt});
+    saveLS(LS_PENDING, pending);
+    updateNetStatus();
+    return {prize: selected, drawn_at: drawnAt, ticket_num: ticket.ticket_num, offline: true};
+}
+
+let reconciling = false;
+async function reconcilePending() {
+    const pending = loadLS(LS_PENDING, []);
+    if (reconciling || !pending.length || !navigator.onLine) return;
+    reconciling = true;
+
+    const byBatch = {};
+    pending.forEach(d => (byBatch[d.batch_id] = byBatch[d.batch_id] || []).push(d));
+    const done = new Set();
+    for (const [batchId, draws] of Object.entries(byBatch)) {
+        try {
+            const res = await postJSON('/api/offline/reconcile', {
+                batch_id: batchId,
+                draws: draws.map(d => ({ticket_num: d.ticket_num, grade: d.grade, drawn_at: d.drawn_at})),
+            });
+            res.rejected.forEach(r => console.warn('オフライン抽選の取り込み拒否:', r.ticket_num, r.reason));
+            draws.forEach(d => done.add(d.ticket_num));
+        } catch(e) {
+            // Network failures are retried later; a server-side rejection
+            // (e.g. unknown batch after a restart) will never succeed.
+            if (!isNetworkError(e)) draws.forEach(d => done.add(d.ticket_num));
+            console.error('オフライン抽選の送信エラー:', e);
+        }
+    }
+    // Re-read: draws may have been queued while the requests were in flight.
+    saveLS(LS_PENDING, loadLS(LS_PENDING, []).filter(d => !done.has(d.ticket_num)));
+    reconciling = false;
+    updateNetStatus();
+}
+
+function updateNetStatus() {
+    const el = document.getElementById('netStatus');
+    if (!el) return;
+    const pending = loadLS(LS_PENDING, []).length;
+    const tickets = availableTickets();
+    const label = navigator.onLine ? '🟢 オンライン' : '🔴 オフライン';
+    el.textContent = label + '（未送信 ' + pending + ' 件／整理券 ' + tickets + ' 枚）';
+    el.classList.toggle('offline', !navigator.onLine);
+}
+
+function syncOffline() {
+    updateNetStatus();
+    reconcilePending();
+    ensureTickets();
+}
+
 /* ---------- Bootstrap ---------- */
+if ('serviceWorker' in navigator) {
+    navigator.serviceWorker.register('/sw.js').catch(e => console.error('Service Worker 登録エラー:', e));
+}
+window.addEventListener('online', syncOffline);
+window.addEventListener('offline', updateNetStatus);
 setInterval(updateCountdown, 1000);
 setInterval(fetchPrizes, 5000);
+setInterval(syncOffline, 15000);
 fetchPrizes();
+syncOffline();
 </script>
 </body>
 </html>`
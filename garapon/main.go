@@ -1,14 +1,20 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"garapon/handler"
 	"garapon/service"
+	"garapon/webhook"
 )
 
 // バージョン情報は make build 時に -ldflags で注入される
@@ -21,10 +27,14 @@ var (
 const (
 	rotationInterval = 30 * time.Second
 	listenAddr       = ":8081"
+	// kioskKeyEnv names the environment variable holding the kiosk key that
+	// enables the offline API (kiosks open /?kiosk=<key> once to store it).
+	kioskKeyEnv = "GARAPON_KIOSK_KEY"
 )
 
 func main() {
 	showVersion := flag.Bool("version", false, "バージョン情報を表示して終了")
+	webhookConfig := flag.String("webhooks", "", "当選通知 webhook の設定ファイル（JSON）")
 	flag.Parse()
 
 	if *showVersion {
@@ -33,7 +43,23 @@ func main() {
 	}
 
 	svc := service.New(rotationInterval)
-	h := handler.New(svc)
+	var dispatcher *webhook.Dispatcher
+	if *webhookConfig != "" {
+		cfg, err := webhook.LoadConfig(*webhookConfig)
+		if err != nil {
+			log.Fatalf("webhook 設定エラー: %v", err)
+		}
+		dispatcher = webhook.New(cfg)
+		svc = webhook.Wrap(svc, dispatcher)
+		fmt.Printf("📣 webhook 通知先: %d 件\n", len(cfg.Endpoints))
+	}
+	// オフライン抽選の結果はクライアント申告なので、キーを知るキオスクだけに受け付ける
+	var opts []handler.Option
+	if key := os.Getenv(kioskKeyEnv); key != "" {
+		opts = append(opts, handler.WithKioskKey(key))
+		fmt.Println("📴 オフライン抽選: 有効")
+	}
+	h := handler.New(svc, opts...)
 
 	mux := http.NewServeMux()
 	h.RegisterRoutes(mux)
@@ -42,7 +68,30 @@ func main() {
 	fmt.Printf("🌐 http://localhost%s にアクセスしてください\n", listenAddr)
 	fmt.Printf("🔄 当選確率は %v ごとに自動変更されます\n", rotationInterval)
 
-	if err := http.ListenAndServe(listenAddr, mux); err != nil {
-		log.Fatalf("サーバー起動エラー: %v", err)
+	srv := &http.Server{Addr: listenAddr, Handler: mux}
+	errCh := make(chan error, 1)
+	go func() { errCh <- srv.ListenAndServe() }()
+
+	// SIGINT / SIGTERM で受付を止め、送信待ちの webhook を送り切ってから終了する
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			if dispatcher != nil {
+				dispatcher.Close()
+			}
+			log.Fatalf("サーバー起動エラー: %v", err)
+		}
+	case <-ctx.Done():
+		fmt.Fprintln(os.Stderr, "\n終了中...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("シャットダウンエラー: %v", err)
+		}
+	}
+	if dispatcher != nil {
+		dispatcher.Close()
 	}
 }
@@ -53,6 +53,44 @@ type PrizesInfo struct {
 	RotationIntervalSec int       `json:"rotation_interval_sec"`
 }
 
+// TicketBatch is a block of ticket numbers reserved by the server so that a
+// kiosk can keep drawing while offline without colliding with online draws.
+type TicketBatch struct {
+	ID       string    `json:"id"`
+	Tickets  []int     `json:"tickets"`
+	IssuedAt time.Time `json:"issued_at"`
+}
+
+// TicketBatchRequest is the JSON body of POST /api/offline/tickets.
+type TicketBatchRequest struct {
+	Count int `json:"count"`
+}
+
+// OfflineDraw is one draw performed locally by a kiosk while disconnected.
+type OfflineDraw struct {
+	TicketNum int        `json:"ticket_num"`
+	Grade     PrizeGrade `json:"grade"`
+	DrawnAt   time.Time  `json:"drawn_at"`
+}
+
+// ReconcileRequest is the JSON body of POST /api/offline/reconcile.
+type ReconcileRequest struct {
+	BatchID string        `json:"batch_id"`
+	Draws   []OfflineDraw `json:"draws"`
+}
+
+// RejectedDraw explains why an offline draw was not merged into history.
+type RejectedDraw struct {
+	TicketNum int    `json:"ticket_num"`
+	Reason    string `json:"reason"`
+}
+
+// ReconcileResult reports which offline draws were merged into history.
+type ReconcileResult struct {
+	Accepted []DrawResult   `json:"accepted"`
+	Rejected []RejectedDraw `json:"rejected"`
+}
+
 // ErrorResponse is the JSON body returned on API errors.
 type ErrorResponse struct {
 	Error string `json:"error"`
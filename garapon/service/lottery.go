@@ -43,6 +43,8 @@ type LotteryService interface {
 	History() []model.DrawResult
 	Stats() model.Stats
 	Prizes() model.PrizesInfo
+	ReserveTickets(count int) (model.TicketBatch, error)
+	Reconcile(batchID string, draws []model.OfflineDraw) (model.ReconcileResult, error)
 }
 
 type lotteryService struct {
@@ -51,6 +53,7 @@ type lotteryService struct {
 	history       []model.DrawResult
 	historyMu     sync.Mutex
 	ticketCount   int
+	batches       map[string]*batch // batch ID → 予約済み整理券
 	nextRotateAt  time.Time
 	lastRotatedAt time.Time
 	interval      time.Duration
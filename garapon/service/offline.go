@@ -0,0 +1,158 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"garapon/model"
+)
+
+const (
+	// maxOfflineBatch caps how many tickets a kiosk may reserve at once.
+	maxOfflineBatch = 200
+	// batchTTL is how long a reserved batch can be reconciled.
+	batchTTL = 24 * time.Hour
+	// maxOpenBatches caps the unexpired batches kept at once.
+	maxOpenBatches = 64
+)
+
+// ErrBatchNotFound is returned by Reconcile for an unknown batch ID.
+var ErrBatchNotFound = errors.New("オフライン整理券バッチが見つかりません")
+
+// ErrTooManyBatches is returned by ReserveTickets when maxOpenBatches
+// unexpired batches are already open.
+var ErrTooManyBatches = errors.New("未精算のオフライン整理券バッチが多すぎます")
+
+// batch is a reserved block of tickets awaiting reconciliation.
+type batch struct {
+	unused   map[int]bool // 整理券番号 → 未使用なら true
+	issuedAt time.Time
+}
+
+// ReserveTickets allocates count consecutive ticket numbers for offline use.
+// The numbers are removed from the online sequence immediately, so draws made
+// through Draw can never reuse them.
+//
+// Batches are kept in memory only: they expire after batchTTL, at most
+// maxOpenBatches may be open at once (ErrTooManyBatches beyond that), and all
+// of them are lost on restart (the kiosk then drops its queued draws, as it
+// does for any unknown batch).
+func (s *lotteryService) ReserveTickets(count int) (model.TicketBatch, error) {
+	if count <= 0 || count > maxOfflineBatch {
+		return model.TicketBatch{}, fmt.Errorf("予約枚数は 1〜%d の範囲で指定してください", maxOfflineBatch)
+	}
+	id, err := newBatchID()
+	if err != nil {
+		return model.TicketBatch{}, err
+	}
+
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+	now := time.Now()
+	s.pruneBatches(now)
+	if len(s.batches) >= maxOpenBatches {
+		return model.TicketBatch{}, ErrTooManyBatches
+	}
+	tickets := make([]int, count)
+	unused := make(map[int]bool, count)
+	for i := range tickets {
+		s.ticketCount++
+		tickets[i] = s.ticketCount
+		unused[s.ticketCount] = true
+	}
+	s.batches[id] = &batch{unused: unused, issuedAt: now}
+
+	return model.TicketBatch{ID: id, Tickets: tickets, IssuedAt: now}, nil
+}
+
+// pruneBatches drops expired batches. Caller must hold historyMu.
+func (s *lotteryService) pruneBatches(now time.Time) {
+	if s.batches == nil {
+		s.batches = make(map[string]*batch)
+	}
+	for id, b := range s.batches {
+		if now.Sub(b.issuedAt) > batchTTL {
+			delete(s.batches, id)
+		}
+	}
+}
+
+// Reconcile merges draws performed offline into history. Each draw must use
+// an unused ticket from the given batch and name a grade present in the
+// current prize table; anything else is rejected with a reason.
+// Accepted draws are interleaved into history by DrawnAt.
+func (s *lotteryService) Reconcile(batchID string, draws []model.OfflineDraw) (model.ReconcileResult, error) {
+	s.prizeMu.RLock()
+	byGrade := make(map[model.PrizeGrade]model.Prize, len(s.prizes))
+	for _, p := range s.prizes {
+		byGrade[p.Grade] = p
+	}
+	s.prizeMu.RUnlock()
+
+	s.historyMu.Lock()
+	defer s.historyMu.Unlock()
+
+	b, ok := s.batches[batchID]
+	if ok && time.Since(b.issuedAt) > batchTTL {
+		delete(s.batches, batchID)
+		ok = false
+	}
+	if !ok {
+		return model.ReconcileResult{}, ErrBatchNotFound
+	}
+
+	res := model.ReconcileResult{
+		Accepted: []model.DrawResult{},
+		Rejected: []model.RejectedDraw{},
+	}
+	now := time.Now()
+	for _, d := range draws {
+		used, issued := b.unused[d.TicketNum]
+		prize, known := byGrade[d.Grade]
+		switch {
+		case !issued:
+			res.Rejected = append(res.Rejected, model.RejectedDraw{TicketNum: d.TicketNum, Reason: "このバッチの整理券ではありません"})
+			continue
+		case !used:
+			res.Rejected = append(res.Rejected, model.RejectedDraw{TicketNum: d.TicketNum, Reason: "使用済みの整理券です"})
+			continue
+		case !known:
+			res.Rejected = append(res.Rejected, model.RejectedDraw{TicketNum: d.TicketNum, Reason: "不明な等級です: " + string(d.Grade)})
+			continue
+		}
+		b.unused[d.TicketNum] = false
+
+		drawnAt := d.DrawnAt
+		if drawnAt.IsZero() || drawnAt.After(now) {
+			drawnAt = now
+		}
+		res.Accepted = append(res.Accepted, model.DrawResult{
+			Prize:     prize,
+			DrawnAt:   drawnAt,
+			TicketNum: d.TicketNum,
+		})
+	}
+
+	if len(res.Accepted) > 0 {
+		s.history = append(s.history, res.Accepted...)
+		sort.SliceStable(s.history, func(i, j int) bool {
+			return s.history[i].DrawnAt.After(s.history[j].DrawnAt)
+		})
+		if len(s.history) > maxHistory {
+			s.history = s.history[:maxHistory]
+		}
+	}
+	return res, nil
+}
+
+func newBatchID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("バッチID生成エラー: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
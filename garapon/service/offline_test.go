@@ -0,0 +1,167 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"garapon/model"
+)
+
+// ============================================================
+// ReserveTickets
+// ============================================================
+
+func TestReserveTickets_ReturnsConsecutiveNumbers(t *testing.T) {
+	svc := NewWithoutRotation()
+	batch, err := svc.ReserveTickets(5)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if batch.ID == "" {
+		t.Error("バッチIDが空")
+	}
+	for i, n := range batch.Tickets {
+		if n != i+1 {
+			t.Errorf("Tickets[%d]: got %d, want %d", i, n, i+1)
+		}
+	}
+}
+
+func TestReserveTickets_OnlineDrawSkipsReservedNumbers(t *testing.T) {
+	svc := NewWithoutRotation()
+	svc.Draw()             //nolint:errcheck // ticket 1
+	svc.ReserveTickets(10) //nolint:errcheck // tickets 2–11
+	r, _ := svc.Draw()
+	if r.TicketNum != 12 {
+		t.Errorf("予約後のオンライン抽選番号: got %d, want 12", r.TicketNum)
+	}
+}
+
+func TestReserveTickets_RejectsOutOfRangeCount(t *testing.T) {
+	svc := NewWithoutRotation()
+	for _, n := range []int{0, -1, maxOfflineBatch + 1} {
+		if _, err := svc.ReserveTickets(n); err == nil {
+			t.Errorf("count=%d でエラーにならない", n)
+		}
+	}
+}
+
+// ============================================================
+// Reconcile
+// ============================================================
+
+func TestReconcile_MergesIntoHistoryByDrawnAt(t *testing.T) {
+	svc := NewWithoutRotation()
+	batch, _ := svc.ReserveTickets(3)
+	online, _ := svc.Draw()
+
+	earlier := online.DrawnAt.Add(-10 * time.Minute)
+	res, err := svc.Reconcile(batch.ID, []model.OfflineDraw{
+		{TicketNum: batch.Tickets[0], Grade: model.GradeTokutou, DrawnAt: earlier},
+		{TicketNum: batch.Tickets[1], Grade: model.GradeHazure, DrawnAt: earlier.Add(time.Minute)},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Accepted) != 2 || len(res.Rejected) != 0 {
+		t.Fatalf("結果不正: %+v", res)
+	}
+	if res.Accepted[0].Prize.Name != "特等賞" {
+		t.Errorf("景品が等級から補完されていない: %+v", res.Accepted[0].Prize)
+	}
+
+	hist := svc.History()
+	if len(hist) != 3 {
+		t.Fatalf("履歴件数: got %d, want 3", len(hist))
+	}
+	if hist[0].TicketNum != online.TicketNum || hist[2].TicketNum != batch.Tickets[0] {
+		t.Errorf("履歴が DrawnAt 降順になっていない: %+v", hist)
+	}
+	if got := svc.Stats().GradeCount[string(model.GradeTokutou)]; got != 1 {
+		t.Errorf("統計の特等件数: got %d, want 1", got)
+	}
+}
+
+func TestReconcile_RejectsReusedForeignAndUnknownGrade(t *testing.T) {
+	svc := NewWithoutRotation()
+	batch, _ := svc.ReserveTickets(2)
+	now := time.Now()
+	res, err := svc.Reconcile(batch.ID, []model.OfflineDraw{
+		{TicketNum: batch.Tickets[0], Grade: model.GradeHazure, DrawnAt: now},
+		{TicketNum: batch.Tickets[0], Grade: model.GradeHazure, DrawnAt: now}, // 二重使用
+		{TicketNum: 999, Grade: model.GradeHazure, DrawnAt: now},              // 他バッチ
+		{TicketNum: batch.Tickets[1], Grade: "5等", DrawnAt: now},              // 不明な等級
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(res.Accepted) != 1 || len(res.Rejected) != 3 {
+		t.Errorf("accepted=%d rejected=%d, want 1/3", len(res.Accepted), len(res.Rejected))
+	}
+
+	// 不明な等級で拒否された整理券は未使用のまま残る
+	res, _ = svc.Reconcile(batch.ID, []model.OfflineDraw{
+		{TicketNum: batch.Tickets[1], Grade: model.GradeYontou, DrawnAt: now},
+	})
+	if len(res.Accepted) != 1 {
+		t.Errorf("再送が受理されない: %+v", res)
+	}
+}
+
+func TestReconcile_ExpiredBatch(t *testing.T) {
+	svc := NewWithoutRotation()
+	batch, _ := svc.ReserveTickets(1)
+	impl := asImpl(svc)
+	impl.batches[batch.ID].issuedAt = time.Now().Add(-batchTTL - time.Minute)
+
+	_, err := svc.Reconcile(batch.ID, []model.OfflineDraw{{TicketNum: batch.Tickets[0], Grade: model.GradeHazure}})
+	if !errors.Is(err, ErrBatchNotFound) {
+		t.Errorf("err: got %v, want ErrBatchNotFound", err)
+	}
+	if _, ok := impl.batches[batch.ID]; ok {
+		t.Error("期限切れのバッチが残っている")
+	}
+}
+
+func TestReserveTickets_CapsOpenBatches(t *testing.T) {
+	svc := NewWithoutRotation()
+	expired, _ := svc.ReserveTickets(1)
+	asImpl(svc).batches[expired.ID].issuedAt = time.Now().Add(-batchTTL - time.Minute)
+	first, _ := svc.ReserveTickets(1)
+	for i := 1; i < maxOpenBatches; i++ {
+		if _, err := svc.ReserveTickets(1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if n := len(asImpl(svc).batches); n != maxOpenBatches {
+		t.Errorf("保持バッチ数: got %d, want %d", n, maxOpenBatches)
+	}
+	if _, err := svc.ReserveTickets(1); !errors.Is(err, ErrTooManyBatches) {
+		t.Errorf("上限到達時: got %v, want ErrTooManyBatches", err)
+	}
+	// 期限内のバッチは上限に達しても破棄しない
+	if _, err := svc.Reconcile(first.ID, nil); err != nil {
+		t.Errorf("期限内のバッチが破棄された: %v", err)
+	}
+}
+
+func TestReconcile_UnknownBatch(t *testing.T) {
+	svc := NewWithoutRotation()
+	_, err := svc.Reconcile("missing", nil)
+	if !errors.Is(err, ErrBatchNotFound) {
+		t.Errorf("err: got %v, want ErrBatchNotFound", err)
+	}
+}
+
+func TestReconcile_FutureTimestampClampedToNow(t *testing.T) {
+	svc := NewWithoutRotation()
+	batch, _ := svc.ReserveTickets(1)
+	before := time.Now()
+	res, _ := svc.Reconcile(batch.ID, []model.OfflineDraw{
+		{TicketNum: batch.Tickets[0], Grade: model.GradeHazure, DrawnAt: before.Add(time.Hour)},
+	})
+	if len(res.Accepted) != 1 || res.Accepted[0].DrawnAt.After(time.Now()) {
+		t.Errorf("未来の DrawnAt が補正されていない: %+v", res.Accepted)
+	}
+}
@@ -0,0 +1,497 @@
+// Package webhook delivers outbound HTTP notifications when selected prizes are drawn.
+//
+// Deliveries are asynchronous: LotteryService.Draw never waits on a webhook.
+// Each endpoint receives a JSON POST in one of three payload formats
+// (generic / Slack / LINE), optionally signed with HMAC-SHA256. Failed
+// deliveries are retried with exponential backoff and, once attempts are
+// exhausted, recorded as dead letters.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"garapon/model"
+	"garapon/service"
+)
+
+// Header names attached to every delivery.
+const (
+	HeaderSignature = "X-Garapon-Signature"
+	HeaderTimestamp = "X-Garapon-Timestamp"
+	HeaderEvent     = "X-Garapon-Event"
+)
+
+const (
+	eventPrizeDrawn = "prize.drawn"
+	queueSize       = 256
+	workerCount     = 2
+)
+
+// Format selects the payload template sent to an endpoint.
+type Format string
+
+const (
+	FormatGeneric Format = "generic"
+	FormatSlack   Format = "slack"
+	FormatLINE    Format = "line"
+)
+
+// HTTPDoer is the interface satisfied by *http.Client, enabling test injection.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Endpoint is one configured webhook receiver.
+type Endpoint struct {
+	Name    string             `json:"name"`
+	URL     string             `json:"url"`
+	Format  Format             `json:"format"`
+	Grades  []model.PrizeGrade `json:"grades"`
+	Secret  string             `json:"secret,omitempty"`
+	Headers map[string]string  `json:"headers,omitempty"`
+	// LineTo is the LINE Messaging API push destination (userId / groupId).
+	LineTo string `json:"line_to,omitempty"`
+}
+
+// wants reports whether the endpoint subscribes to grade.
+func (e Endpoint) wants(grade model.PrizeGrade) bool {
+	for _, g := range e.Grades {
+		if g == grade {
+			return true
+		}
+	}
+	return false
+}
+
+// Config is the full webhook configuration, normally loaded from a JSON file.
+type Config struct {
+	Endpoints      []Endpoint `json:"endpoints"`
+	MaxAttempts    int        `json:"max_attempts"`
+	InitialBackoff Duration   `json:"initial_backoff"`
+	MaxBackoff     Duration   `json:"max_backoff"`
+	// DeadLetterPath, when set, receives one JSON line per abandoned delivery.
+	DeadLetterPath string `json:"dead_letter_path,omitempty"`
+}
+
+// DefaultGrades are the grades notified when an endpoint omits "grades".
+var DefaultGrades = []model.PrizeGrade{model.GradeTokutou, model.GradeIttou}
+
+// withDefaults fills zero-valued settings with sensible defaults.
+func (c Config) withDefaults() Config {
+	if c.MaxAttempts <= 0 {
+		c.MaxAttempts = 5
+	}
+	if c.InitialBackoff <= 0 {
+		c.InitialBackoff = Duration(500 * time.Millisecond)
+	}
+	if c.MaxBackoff <= 0 {
+		c.MaxBackoff = Duration(30 * time.Second)
+	}
+	eps := make([]Endpoint, len(c.Endpoints))
+	for i, e := range c.Endpoints {
+		if e.Format == "" {
+			e.Format = FormatGeneric
+		}
+		if len(e.Grades) == 0 {
+			e.Grades = DefaultGrades
+		}
+		if e.Name == "" {
+			e.Name = e.URL
+		}
+		eps[i] = e
+	}
+	c.Endpoints = eps
+	return c
+}
+
+// Validate checks the configuration for obvious mistakes.
+func (c Config) Validate() error {
+	for i, e := range c.Endpoints {
+		if e.URL == "" {
+			return fmt.Errorf("endpoints[%d]: url が未設定です", i)
+		}
+		switch e.Format {
+		case "", FormatGeneric, FormatSlack, FormatLINE:
+		default:
+			return fmt.Errorf("endpoints[%d]: 未知の format %q", i, e.Format)
+		}
+	}
+	return nil
+}
+
+// LoadConfig reads a JSON webhook configuration file.
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("webhook 設定の読み込みエラー: %w", err)
+	}
+	var cfg Config
+	if err := json.Unmarshal(b, &cfg); err != nil {
+		return Config{}, fmt.Errorf("webhook 設定のパースエラー: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Duration is a time.Duration that marshals to/from strings such as "500ms".
+type Duration time.Duration
+
+// UnmarshalJSON accepts either a duration string or a number of nanoseconds.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err == nil {
+		v, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		*d = Duration(v)
+		return nil
+	}
+	var n int64
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("duration の形式が不正です: %s", b)
+	}
+	*d = Duration(n)
+	return nil
+}
+
+// MarshalJSON encodes the duration as a string.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DeadLetter records a delivery that was abandoned after all retries.
+type DeadLetter struct {
+	Endpoint  string          `json:"endpoint"`
+	URL       string          `json:"url"`
+	Payload   json.RawMessage `json:"payload"`
+	Attempts  int             `json:"attempts"`
+	LastError string          `json:"last_error"`
+	FailedAt  time.Time       `json:"failed_at"`
+}
+
+type job struct {
+	endpoint Endpoint
+	result   model.DrawResult
+}
+
+// Dispatcher queues draw results and delivers them to matching endpoints.
+type Dispatcher struct {
+	cfg   Config
+	http  HTTPDoer
+	queue chan job
+	done  chan struct{} // Close で閉じ、リトライ待ちを打ち切る
+	wg    sync.WaitGroup
+
+	closeOnce sync.Once
+	mu        sync.Mutex
+	closed    bool
+	dead      []DeadLetter
+
+	fileMu sync.Mutex // dead letter ファイルへの追記を直列化する（mu とは別）
+}
+
+// New returns a Dispatcher with a production HTTP client (10s timeout)
+// and starts its delivery workers.
+func New(cfg Config) *Dispatcher {
+	return NewWithHTTP(cfg, &http.Client{Timeout: 10 * time.Second})
+}
+
+// NewWithHTTP returns a Dispatcher using the provided HTTPDoer (for testing).
+func NewWithHTTP(cfg Config, h HTTPDoer) *Dispatcher {
+	d := &Dispatcher{
+		cfg:   cfg.withDefaults(),
+		http:  h,
+		queue: make(chan job, queueSize),
+		done:  make(chan struct{}),
+	}
+	for i := 0; i < workerCount; i++ {
+		d.wg.Add(1)
+		go d.worker()
+	}
+	return d
+}
+
+// Notify enqueues r for every endpoint subscribed to its grade. It never blocks:
+// if the queue is full the delivery is recorded as a dead letter immediately.
+func (d *Dispatcher) Notify(r model.DrawResult) {
+	var dropped []DeadLetter
+	d.mu.Lock()
+	if d.closed {
+		d.mu.Unlock()
+		return
+	}
+	for _, e := range d.cfg.Endpoints {
+		if !e.wants(r.Prize.Grade) {
+			continue
+		}
+		select {
+		case d.queue <- job{endpoint: e, result: r}:
+		default:
+			payload, _ := buildPayload(e, r)
+			dl := newDeadLetter(e, payload, 0, errors.New("送信キューが満杯です"))
+			d.dead = append(d.dead, dl)
+			dropped = append(dropped, dl)
+		}
+	}
+	d.mu.Unlock()
+
+	// ファイル書き込みはロックの外で行い、同時に抽選する Draw を待たせない
+	for _, dl := range dropped {
+		d.persistDead(dl)
+	}
+}
+
+// Close stops accepting new notifications and waits for queued deliveries
+// (including their retries) to finish. Retries no longer wait out their
+// backoff once Close is called, so shutdown is bounded by the request
+// timeouts rather than the backoff schedule.
+func (d *Dispatcher) Close() {
+	d.closeOnce.Do(func() {
+		d.mu.Lock()
+		d.closed = true
+		close(d.queue)
+		close(d.done)
+		d.mu.Unlock()
+		d.wg.Wait()
+	})
+}
+
+// DeadLetters returns a copy of all deliveries abandoned so far.
+func (d *Dispatcher) DeadLetters() []DeadLetter {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	cp := make([]DeadLetter, len(d.dead))
+	copy(cp, d.dead)
+	return cp
+}
+
+func (d *Dispatcher) worker() {
+	defer d.wg.Done()
+	for j := range d.queue {
+		d.deliver(j)
+	}
+}
+
+// deliver sends one job, retrying retryable failures with exponential backoff.
+func (d *Dispatcher) deliver(j job) {
+	payload, err := buildPayload(j.endpoint, j.result)
+	if err != nil {
+		d.recordDead(j.endpoint, payload, 0, err)
+		return
+	}
+
+	backoff := time.Duration(d.cfg.InitialBackoff)
+	var lastErr error
+	for attempt := 1; attempt <= d.cfg.MaxAttempts; attempt++ {
+		retry, err := d.post(j.endpoint, payload)
+		if err == nil {
+			return
+		}
+		lastErr = err
+		if !retry || attempt == d.cfg.MaxAttempts {
+			d.recordDead(j.endpoint, payload, attempt, lastErr)
+			return
+		}
+		timer := time.NewTimer(backoff)
+		select {
+		case <-timer.C:
+		case <-d.done:
+			timer.Stop() // 終了処理中は待たずに残りの試行を行う
+		}
+		backoff *= 2
+		if backoff > time.Duration(d.cfg.MaxBackoff) {
+			backoff = time.Duration(d.cfg.MaxBackoff)
+		}
+	}
+}
+
+// post performs a single HTTP attempt. The bool result reports whether the
+// failure is worth retrying (network errors, 429 and 5xx).
+func (d *Dispatcher) post(e Endpoint, payload []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.URL, bytes.NewReader(payload))
+	if err != nil {
+		return false, fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set(HeaderEvent, eventPrizeDrawn)
+	for k, v := range e.Headers {
+		req.Header.Set(k, v)
+	}
+	if e.Secret != "" {
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		req.Header.Set(HeaderTimestamp, ts)
+		req.Header.Set(HeaderSignature, Sign(e.Secret, ts, payload))
+	}
+
+	resp, err := d.http.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("HTTPリクエストエラー: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("HTTPステータス %d", resp.StatusCode)
+}
+
+func (d *Dispatcher) recordDead(e Endpoint, payload []byte, attempts int, err error) {
+	dl := newDeadLetter(e, payload, attempts, err)
+	d.mu.Lock()
+	d.dead = append(d.dead, dl)
+	d.mu.Unlock()
+	d.persistDead(dl)
+}
+
+func newDeadLetter(e Endpoint, payload []byte, attempts int, err error) DeadLetter {
+	return DeadLetter{
+		Endpoint:  e.Name,
+		URL:       e.URL,
+		Payload:   json.RawMessage(payload),
+		Attempts:  attempts,
+		LastError: err.Error(),
+		FailedAt:  time.Now(),
+	}
+}
+
+// persistDead logs dl and appends it to the dead letter file, if any.
+// It must not be called with d.mu held.
+func (d *Dispatcher) persistDead(dl DeadLetter) {
+	log.Printf("webhook %q への送信を断念しました（%d 回試行）: %s", dl.Endpoint, dl.Attempts, dl.LastError)
+
+	if d.cfg.DeadLetterPath == "" {
+		return
+	}
+	d.fileMu.Lock()
+	defer d.fileMu.Unlock()
+	f, ferr := os.OpenFile(d.cfg.DeadLetterPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if ferr != nil {
+		log.Printf("dead letter ファイルを開けません: %v", ferr)
+		return
+	}
+	defer f.Close()
+	if err := json.NewEncoder(f).Encode(dl); err != nil {
+		log.Printf("dead letter の書き込みエラー: %v", err)
+	}
+}
+
+// Sign returns the signature header value for payload: "sha256=" followed by
+// the hex HMAC-SHA256 of "<timestamp>.<payload>" keyed with secret.
+// Receivers should recompute it and compare with hmac.Equal.
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// ---- payload templates ----
+
+// Event is the generic JSON payload.
+type Event struct {
+	Event       string           `json:"event"`
+	Grade       model.PrizeGrade `json:"grade"`
+	PrizeName   string           `json:"prize_name"`
+	Description string           `json:"description"`
+	TicketNum   int              `json:"ticket_num"`
+	DrawnAt     time.Time        `json:"drawn_at"`
+}
+
+type slackPayload struct {
+	Text string `json:"text"`
+}
+
+type lineMessage struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type linePayload struct {
+	To       string        `json:"to,omitempty"`
+	Messages []lineMessage `json:"messages"`
+}
+
+// message renders the human-readable announcement used by chat formats.
+func message(r model.DrawResult) string {
+	return fmt.Sprintf("🎉 %s が出ました！ 抽選番号 #%d ／ %s",
+		r.Prize.Name, r.TicketNum, r.Prize.Description)
+}
+
+func buildPayload(e Endpoint, r model.DrawResult) ([]byte, error) {
+	switch e.Format {
+	case FormatSlack:
+		return json.Marshal(slackPayload{Text: message(r)})
+	case FormatLINE:
+		return json.Marshal(linePayload{
+			To:       e.LineTo,
+			Messages: []lineMessage{{Type: "text", Text: message(r)}},
+		})
+	default:
+		return json.Marshal(Event{
+			Event:       eventPrizeDrawn,
+			Grade:       r.Prize.Grade,
+			PrizeName:   r.Prize.Name,
+			Description: r.Prize.Description,
+			TicketNum:   r.TicketNum,
+			DrawnAt:     r.DrawnAt,
+		})
+	}
+}
+
+// ---- service decorator ----
+
+// notifyingService wraps a LotteryService and forwards successful draws to a Dispatcher.
+type notifyingService struct {
+	service.LotteryService
+	d *Dispatcher
+}
+
+// Wrap returns a LotteryService that behaves like svc but notifies d after
+// every successful Draw.
+func Wrap(svc service.LotteryService, d *Dispatcher) service.LotteryService {
+	return &notifyingService{LotteryService: svc, d: d}
+}
+
+// Draw performs the underlying draw and enqueues a notification on success.
+func (s *notifyingService) Draw() (model.DrawResult, error) {
+	r, err := s.LotteryService.Draw()
+	if err == nil {
+		s.d.Notify(r)
+	}
+	return r, err
+}
+
+// Reconcile merges offline draws and notifies d of every accepted draw, so a
+// jackpot drawn while the kiosk was offline is still announced on recovery.
+func (s *notifyingService) Reconcile(batchID string, draws []model.OfflineDraw) (model.ReconcileResult, error) {
+	res, err := s.LotteryService.Reconcile(batchID, draws)
+	if err == nil {
+		for _, r := range res.Accepted {
+			s.d.Notify(r)
+		}
+	}
+	return res, err
+}
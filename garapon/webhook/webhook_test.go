@@ -0,0 +1,289 @@
+package webhook
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"garapon/model"
+	"garapon/service"
+)
+
+// ============================================================
+// ヘルパー
+// ============================================================
+
+// receiver is a local httptest server that records every request body.
+type receiver struct {
+	srv    *httptest.Server
+	mu     sync.Mutex
+	bodies [][]byte
+	heads  []http.Header
+	calls  atomic.Int32
+	// failFirst makes the first N requests return status.
+	failFirst int32
+	status    int
+}
+
+func newReceiver(t *testing.T) *receiver {
+	t.Helper()
+	rc := &receiver{status: http.StatusInternalServerError}
+	rc.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := rc.calls.Add(1)
+		b, _ := io.ReadAll(r.Body)
+		if n <= rc.failFirst {
+			w.WriteHeader(rc.status)
+			return
+		}
+		rc.mu.Lock()
+		rc.bodies = append(rc.bodies, b)
+		rc.heads = append(rc.heads, r.Header.Clone())
+		rc.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(rc.srv.Close)
+	return rc
+}
+
+func (rc *receiver) received() ([][]byte, []http.Header) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return rc.bodies, rc.heads
+}
+
+func fastConfig(eps ...Endpoint) Config {
+	return Config{
+		Endpoints:      eps,
+		MaxAttempts:    3,
+		InitialBackoff: Duration(time.Millisecond),
+		MaxBackoff:     Duration(5 * time.Millisecond),
+	}
+}
+
+func drawOf(grade model.PrizeGrade) model.DrawResult {
+	return model.DrawResult{
+		Prize:     model.Prize{Grade: grade, Name: string(grade) + "賞", Description: "テスト景品"},
+		DrawnAt:   time.Date(2026, 1, 2, 10, 0, 0, 0, time.UTC),
+		TicketNum: 42,
+	}
+}
+
+// ============================================================
+// 配信
+// ============================================================
+
+func TestNotify_GenericPayload(t *testing.T) {
+	rc := newReceiver(t)
+	d := NewWithHTTP(fastConfig(Endpoint{URL: rc.srv.URL}), rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+	d.Close()
+
+	bodies, heads := rc.received()
+	if len(bodies) != 1 {
+		t.Fatalf("受信件数: got %d, want 1", len(bodies))
+	}
+	var ev Event
+	if err := json.Unmarshal(bodies[0], &ev); err != nil {
+		t.Fatalf("JSONパースエラー: %v", err)
+	}
+	if ev.Grade != model.GradeTokutou || ev.TicketNum != 42 || ev.Event != eventPrizeDrawn {
+		t.Errorf("ペイロード不正: %+v", ev)
+	}
+	if heads[0].Get(HeaderSignature) != "" {
+		t.Error("secret 未設定なのに署名ヘッダーがある")
+	}
+}
+
+func TestNotify_SkipsUnsubscribedGrades(t *testing.T) {
+	rc := newReceiver(t)
+	d := NewWithHTTP(fastConfig(Endpoint{URL: rc.srv.URL}), rc.srv.Client())
+	d.Notify(drawOf(model.GradeHazure))
+	d.Notify(drawOf(model.GradeSantou))
+	d.Close()
+
+	if n := rc.calls.Load(); n != 0 {
+		t.Errorf("デフォルト（特等・1等）以外で送信された: %d 件", n)
+	}
+}
+
+func TestNotify_SlackAndLINEFormats(t *testing.T) {
+	slack := newReceiver(t)
+	line := newReceiver(t)
+	d := NewWithHTTP(fastConfig(
+		Endpoint{URL: slack.srv.URL, Format: FormatSlack},
+		Endpoint{URL: line.srv.URL, Format: FormatLINE, LineTo: "U123"},
+	), http.DefaultClient)
+	d.Notify(drawOf(model.GradeIttou))
+	d.Close()
+
+	sb, _ := slack.received()
+	var sp slackPayload
+	if len(sb) != 1 || json.Unmarshal(sb[0], &sp) != nil || !strings.Contains(sp.Text, "#42") {
+		t.Errorf("Slack ペイロード不正: %s", sb)
+	}
+	lb, _ := line.received()
+	var lp linePayload
+	if len(lb) != 1 || json.Unmarshal(lb[0], &lp) != nil {
+		t.Fatalf("LINE ペイロード不正: %s", lb)
+	}
+	if lp.To != "U123" || len(lp.Messages) != 1 || lp.Messages[0].Type != "text" {
+		t.Errorf("LINE ペイロード不正: %+v", lp)
+	}
+}
+
+func TestNotify_HMACSignatureVerifies(t *testing.T) {
+	rc := newReceiver(t)
+	d := NewWithHTTP(fastConfig(Endpoint{URL: rc.srv.URL, Secret: "s3cret"}), rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+	d.Close()
+
+	bodies, heads := rc.received()
+	if len(bodies) != 1 {
+		t.Fatalf("受信件数: got %d, want 1", len(bodies))
+	}
+	ts := heads[0].Get(HeaderTimestamp)
+	if ts == "" {
+		t.Fatal("タイムスタンプヘッダーがない")
+	}
+	if got, want := heads[0].Get(HeaderSignature), Sign("s3cret", ts, bodies[0]); got != want {
+		t.Errorf("署名: got %q, want %q", got, want)
+	}
+}
+
+// ============================================================
+// リトライ・dead letter
+// ============================================================
+
+func TestNotify_RetriesOn5xxThenSucceeds(t *testing.T) {
+	rc := newReceiver(t)
+	rc.failFirst = 2
+	d := NewWithHTTP(fastConfig(Endpoint{URL: rc.srv.URL}), rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+	d.Close()
+
+	if n := rc.calls.Load(); n != 3 {
+		t.Errorf("試行回数: got %d, want 3", n)
+	}
+	if dl := d.DeadLetters(); len(dl) != 0 {
+		t.Errorf("dead letter が記録された: %+v", dl)
+	}
+}
+
+func TestNotify_DeadLetterAfterMaxAttempts(t *testing.T) {
+	rc := newReceiver(t)
+	rc.failFirst = 100
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	cfg := fastConfig(Endpoint{Name: "mc", URL: rc.srv.URL})
+	cfg.DeadLetterPath = path
+	d := NewWithHTTP(cfg, rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+	d.Close()
+
+	if n := rc.calls.Load(); n != 3 {
+		t.Errorf("試行回数: got %d, want 3", n)
+	}
+	dl := d.DeadLetters()
+	if len(dl) != 1 || dl[0].Endpoint != "mc" || dl[0].Attempts != 3 {
+		t.Fatalf("dead letter 不正: %+v", dl)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("dead letter ファイルが読めない: %v", err)
+	}
+	if strings.Count(string(b), "\n") != 1 {
+		t.Errorf("dead letter ファイルの行数が不正: %q", b)
+	}
+}
+
+func TestClose_SkipsBackoffWait(t *testing.T) {
+	rc := newReceiver(t)
+	rc.failFirst = 1
+	cfg := fastConfig(Endpoint{URL: rc.srv.URL})
+	cfg.InitialBackoff, cfg.MaxBackoff = Duration(time.Hour), Duration(time.Hour)
+	d := NewWithHTTP(cfg, rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+
+	// 1 回目の失敗を待ってから閉じる
+	for deadline := time.Now().Add(5 * time.Second); rc.calls.Load() < 1 && time.Now().Before(deadline); {
+		time.Sleep(time.Millisecond)
+	}
+	start := time.Now()
+	d.Close()
+	if el := time.Since(start); el > 5*time.Second {
+		t.Fatalf("Close がリトライ待ちで止まった: %v", el)
+	}
+	if bodies, _ := rc.received(); len(bodies) != 1 {
+		t.Errorf("終了時に残りの試行が行われていない: %d 件", len(bodies))
+	}
+}
+
+func TestNotify_NoRetryOn4xx(t *testing.T) {
+	rc := newReceiver(t)
+	rc.failFirst = 100
+	rc.status = http.StatusBadRequest
+	d := NewWithHTTP(fastConfig(Endpoint{URL: rc.srv.URL}), rc.srv.Client())
+	d.Notify(drawOf(model.GradeTokutou))
+	d.Close()
+
+	if n := rc.calls.Load(); n != 1 {
+		t.Errorf("4xx でリトライされた: %d 回", n)
+	}
+	if len(d.DeadLetters()) != 1 {
+		t.Error("4xx が dead letter に記録されていない")
+	}
+}
+
+// ============================================================
+// 設定・デコレーター
+// ============================================================
+
+func TestLoadConfig_ParsesDurationsAndValidates(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.json")
+	os.WriteFile(good, []byte(`{"endpoints":[{"url":"http://x","format":"slack","grades":["特等"]}],
+		"max_attempts":4,"initial_backoff":"250ms"}`), 0o644)
+	cfg, err := LoadConfig(good)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.MaxAttempts != 4 || time.Duration(cfg.InitialBackoff) != 250*time.Millisecond {
+		t.Errorf("設定値不正: %+v", cfg)
+	}
+
+	bad := filepath.Join(dir, "bad.json")
+	os.WriteFile(bad, []byte(`{"endpoints":[{"url":"http://x","format":"teams"}]}`), 0o644)
+	if _, err := LoadConfig(bad); err == nil {
+		t.Error("未知の format でエラーにならない")
+	}
+}
+
+func TestWrap_NotifiesOnDraw(t *testing.T) {
+	rc := newReceiver(t)
+	cfg := fastConfig(Endpoint{URL: rc.srv.URL, Grades: []model.PrizeGrade{
+		model.GradeTokutou, model.GradeIttou, model.GradeNittou,
+		model.GradeSantou, model.GradeYontou, model.GradeHazure,
+	}})
+	d := NewWithHTTP(cfg, rc.srv.Client())
+	svc := Wrap(service.NewWithoutRotation(), d)
+	for i := 0; i < 5; i++ {
+		if _, err := svc.Draw(); err != nil {
+			t.Fatalf("Draw エラー: %v", err)
+		}
+	}
+	d.Close()
+
+	if n := rc.calls.Load(); n != 5 {
+		t.Errorf("通知件数: got %d, want 5", n)
+	}
+	if len(svc.History()) != 5 {
+		t.Error("デコレーター経由でも履歴が記録されること")
+	}
+}
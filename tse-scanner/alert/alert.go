@@ -0,0 +1,303 @@
+// Package alert evaluates user-defined rules against each scan and delivers
+// the resulting alerts to notification channels.
+//
+// Rules and channels are configured in a YAML file (see Config). Every alert
+// is keyed by rule and symbol (or sector); a key that fired within its
+// cool-down is suppressed, so a stock that stays hot does not page every scan.
+//
+//	cooldown: 30m
+//	channels:
+//	  desk:  {type: desktop}
+//	  slack: {type: slack, url: "${SLACK_WEBHOOK_URL}"}
+//	  mail:  {type: smtp, host: smtp.example.com, username: me, password: "${SMTP_PASSWORD}",
+//	          from: scanner@example.com, to: [me@example.com]}
+//	rules:
+//	  - name: 高スコア新高値
+//	    when: score >= 70 and signal 🏆52週新高値
+//	    channels: [desk, slack]
+//	  - name: 半導体セクター
+//	    sector: 半導体
+//	    when: avg_change > 3
+//	    cooldown: 2h
+//	positions:            # -portfolio の利確・損切ライン到達
+//	  channels: [desk]
+//	  cooldown: 1h
+package alert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"tse-scanner/exchange"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
+)
+
+// DefaultCooldown is used when neither the rule nor the config sets one.
+const DefaultCooldown = 30 * time.Minute
+
+// Duration is a time.Duration written as "30m" / "1h" in config files.
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	v, err := time.ParseDuration(n.Value)
+	if err != nil {
+		return fmt.Errorf("期間 %q を解釈できません（例: 30m, 1h）", n.Value)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Config is the alert configuration file.
+type Config struct {
+	Cooldown  Duration                 `yaml:"cooldown,omitempty"`
+	Rules     []Rule                   `yaml:"rules"`
+	Channels  map[string]ChannelConfig `yaml:"channels"`
+	Positions PositionAlerts           `yaml:"positions,omitempty"`
+}
+
+// PositionAlerts routes the take-profit / stop-loss alerts of held stocks.
+type PositionAlerts struct {
+	Channels []string `yaml:"channels,omitempty"` // 空なら全チャネル
+	Cooldown Duration `yaml:"cooldown,omitempty"` // 0 なら Config.Cooldown
+}
+
+// LoadConfig reads a YAML alert configuration. ${VAR} references in channel
+// settings are expanded from the environment so secrets need not be stored
+// in the file; every other "$" is kept literally.
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("アラート設定を開けません: %w", err)
+	}
+	var cfg Config
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil {
+		return Config{}, fmt.Errorf("%s: アラート設定のパースエラー: %w", path, err)
+	}
+	for name, cc := range cfg.Channels {
+		for _, f := range []*string{&cc.URL, &cc.Token, &cc.Host, &cc.Username, &cc.Password, &cc.From} {
+			*f = expandEnv(*f)
+		}
+		for i := range cc.To {
+			cc.To[i] = expandEnv(cc.To[i])
+		}
+		cfg.Channels[name] = cc
+	}
+	return cfg, nil
+}
+
+// envRef matches an explicit ${VAR} reference.
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// expandEnv replaces ${VAR} references in s; a bare "$" is left as is.
+func expandEnv(s string) string {
+	return envRef.ReplaceAllStringFunc(s, func(m string) string {
+		return os.Getenv(m[2 : len(m)-1])
+	})
+}
+
+// Alert is one fired rule.
+type Alert struct {
+	Rule     string
+	Symbol   string // 銘柄ルールのみ
+	Name     string // 銘柄ルールのみ
+	Sector   string
+	Message  string
+	At       time.Time
+	Channels []string // 配信先チャネル名
+}
+
+// Notifier delivers alerts to one channel.
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Engine evaluates rules and dispatches alerts.
+type Engine struct {
+	rules     []Rule
+	positions [2]Rule // 利確・損切（Evaluate の対象外）
+	notifiers map[string]Notifier
+	cooldown  time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time // rule + 対象 → 最後に発火した時刻
+}
+
+// New compiles cfg's rules and wires them to notifiers (by channel name).
+func New(cfg Config, notifiers map[string]Notifier) (*Engine, error) {
+	e := &Engine{
+		notifiers: notifiers,
+		cooldown:  time.Duration(cfg.Cooldown),
+		last:      make(map[string]time.Time),
+	}
+	if e.cooldown == 0 {
+		e.cooldown = DefaultCooldown
+	}
+	var errs []error
+	for _, r := range cfg.Rules {
+		if err := r.compile(); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(r.Channels) == 0 {
+			for name := range notifiers {
+				r.Channels = append(r.Channels, name)
+			}
+			sort.Strings(r.Channels)
+		}
+		for _, ch := range r.Channels {
+			if _, ok := notifiers[ch]; !ok {
+				errs = append(errs, fmt.Errorf("ルール %q: 未定義のチャネル %q", r.Name, ch))
+			}
+		}
+		e.rules = append(e.rules, r)
+	}
+	channels := cfg.Positions.Channels
+	if len(channels) == 0 {
+		for name := range notifiers {
+			channels = append(channels, name)
+		}
+		sort.Strings(channels)
+	}
+	for _, ch := range channels {
+		if _, ok := notifiers[ch]; !ok {
+			errs = append(errs, fmt.Errorf("positions: 未定義のチャネル %q", ch))
+		}
+	}
+	for i, hit := range []portfolio.Hit{portfolio.HitTakeProfit, portfolio.HitStopLoss} {
+		e.positions[i] = Rule{Name: string(hit), Channels: channels, Cooldown: cfg.Positions.Cooldown}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// NewFromConfig builds the notifiers described in cfg.Channels and returns an Engine.
+func NewFromConfig(cfg Config) (*Engine, error) {
+	notifiers := make(map[string]Notifier, len(cfg.Channels))
+	for name, cc := range cfg.Channels {
+		n, err := NewNotifier(cc)
+		if err != nil {
+			return nil, fmt.Errorf("チャネル %q: %w", name, err)
+		}
+		notifiers[name] = n
+	}
+	return New(cfg, notifiers)
+}
+
+// Evaluate runs every rule against one scan's candidates (all scored quotes,
+// not only those above -min-score) and returns the alerts that are not in
+// cool-down. Fired alerts start a new cool-down.
+func (e *Engine) Evaluate(at time.Time, cands []model.Candidate) []Alert {
+	var alerts []Alert
+	var sectors []sector.Stats
+	for i := range e.rules {
+		r := &e.rules[i]
+		if r.Sector == "" {
+			for _, c := range cands {
+				if r.matchStock(c) && e.fire(r, c.Symbol, at) {
+					alerts = append(alerts, stockAlert(r, c, at))
+				}
+			}
+			continue
+		}
+		if sectors == nil {
+			sectors = sector.AggregateCandidates(cands)
+		}
+		for _, s := range sectors {
+			if (r.Sector == "*" || r.Sector == s.Sector) && r.matchSector(s) && e.fire(r, "sector:"+s.Sector, at) {
+				alerts = append(alerts, sectorAlert(r, s, at))
+			}
+		}
+	}
+	return alerts
+}
+
+// EvaluatePositions returns an alert for every holding that reached its
+// take-profit or stop-loss level and is not in cool-down. Without channels
+// the alerts are still returned (and cooled down) so the caller can log them.
+func (e *Engine) EvaluatePositions(at time.Time, hits []portfolio.Holding) []Alert {
+	var alerts []Alert
+	for _, h := range hits {
+		r := &e.positions[0]
+		if h.Hit == portfolio.HitStopLoss {
+			r = &e.positions[1]
+		}
+		if h.Hit != portfolio.HitNone && e.fire(r, h.Symbol+"\x00"+string(h.Account), at) {
+			alerts = append(alerts, positionAlert(r, h, at))
+		}
+	}
+	return alerts
+}
+
+// fire records that r fired for target at t unless it is still cooling down.
+func (e *Engine) fire(r *Rule, target string, t time.Time) bool {
+	cooldown := time.Duration(r.Cooldown)
+	if cooldown == 0 {
+		cooldown = e.cooldown
+	}
+	key := r.Name + "\x00" + target
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if last, ok := e.last[key]; ok && t.Sub(last) < cooldown {
+		return false
+	}
+	e.last[key] = t
+	return true
+}
+
+// Dispatch sends every alert to its channels and returns the joined errors.
+func (e *Engine) Dispatch(ctx context.Context, alerts []Alert) error {
+	var errs []error
+	for _, a := range alerts {
+		for _, ch := range a.Channels {
+			if err := e.notifiers[ch].Notify(ctx, a); err != nil {
+				errs = append(errs, fmt.Errorf("%s への通知に失敗（%s）: %w", ch, a.Rule, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func stockAlert(r *Rule, c model.Candidate, at time.Time) Alert {
+	return Alert{
+		Rule: r.Name, Symbol: c.Symbol, Name: c.Name, Sector: c.Sector, At: at, Channels: r.Channels,
+		Message: fmt.Sprintf("🚨 [%s] %s %s %s円（%+.2f%%）スコア %.0f",
+			r.Name, c.Symbol, c.Name, exchange.Format(c.Price), c.ChangePercent, c.SurgeScore),
+	}
+}
+
+func positionAlert(r *Rule, h portfolio.Holding, at time.Time) Alert {
+	icon := "🎯"
+	if h.Hit == portfolio.HitStopLoss {
+		icon = "🛑"
+	}
+	return Alert{
+		Rule: r.Name, Symbol: h.Symbol, Name: h.Name, At: at, Channels: r.Channels,
+		Message: fmt.Sprintf("%s [%s] %s %s %s円 が%sライン %s円 に到達（%d 株、損益 %s / %+.2f%%）",
+			icon, r.Name, h.Symbol, h.Name, exchange.Format(h.Price), r.Name, exchange.Format(h.HitPrice),
+			h.Shares, portfolio.SignedYen(h.PnL), h.PnLPercent),
+	}
+}
+
+func sectorAlert(r *Rule, s sector.Stats, at time.Time) Alert {
+	return Alert{
+		Rule: r.Name, Sector: s.Sector, At: at, Channels: r.Channels,
+		Message: fmt.Sprintf("🚨 [%s] セクター %s 平均 %+.2f%%（%d 銘柄、上昇 %.0f%%）",
+			r.Name, s.Sector, s.AvgChange, s.Count, s.Breadth),
+	}
+}
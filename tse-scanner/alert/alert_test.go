@@ -0,0 +1,364 @@
+package alert_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"tse-scanner/alert"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+)
+
+// ---- helpers ----
+
+var t0 = time.Date(2024, 6, 14, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+type recorder struct {
+	mu     sync.Mutex
+	alerts []alert.Alert
+}
+
+func (r *recorder) Notify(_ context.Context, a alert.Alert) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.alerts = append(r.alerts, a)
+	return nil
+}
+
+func cand(symbol, sector string, score, change float64, signals ...string) model.Candidate {
+	c := model.Candidate{
+		Quote:      model.Quote{Symbol: symbol, Name: "銘柄" + symbol, Sector: sector, Price: 1000, ChangePercent: change, Valid: true},
+		SurgeScore: score,
+	}
+	for _, s := range signals {
+		c.Signals = append(c.Signals, model.Signal{Label: s})
+	}
+	return c
+}
+
+func newEngine(t *testing.T, rules ...alert.Rule) (*alert.Engine, *recorder) {
+	t.Helper()
+	rec := &recorder{}
+	e, err := alert.New(alert.Config{Rules: rules}, map[string]alert.Notifier{"test": rec})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return e, rec
+}
+
+// ---- rules ----
+
+func TestEvaluate_StockRuleWithSignal(t *testing.T) {
+	e, _ := newEngine(t, alert.Rule{Name: "新高値", When: "score >= 70 and signal 🏆52週新高値"})
+	alerts := e.Evaluate(t0, []model.Candidate{
+		cand("A.T", "", 80, 5, "🏆52週新高値"),
+		cand("B.T", "", 90, 5),            // シグナルなし
+		cand("C.T", "", 60, 5, "🏆52週新高値"), // スコア不足
+	})
+	if len(alerts) != 1 || alerts[0].Symbol != "A.T" {
+		t.Fatalf("want alert for A.T only, got %+v", alerts)
+	}
+	if !strings.Contains(alerts[0].Message, "A.T") || alerts[0].Channels[0] != "test" {
+		t.Errorf("unexpected alert: %+v", alerts[0])
+	}
+}
+
+func TestEvaluate_ClauseForms(t *testing.T) {
+	c := cand("7203.T", "自動車", 50, 3.5, "⚡出来高急増")
+	cases := map[string]bool{
+		"change > 3%":                 true,
+		"change ≥ 3.5":                true,
+		"change < 3":                  false,
+		"not signal ⚡出来高急増":           false,
+		"!signal 🚀大幅上昇":               true,
+		"sector 自動車 && score == 50":   true,
+		"symbol 7203.t":               true,
+		"score != 50":                 false,
+		"SCORE >= 10 AND change >= 1": false, // 項目名は小文字のみ
+	}
+	for when, want := range cases {
+		rec := &recorder{}
+		e, err := alert.New(alert.Config{Rules: []alert.Rule{{Name: "r", When: when}}},
+			map[string]alert.Notifier{"test": rec})
+		if err != nil {
+			if want {
+				t.Errorf("%q: unexpected error %v", when, err)
+			}
+			continue
+		}
+		if got := len(e.Evaluate(t0, []model.Candidate{c})) == 1; got != want {
+			t.Errorf("%q: got %v, want %v", when, got, want)
+		}
+	}
+}
+
+func TestEvaluate_SectorRule(t *testing.T) {
+	e, _ := newEngine(t, alert.Rule{Name: "半導体", Sector: "半導体", When: "avg_change > 3 and count >= 2"})
+	cands := []model.Candidate{
+		cand("A.T", "半導体", 10, 5),
+		cand("B.T", "半導体", 10, 2),
+		cand("C.T", "銀行", 10, 9),
+	}
+	alerts := e.Evaluate(t0, cands)
+	if len(alerts) != 1 || alerts[0].Sector != "半導体" || alerts[0].Symbol != "" {
+		t.Fatalf("want one sector alert, got %+v", alerts)
+	}
+
+	any, _ := newEngine(t, alert.Rule{Name: "全業種", Sector: "*", When: "advancers >= 100"})
+	if got := any.Evaluate(t0, cands); len(got) != 2 {
+		t.Errorf("wildcard sector: want 2 alerts, got %+v", got)
+	}
+}
+
+func TestEvaluate_Cooldown(t *testing.T) {
+	e, _ := newEngine(t,
+		alert.Rule{Name: "既定", When: "score >= 50"},
+		alert.Rule{Name: "短い", When: "score >= 50", Cooldown: alert.Duration(5 * time.Minute)},
+	)
+	cands := []model.Candidate{cand("A.T", "", 80, 5)}
+	count := func(at time.Time) int { return len(e.Evaluate(at, cands)) }
+
+	if n := count(t0); n != 2 {
+		t.Fatalf("first scan: want 2, got %d", n)
+	}
+	if n := count(t0.Add(time.Minute)); n != 0 {
+		t.Errorf("within cooldown: want 0, got %d", n)
+	}
+	if n := count(t0.Add(6 * time.Minute)); n != 1 {
+		t.Errorf("after short cooldown: want 1, got %d", n)
+	}
+	if n := count(t0.Add(alert.DefaultCooldown + time.Minute)); n != 2 {
+		t.Errorf("after default cooldown: want 2, got %d", n)
+	}
+}
+
+func TestEvaluatePositions(t *testing.T) {
+	e, err := alert.New(alert.Config{Positions: alert.PositionAlerts{Cooldown: alert.Duration(time.Hour)}},
+		map[string]alert.Notifier{"test": &recorder{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	hits := []portfolio.Holding{
+		{Position: portfolio.Position{Symbol: "7203.T", Name: "トヨタ自動車", Shares: 100, Account: portfolio.AccountSpecific},
+			Price: 3600, PnL: 60000, PnLPercent: 20, Hit: portfolio.HitTakeProfit, HitPrice: 3600},
+		{Position: portfolio.Position{Symbol: "9984.T", Shares: 10, Account: portfolio.AccountNISA},
+			Price: 7900, PnL: -11000, PnLPercent: -12.2, Hit: portfolio.HitStopLoss, HitPrice: 8000},
+	}
+	alerts := e.EvaluatePositions(t0, hits)
+	if len(alerts) != 2 || alerts[0].Rule != "利確" || alerts[1].Rule != "損切" || alerts[0].Channels[0] != "test" {
+		t.Fatalf("unexpected alerts: %+v", alerts)
+	}
+	if msg := alerts[0].Message; !strings.Contains(msg, "3600円 が利確ライン 3600円 に到達") || !strings.Contains(msg, "+60,000円") {
+		t.Errorf("message = %q", msg)
+	}
+	if n := len(e.EvaluatePositions(t0.Add(30*time.Minute), hits)); n != 0 {
+		t.Errorf("within cooldown: want 0, got %d", n)
+	}
+	if n := len(e.EvaluatePositions(t0.Add(2*time.Hour), hits)); n != 2 {
+		t.Errorf("after cooldown: want 2, got %d", n)
+	}
+
+	if _, err := alert.New(alert.Config{Positions: alert.PositionAlerts{Channels: []string{"missing"}}}, nil); err == nil {
+		t.Error("want error for undefined position channel")
+	}
+}
+
+func TestNew_RejectsInvalidRules(t *testing.T) {
+	cases := []alert.Rule{
+		{Name: "", When: "score > 1"},
+		{Name: "x", When: ""},
+		{Name: "x", When: "unknown > 1"},
+		{Name: "x", When: "score >> 1"},
+		{Name: "x", Sector: "銀行", When: "score > 1"},    // 銘柄の項目
+		{Name: "x", Sector: "銀行", When: "signal 🚀大幅上昇"}, // セクターでは不可
+		{Name: "x", When: "score > 1", Channels: []string{"missing"}},
+	}
+	for _, r := range cases {
+		if _, err := alert.New(alert.Config{Rules: []alert.Rule{r}}, map[string]alert.Notifier{"test": &recorder{}}); err == nil {
+			t.Errorf("rule %+v: want error", r)
+		}
+	}
+}
+
+func TestLoadConfig_ExpandsEnvAndBuildsChannels(t *testing.T) {
+	t.Setenv("TEST_SLACK_URL", "https://hooks.example.com/x")
+	path := filepath.Join(t.TempDir(), "alerts.yaml")
+	doc := `
+cooldown: 10m
+channels:
+  slack: {type: slack, url: "${TEST_SLACK_URL}"}
+  mail:  {type: smtp, host: smtp.example.com, password: "pa$$word", from: a@example.com, to: [b@example.com]}
+rules:
+  - name: $高スコア
+    when: score >= 70
+`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := alert.LoadConfig(path)
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.Channels["slack"].URL != "https://hooks.example.com/x" || time.Duration(cfg.Cooldown) != 10*time.Minute {
+		t.Errorf("unexpected config: %+v", cfg)
+	}
+	if cfg.Channels["mail"].Password != "pa$$word" || cfg.Rules[0].Name != "$高スコア" {
+		t.Errorf("literal $ should be kept: password=%q rule=%q", cfg.Channels["mail"].Password, cfg.Rules[0].Name)
+	}
+	if _, err := alert.NewFromConfig(cfg); err != nil {
+		t.Errorf("NewFromConfig: %v", err)
+	}
+	cfg.Channels["bad"] = alert.ChannelConfig{Type: "pager"}
+	if _, err := alert.NewFromConfig(cfg); err == nil {
+		t.Error("want error for unknown channel type")
+	}
+}
+
+// ---- channels ----
+
+func TestWebhookPayloads(t *testing.T) {
+	var mu sync.Mutex
+	bodies := map[string]map[string]any{}
+	auth := ""
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var v map[string]any
+		json.NewDecoder(r.Body).Decode(&v) //nolint:errcheck
+		mu.Lock()
+		bodies[r.URL.Path] = v
+		if r.URL.Path == "/line" {
+			auth = r.Header.Get("Authorization")
+		}
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	a := alert.Alert{Rule: "r", Message: "🚨 テスト", At: t0}
+	ctx := context.Background()
+	for _, n := range []alert.Notifier{
+		alert.NewWebhook(srv.Client(), alert.ChannelSlack, srv.URL+"/slack"),
+		alert.NewWebhook(srv.Client(), alert.ChannelDiscord, srv.URL+"/discord"),
+		alert.NewLINE(srv.Client(), srv.URL+"/line", "tok", []string{"U123"}),
+	} {
+		if err := n.Notify(ctx, a); err != nil {
+			t.Fatalf("Notify: %v", err)
+		}
+	}
+	if bodies["/slack"]["text"] != "🚨 テスト" {
+		t.Errorf("slack payload: %v", bodies["/slack"])
+	}
+	if bodies["/discord"]["content"] != "🚨 テスト" {
+		t.Errorf("discord payload: %v", bodies["/discord"])
+	}
+	if bodies["/line"]["to"] != "U123" || auth != "Bearer tok" {
+		t.Errorf("line payload: %v auth=%q", bodies["/line"], auth)
+	}
+}
+
+func TestWebhook_HTTPErrorReturned(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+	n := alert.NewWebhook(srv.Client(), alert.ChannelSlack, srv.URL)
+	if err := n.Notify(context.Background(), alert.Alert{Message: "x"}); err == nil {
+		t.Error("want error for 400 response")
+	}
+}
+
+func TestDesktop_RunsNotifier(t *testing.T) {
+	var got []string
+	d := alert.NewDesktopWithRunner(func(_ context.Context, name string, args ...string) error {
+		got = append([]string{name}, args...)
+		return nil
+	})
+	if err := d.Notify(context.Background(), alert.Alert{Message: "🚨 テスト"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(got) == 0 || !strings.Contains(strings.Join(got, " "), "🚨 テスト") {
+		t.Errorf("command: %v", got)
+	}
+}
+
+// fakeSMTP is a minimal SMTP server that accepts one message.
+func fakeSMTP(t *testing.T) (addr string, msg <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	out := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+		reply := func(s string) { io.WriteString(conn, s+"\r\n") } //nolint:errcheck
+		reply("220 localhost ESMTP")
+		var data strings.Builder
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.ToUpper(strings.TrimSpace(line))
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+				reply("250 localhost")
+			case cmd == "DATA":
+				reply("354 go ahead")
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil || l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				out <- data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 bye")
+				return
+			default:
+				reply("250 OK")
+			}
+		}
+	}()
+	return ln.Addr().String(), out
+}
+
+func TestSMTP_SendsMessage(t *testing.T) {
+	addr, msg := fakeSMTP(t)
+	host, port, _ := net.SplitHostPort(addr)
+	var p int
+	for _, c := range port {
+		p = p*10 + int(c-'0')
+	}
+	n := alert.NewSMTP(alert.ChannelConfig{Host: host, Port: p, From: "scanner@example.com", To: []string{"me@example.com"}})
+
+	err := n.Notify(context.Background(), alert.Alert{Rule: "高スコア", Symbol: "7203.T", Name: "トヨタ自動車", Message: "🚨 本文", At: t0})
+	if err != nil {
+		t.Fatalf("Notify: %v", err)
+	}
+	select {
+	case m := <-msg:
+		if !strings.Contains(m, "🚨 本文") || !strings.Contains(m, "Subject: =?UTF-8?b?") {
+			t.Errorf("unexpected message:\n%s", m)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no message received")
+	}
+}
@@ -0,0 +1,243 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Channel types.
+const (
+	ChannelDesktop = "desktop"
+	ChannelSMTP    = "smtp"
+	ChannelSlack   = "slack"
+	ChannelDiscord = "discord"
+	ChannelLINE    = "line"
+)
+
+const lineEndpoint = "https://api.line.me/v2/bot/message/push"
+
+// ChannelConfig describes one notification channel.
+type ChannelConfig struct {
+	Type string `yaml:"type"`
+
+	// slack / discord: Incoming Webhook URL
+	URL string `yaml:"url,omitempty"`
+
+	// line: Messaging API のチャネルアクセストークンと push 先（userId / groupId）
+	Token string `yaml:"token,omitempty"`
+
+	// smtp
+	Host     string `yaml:"host,omitempty"`
+	Port     int    `yaml:"port,omitempty"` // 既定 587
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	From     string `yaml:"from,omitempty"`
+
+	// smtp: 宛先メールアドレス / line: push 先
+	To []string `yaml:"to,omitempty"`
+}
+
+// HTTPDoer is the interface satisfied by *http.Client, enabling test injection.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// NewNotifier builds the Notifier for cc with production clients.
+func NewNotifier(cc ChannelConfig) (Notifier, error) {
+	h := &http.Client{Timeout: 10 * time.Second}
+	switch cc.Type {
+	case ChannelDesktop:
+		return NewDesktop(), nil
+	case ChannelSMTP:
+		if cc.Host == "" || cc.From == "" || len(cc.To) == 0 {
+			return nil, fmt.Errorf("smtp には host / from / to が必要です")
+		}
+		return NewSMTP(cc), nil
+	case ChannelSlack, ChannelDiscord:
+		if cc.URL == "" {
+			return nil, fmt.Errorf("%s には url が必要です", cc.Type)
+		}
+		return NewWebhook(h, cc.Type, cc.URL), nil
+	case ChannelLINE:
+		if cc.Token == "" || len(cc.To) == 0 {
+			return nil, fmt.Errorf("line には token / to が必要です")
+		}
+		return NewLINE(h, lineEndpoint, cc.Token, cc.To), nil
+	default:
+		return nil, fmt.Errorf("未知のチャネル種別です: %q（desktop / smtp / slack / discord / line）", cc.Type)
+	}
+}
+
+// ---- desktop ----
+
+// Desktop shows an OS notification (notify-send / osascript / PowerShell).
+type Desktop struct {
+	run func(ctx context.Context, name string, args ...string) error
+}
+
+// NewDesktop returns a Desktop notifier for the current OS.
+func NewDesktop() *Desktop {
+	return NewDesktopWithRunner(func(ctx context.Context, name string, args ...string) error {
+		return exec.CommandContext(ctx, name, args...).Run()
+	})
+}
+
+// NewDesktopWithRunner returns a Desktop notifier that runs commands via run (for testing).
+func NewDesktopWithRunner(run func(ctx context.Context, name string, args ...string) error) *Desktop {
+	return &Desktop{run: run}
+}
+
+// Notify implements Notifier.
+func (d *Desktop) Notify(ctx context.Context, a Alert) error {
+	const title = "東証急騰スキャナー"
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s", strconv.Quote(a.Message), strconv.Quote(title))
+		return d.run(ctx, "osascript", "-e", script)
+	case "windows":
+		script := fmt.Sprintf(`[reflection.assembly]::loadwithpartialname('System.Windows.Forms') | Out-Null;`+
+			`$n = New-Object System.Windows.Forms.NotifyIcon; $n.Icon = [System.Drawing.SystemIcons]::Information;`+
+			`$n.Visible = $true; $n.ShowBalloonTip(10000, '%s', '%s', 'Info')`,
+			title, strings.ReplaceAll(a.Message, "'", "''"))
+		return d.run(ctx, "powershell", "-NoProfile", "-Command", script)
+	default:
+		return d.run(ctx, "notify-send", "--app-name=tse-scanner", title, a.Message)
+	}
+}
+
+// ---- smtp ----
+
+// SMTP sends alerts by e-mail.
+type SMTP struct {
+	addr     string
+	auth     smtp.Auth
+	from     string
+	to       []string
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTP returns an SMTP notifier. PLAIN auth is used when Username is set.
+func NewSMTP(cc ChannelConfig) *SMTP {
+	port := cc.Port
+	if port == 0 {
+		port = 587
+	}
+	s := &SMTP{
+		addr:     net.JoinHostPort(cc.Host, strconv.Itoa(port)),
+		from:     cc.From,
+		to:       cc.To,
+		sendMail: smtp.SendMail,
+	}
+	if cc.Username != "" {
+		s.auth = smtp.PlainAuth("", cc.Username, cc.Password, cc.Host)
+	}
+	return s
+}
+
+// Notify implements Notifier.
+func (s *SMTP) Notify(_ context.Context, a Alert) error {
+	subject := "[tse-scanner] " + a.Rule
+	if a.Symbol != "" {
+		subject += " " + a.Symbol + " " + a.Name
+	} else if a.Sector != "" {
+		subject += " " + a.Sector
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", s.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.BEncoding.Encode("UTF-8", subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", a.At.Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("Content-Transfer-Encoding: 8bit\r\n\r\n")
+	msg.WriteString(a.Message + "\r\n")
+	return s.sendMail(s.addr, s.auth, s.from, s.to, msg.Bytes())
+}
+
+// ---- webhooks (Slack / Discord / LINE) ----
+
+// Webhook posts alerts to a Slack or Discord Incoming Webhook.
+type Webhook struct {
+	http HTTPDoer
+	kind string
+	url  string
+}
+
+// NewWebhook returns a Slack (kind "slack") or Discord (kind "discord") notifier.
+func NewWebhook(h HTTPDoer, kind, url string) *Webhook {
+	return &Webhook{http: h, kind: kind, url: url}
+}
+
+// Notify implements Notifier.
+func (w *Webhook) Notify(ctx context.Context, a Alert) error {
+	var payload any = map[string]string{"text": a.Message}
+	if w.kind == ChannelDiscord {
+		payload = map[string]string{"content": a.Message}
+	}
+	return postJSON(ctx, w.http, w.url, nil, payload)
+}
+
+// LINE pushes alerts with the LINE Messaging API.
+type LINE struct {
+	http     HTTPDoer
+	endpoint string
+	token    string
+	to       []string
+}
+
+// NewLINE returns a LINE notifier posting to endpoint (lineEndpoint in production).
+func NewLINE(h HTTPDoer, endpoint, token string, to []string) *LINE {
+	return &LINE{http: h, endpoint: endpoint, token: token, to: to}
+}
+
+// Notify implements Notifier.
+func (l *LINE) Notify(ctx context.Context, a Alert) error {
+	headers := map[string]string{"Authorization": "Bearer " + l.token}
+	for _, to := range l.to {
+		payload := map[string]any{
+			"to":       to,
+			"messages": []map[string]string{{"type": "text", "text": a.Message}},
+		}
+		if err := postJSON(ctx, l.http, l.endpoint, headers, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func postJSON(ctx context.Context, h HTTPDoer, url string, headers map[string]string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := h.Do(req)
+	if err != nil {
+		return fmt.Errorf("HTTPリクエストエラー: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("HTTPステータス %d", resp.StatusCode)
+	}
+	return nil
+}
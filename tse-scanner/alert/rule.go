@@ -0,0 +1,226 @@
+package alert
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"tse-scanner/model"
+	"tse-scanner/sector"
+)
+
+// Rule is one user-defined alert condition.
+//
+// When is a list of clauses joined by "and" (or "&&"). Each clause is one of
+//
+//	<field> <op> <number>   e.g. score >= 70, change > 3%
+//	signal <label>          the candidate has the signal, e.g. signal 🏆52週新高値
+//	sector <name>           the stock belongs to the sector (stock rules only)
+//	symbol <code>           the stock is the given code (stock rules only)
+//
+// optionally prefixed with "not". Operators are >=, >, <=, <, ==, != (≥ and ≤
+// are accepted too).
+//
+// A rule with Sector set is a sector rule: it is evaluated once per sector
+// ("*" = every sector) against aggregate fields instead of per stock.
+type Rule struct {
+	Name     string   `yaml:"name"`
+	When     string   `yaml:"when"`
+	Sector   string   `yaml:"sector,omitempty"`
+	Channels []string `yaml:"channels,omitempty"` // 空なら全チャネル
+	Cooldown Duration `yaml:"cooldown,omitempty"` // 0 なら Config.Cooldown
+
+	clauses []clause
+}
+
+// Stock rule fields.
+var stockFields = map[string]func(c model.Candidate) float64{
+	"score":        func(c model.Candidate) float64 { return c.SurgeScore },
+	"change":       func(c model.Candidate) float64 { return c.ChangePercent },
+	"price":        func(c model.Candidate) float64 { return c.Price },
+	"volume_ratio": func(c model.Candidate) float64 { return c.VolumeRatio },
+	"velocity":     func(c model.Candidate) float64 { return c.Momentum.Velocity },
+	"acceleration": func(c model.Candidate) float64 { return c.Momentum.Acceleration },
+	"pace":         func(c model.Candidate) float64 { return c.Momentum.VolumePace },
+	"rsi":          func(c model.Candidate) float64 { return c.Indicators.RSI },
+}
+
+// Sector rule fields.
+var sectorFields = map[string]func(s sector.Stats) float64{
+	"avg_change": func(s sector.Stats) float64 { return s.AvgChange },
+	"max_change": func(s sector.Stats) float64 { return s.MaxChange },
+	"avg_score":  func(s sector.Stats) float64 { return s.AvgScore },
+	"advancers":  func(s sector.Stats) float64 { return s.Breadth }, // 上昇銘柄の割合（%）
+	"count":      func(s sector.Stats) float64 { return float64(s.Count) },
+}
+
+type clauseKind int
+
+const (
+	kindCompare clauseKind = iota
+	kindSignal
+	kindSector
+	kindSymbol
+)
+
+type clause struct {
+	kind  clauseKind
+	not   bool
+	field string
+	op    string
+	value float64
+	text  string
+}
+
+var (
+	andSplit       = regexp.MustCompile(`(?i)\s+and\s+|\s*&&\s*`)
+	comparePattern = regexp.MustCompile(`^([a-z_0-9]+)\s*(>=|<=|==|!=|>|<|≥|≤)\s*(-?[0-9]+(?:\.[0-9]+)?)\s*%?$`)
+)
+
+// compile parses When and checks the fields against the rule's scope.
+func (r *Rule) compile() error {
+	if strings.TrimSpace(r.Name) == "" {
+		return fmt.Errorf("ルール名（name）がありません")
+	}
+	if strings.TrimSpace(r.When) == "" {
+		return fmt.Errorf("ルール %q: 条件（when）がありません", r.Name)
+	}
+	r.clauses = nil
+	for _, part := range andSplit.Split(strings.TrimSpace(r.When), -1) {
+		c, err := parseClause(part, r.Sector != "")
+		if err != nil {
+			return fmt.Errorf("ルール %q: %w", r.Name, err)
+		}
+		r.clauses = append(r.clauses, c)
+	}
+	return nil
+}
+
+func parseClause(s string, sectorRule bool) (clause, error) {
+	s = strings.TrimSpace(s)
+	var c clause
+	if rest, ok := cutWord(s, "not"); ok {
+		c.not, s = true, rest
+	} else if strings.HasPrefix(s, "!") {
+		c.not, s = true, strings.TrimSpace(s[1:])
+	}
+
+	for word, kind := range map[string]clauseKind{"signal": kindSignal, "sector": kindSector, "symbol": kindSymbol} {
+		if rest, ok := cutWord(s, word); ok {
+			if rest == "" {
+				return c, fmt.Errorf("%q の後に値がありません", word)
+			}
+			if sectorRule {
+				return c, fmt.Errorf("セクタールールでは %q は使えません", word)
+			}
+			c.kind, c.text = kind, rest
+			return c, nil
+		}
+	}
+
+	m := comparePattern.FindStringSubmatch(s)
+	if m == nil {
+		return c, fmt.Errorf("条件 %q を解釈できません（例: score >= 70, signal 🏆52週新高値）", s)
+	}
+	c.kind, c.field, c.op = kindCompare, m[1], normalizeOp(m[2])
+	c.value, _ = strconv.ParseFloat(m[3], 64)
+	if sectorRule {
+		if _, ok := sectorFields[c.field]; !ok {
+			return c, fmt.Errorf("セクターの項目 %q は未対応です（%s）", c.field, fieldNames(sectorFields))
+		}
+	} else if _, ok := stockFields[c.field]; !ok {
+		return c, fmt.Errorf("銘柄の項目 %q は未対応です（%s）", c.field, fieldNames(stockFields))
+	}
+	return c, nil
+}
+
+// cutWord strips a leading keyword followed by whitespace (or end of string).
+func cutWord(s, word string) (string, bool) {
+	if !strings.HasPrefix(strings.ToLower(s), word) {
+		return "", false
+	}
+	rest := s[len(word):]
+	if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+func normalizeOp(op string) string {
+	switch op {
+	case "≥":
+		return ">="
+	case "≤":
+		return "<="
+	}
+	return op
+}
+
+func compare(v float64, op string, want float64) bool {
+	switch op {
+	case ">=":
+		return v >= want
+	case ">":
+		return v > want
+	case "<=":
+		return v <= want
+	case "<":
+		return v < want
+	case "==":
+		return v == want
+	case "!=":
+		return v != want
+	}
+	return false
+}
+
+// matchStock reports whether every clause holds for c.
+func (r *Rule) matchStock(c model.Candidate) bool {
+	for _, cl := range r.clauses {
+		var ok bool
+		switch cl.kind {
+		case kindCompare:
+			ok = compare(stockFields[cl.field](c), cl.op, cl.value)
+		case kindSignal:
+			ok = hasSignal(c.Signals, cl.text)
+		case kindSector:
+			ok = c.Sector == cl.text
+		case kindSymbol:
+			ok = strings.EqualFold(c.Symbol, cl.text)
+		}
+		if ok == cl.not {
+			return false
+		}
+	}
+	return true
+}
+
+// matchSector reports whether every clause holds for s.
+func (r *Rule) matchSector(s sector.Stats) bool {
+	for _, cl := range r.clauses {
+		if compare(sectorFields[cl.field](s), cl.op, cl.value) == cl.not {
+			return false
+		}
+	}
+	return true
+}
+
+func hasSignal(signals []model.Signal, label string) bool {
+	for _, s := range signals {
+		if s.Label == label {
+			return true
+		}
+	}
+	return false
+}
+
+func fieldNames[T any](m map[string]T) string {
+	names := make([]string, 0, len(m))
+	for k := range m {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
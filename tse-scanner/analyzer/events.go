@@ -0,0 +1,70 @@
+package analyzer
+
+import (
+	"sort"
+	"time"
+
+	"tse-scanner/events"
+	"tse-scanner/model"
+)
+
+// Event context (scored when Inputs.Events is set): each candidate carries
+// the corporate events taking effect on the scan's trading day, shown as
+// display-only signals. With a positive event_discount the score of a
+// candidate with a scheduled event (決算・権利落ち・株式分割) is reduced by
+// that share, since its move was expected; unscheduled disclosures are news
+// and keep their full score.
+
+// eventExtra attaches the day's events to each candidate.
+func eventExtra(book *events.Book) extraFunc {
+	return func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+		at := q.FetchedAt
+		if at.IsZero() {
+			at = time.Now()
+		}
+		c.Events = book.On(q.Symbol, at)
+		var signals []model.Signal
+		for _, e := range c.Events {
+			signals = append(signals, model.Signal{Label: eventLabel(e), Score: 0})
+		}
+		return 0, signals
+	}
+}
+
+// eventLabel names an event as a display signal.
+func eventLabel(e model.Event) string {
+	switch events.Kind(e.Kind) {
+	case events.KindEarnings:
+		if e.NextDay {
+			return "📅決算発表翌日"
+		}
+		return "📅決算発表日"
+	case events.KindExDividend:
+		return "💴権利落ち"
+	case events.KindSplit:
+		return "🔀株式分割"
+	default:
+		return "📢適時開示"
+	}
+}
+
+// discountEvents reduces the score of candidates with a scheduled event by
+// EventDiscount, drops those falling below minScore and re-sorts.
+func (p *Profile) discountEvents(cands []model.Candidate, minScore float64) []model.Candidate {
+	out := cands[:0]
+	for _, c := range cands {
+		for _, e := range c.Events {
+			if e.Scheduled {
+				cut := c.SurgeScore * p.EventDiscount
+				c.SurgeScore -= cut
+				c.Signals = append(c.Signals, model.Signal{Label: "予定イベント", Score: -cut})
+				break
+			}
+		}
+		if c.SurgeScore >= minScore {
+			out = append(out, c)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].SurgeScore > out[j].SurgeScore })
+	return out
+}
@@ -0,0 +1,70 @@
+package analyzer_test
+
+import (
+	"testing"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/events"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var eventDay = time.Date(2024, 11, 18, 10, 0, 0, 0, jst)
+
+func eventBook() *events.Book {
+	return events.New([]events.Event{
+		{Symbol: "7203.T", Kind: events.KindEarnings, At: time.Date(2024, 11, 15, 15, 30, 0, 0, jst), HasTime: true},
+		{Symbol: "6758.T", Kind: events.KindDisclosure, At: time.Date(2024, 11, 18, 9, 30, 0, 0, jst), HasTime: true, Title: "業績予想の修正"},
+	})
+}
+
+func eventQuote(symbol string) model.Quote {
+	q := newQuote(5, 4, 1000, 1000, 1000)
+	q.Symbol, q.FetchedAt = symbol, eventDay
+	return q
+}
+
+// ---- tests ----
+
+func TestAnalyzeWith_EventSignals(t *testing.T) {
+	got := analyzer.DefaultProfile().AnalyzeWith([]model.Quote{eventQuote("7203.T"), eventQuote("6758.T"), eventQuote("9984.T")}, 0,
+		analyzer.Inputs{Events: eventBook()})
+	by := make(map[string]model.Candidate)
+	for _, c := range got {
+		by[c.Symbol] = c
+	}
+	if c := by["7203.T"]; !hasSignal(c.Signals, "📅決算発表翌日") || len(c.Events) != 1 {
+		t.Errorf("7203.T: %+v", c.Signals)
+	}
+	if c := by["6758.T"]; !hasSignal(c.Signals, "📢適時開示") {
+		t.Errorf("6758.T: %+v", c.Signals)
+	}
+	if c := by["9984.T"]; len(c.Events) != 0 {
+		t.Errorf("9984.T: %+v", c.Events)
+	}
+	// 割引なしでは同じスコア
+	if by["7203.T"].SurgeScore != by["9984.T"].SurgeScore {
+		t.Errorf("scores differ without discount: %v vs %v", by["7203.T"].SurgeScore, by["9984.T"].SurgeScore)
+	}
+}
+
+func TestAnalyzeWith_EventDiscount(t *testing.T) {
+	p := analyzer.DefaultProfile()
+	p.EventDiscount = 0.5
+	quotes := []model.Quote{eventQuote("7203.T"), eventQuote("6758.T")}
+	full := p.AnalyzeWith(quotes, 0, analyzer.Inputs{})[0].SurgeScore
+
+	got := p.AnalyzeWith(quotes, 0, analyzer.Inputs{Events: eventBook()})
+	if got[0].Symbol != "6758.T" || got[0].SurgeScore != full {
+		t.Errorf("unscheduled disclosure should keep its score: %+v", got[0])
+	}
+	if got[1].Symbol != "7203.T" || got[1].SurgeScore != full/2 || !hasSignal(got[1].Signals, "予定イベント") {
+		t.Errorf("scheduled earnings should be halved: %v (full %v)", got[1].SurgeScore, full)
+	}
+
+	if n := len(p.AnalyzeWith(quotes, full*0.75, analyzer.Inputs{Events: eventBook()})); n != 1 {
+		t.Errorf("discounted candidate below min score: want 1 candidate, got %d", n)
+	}
+}
@@ -0,0 +1,184 @@
+package analyzer
+
+import (
+	"time"
+
+	"tse-scanner/events"
+	"tse-scanner/history"
+	"tse-scanner/indicator"
+	"tse-scanner/model"
+	"tse-scanner/sector"
+)
+
+// Indicator components (default profile, scored only when bars are supplied):
+//
+//	[H] ゴールデンクロス (0–5 点): 5 日線が 25 日線を直近 3 本以内に上抜け。
+//	[I] RSI ブレイク     (0–5 点): RSI(14) が 50 を直近 3 本以内に上抜け。
+//	[J] MACD クロス      (0–5 点): MACD がシグナル線を直近 3 本以内に上抜け。
+//	[K] ボリンジャー     (0–5 点): 現値が +2σ を上抜け。
+//	[L] VWAP 乖離        (0–5 点): 現値が当日 VWAP を上回る率。+1% で満点。
+//	[M] 値幅拡大         (0–5 点): 当日値幅 / ATR(14)。2 倍で満点。
+
+// Inputs are optional data sources beyond the quote snapshot. Zero-valued
+// fields disable the components that depend on them.
+type Inputs struct {
+	History  history.Reader
+	Window   time.Duration          // モメンタムの期間（0 で DefaultMomentumWindow）
+	Daily    map[string][]model.Bar // 銘柄コード → 日足（古い順）
+	Intraday map[string][]model.Bar // 銘柄コード → 当日分足（古い順）
+	Sectors  []sector.Stats         // 今回のスキャンの業種別集計（sector.Aggregate）
+
+	// Benchmark は比較対象の指数の気配値（Valid=false なら対指数スコアなし）。
+	// Daily に指数の日足があればベータを推定する。
+	Benchmark model.Quote
+
+	Mode Mode // 急騰・急落・両方（空なら ModeSurge）
+
+	Events *events.Book // 決算・権利落ちなどのイベント（nil ならイベントなし）
+}
+
+// AnalyzeWith scores quotes using every data source present in in, in the
+// direction selected by in.Mode.
+func (p *Profile) AnalyzeWith(quotes []model.Quote, minScore float64, in Inputs) []model.Candidate {
+	var cands []model.Candidate
+	switch in.Mode {
+	case ModePlunge:
+		cands = p.analyzePlunge(quotes, minScore, in)
+	case ModeBoth:
+		cands = mergeModes(p.analyzeSurge(quotes, minScore, in), p.analyzePlunge(quotes, minScore, in))
+	default:
+		cands = p.analyzeSurge(quotes, minScore, in)
+	}
+	if in.Events != nil && p.EventDiscount > 0 {
+		cands = p.discountEvents(cands, minScore)
+	}
+	return cands
+}
+
+func (p *Profile) analyzeSurge(quotes []model.Quote, minScore float64, in Inputs) []model.Candidate {
+	var extras []extraFunc
+	if in.History != nil {
+		extras = append(extras, p.momentumExtra(in.History, in.Window))
+	}
+	if in.Daily != nil || in.Intraday != nil {
+		extras = append(extras, func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+			c.Indicators = computeIndicators(in.Daily[q.Symbol], in.Intraday[q.Symbol])
+			return p.scoreIndicators(q, c.Indicators, in.Daily[q.Symbol])
+		})
+	}
+	if in.Sectors != nil {
+		extras = append(extras, p.sectorExtra(in.Sectors))
+	}
+	if in.Benchmark.Valid {
+		extras = append(extras, p.relativeExtra(in.Benchmark, in.Daily))
+	}
+	if in.Events != nil {
+		extras = append(extras, eventExtra(in.Events))
+	}
+	return p.analyze(quotes, minScore, extras...)
+}
+
+// computeIndicators evaluates every indicator at the latest bar.
+func computeIndicators(daily, intraday []model.Bar) model.Indicators {
+	var ind model.Indicators
+	if len(daily) > 0 {
+		closes := indicator.Closes(daily)
+		ind.RSI, _ = indicator.Last(indicator.RSI(closes, indicator.RSIPeriod))
+		macd, sig, _ := indicator.MACD(closes, indicator.MACDFast, indicator.MACDSlow, indicator.MACDSignal)
+		ind.MACD, _ = indicator.Last(macd)
+		ind.MACDSignal, _ = indicator.Last(sig)
+		_, upper, lower := indicator.Bollinger(closes, indicator.BollingerPeriod, indicator.BollingerK)
+		ind.BollingerUpper, _ = indicator.Last(upper)
+		ind.BollingerLower, _ = indicator.Last(lower)
+		ind.SMAShort, _ = indicator.Last(indicator.SMA(closes, indicator.SMAShort))
+		ind.SMALong, _ = indicator.Last(indicator.SMA(closes, indicator.SMALong))
+		ind.ATR, _ = indicator.Last(indicator.ATR(daily, indicator.ATRPeriod))
+		ind.HasDaily = true
+	}
+	if len(intraday) > 0 {
+		var ok bool
+		ind.VWAP, ok = indicator.Last(indicator.VWAP(intraday))
+		ind.HasIntraday = ok
+	}
+	return ind
+}
+
+// scoreIndicators scores the [H]–[M] components.
+func (p *Profile) scoreIndicators(q model.Quote, ind model.Indicators, daily []model.Bar) (float64, []model.Signal) {
+	var signals []model.Signal
+	total := 0.0
+	add := func(label string, score float64, display string) {
+		total += score
+		signals = append(signals, model.Signal{Label: label, Score: score})
+		if display != "" {
+			signals = append(signals, model.Signal{Label: display, Score: 0})
+		}
+	}
+
+	if ind.HasDaily {
+		closes := indicator.Closes(daily)
+
+		// ---- [H] ゴールデンクロス ----
+		if c := p.GoldenCross; c.Weight > 0 {
+			short := indicator.SMA(closes, indicator.SMAShort)
+			long := indicator.SMA(closes, indicator.SMALong)
+			if indicator.CrossedAbove(short, long, within(c)) {
+				add("ゴールデンクロス", c.Weight, "✨ゴールデンクロス")
+			}
+		}
+
+		// ---- [I] RSI ブレイク ----
+		if c := p.RSI; c.Weight > 0 {
+			rsi := indicator.RSI(closes, indicator.RSIPeriod)
+			level := make([]float64, len(rsi))
+			for i := range level {
+				level[i] = c.Signal
+			}
+			if indicator.CrossedAbove(rsi, level, within(c)) {
+				add("RSIブレイク", c.Weight, "📶RSIブレイク")
+			}
+			if c.Strong > 0 && ind.RSI >= c.Strong {
+				signals = append(signals, model.Signal{Label: "⚠️RSI過熱", Score: 0})
+			}
+		}
+
+		// ---- [J] MACD クロス ----
+		if c := p.MACD; c.Weight > 0 {
+			macd, sig, _ := indicator.MACD(closes, indicator.MACDFast, indicator.MACDSlow, indicator.MACDSignal)
+			if indicator.CrossedAbove(macd, sig, within(c)) {
+				add("MACDクロス", c.Weight, "〽️MACD好転")
+			}
+		}
+
+		// ---- [K] ボリンジャー上抜け ----
+		if c := p.Bollinger; c.Weight > 0 && ind.BollingerUpper > 0 && q.Price > ind.BollingerUpper {
+			add("ボリンジャー", c.Weight, "🎈+2σ突破")
+		}
+
+		// ---- [M] 値幅拡大 ----
+		if c := p.ATR; c.Weight > 0 && ind.ATR > 0 && q.ChangePercent > 0 && q.DayHigh > q.DayLow {
+			ratio := (q.DayHigh - q.DayLow) / ind.ATR
+			if ratio > c.Threshold {
+				display := ""
+				if ratio >= c.Signal {
+					display = "📏値幅拡大"
+				}
+				add("値幅", c.linear(ratio), display)
+			}
+		}
+	}
+
+	// ---- [L] VWAP 乖離 ----
+	if c := p.VWAP; c.Weight > 0 && ind.HasIntraday && ind.VWAP > 0 {
+		dev := (q.Price - ind.VWAP) / ind.VWAP * 100
+		if dev > c.Threshold {
+			add("VWAP乖離", c.linear(dev), "🧭VWAP上")
+		}
+	}
+	return total, signals
+}
+
+// within returns the cross look-back for c (at least the latest bar).
+func within(c Component) int {
+	return max(c.Within, 1)
+}
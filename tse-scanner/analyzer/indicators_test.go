@@ -0,0 +1,106 @@
+package analyzer_test
+
+import (
+	"testing"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+// dailyBars builds daily bars from closes (H/L = close ± 1%).
+func dailyBars(closes ...float64) []model.Bar {
+	start := time.Date(2024, 1, 4, 15, 0, 0, 0, jst)
+	bars := make([]model.Bar, len(closes))
+	for i, c := range closes {
+		bars[i] = model.Bar{Time: start.AddDate(0, 0, i), Open: c, High: c * 1.01, Low: c * 0.99, Close: c, Volume: 1000}
+	}
+	return bars
+}
+
+// declineThenJump returns n closes in an accelerating decline from 1100 and a
+// final jump, which produces a golden cross, RSI breakout and MACD cross on
+// the last bar.
+func declineThenJump(n int, jump float64) []float64 {
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = 1100 - float64(i*i)*0.05
+	}
+	closes[n-1] = jump
+	return closes
+}
+
+// ---- tests ----
+
+func TestAnalyzeWith_DailyIndicatorSignals(t *testing.T) {
+	closes := declineThenJump(60, 1250)
+	q := model.Quote{Symbol: "TEST.T", Price: 1250, ChangePercent: 0, Valid: true}
+	in := analyzer.Inputs{Daily: map[string][]model.Bar{"TEST.T": dailyBars(closes...)}}
+
+	cs := analyzer.DefaultProfile().AnalyzeWith([]model.Quote{q}, 0, in)
+	c := findCandidate(t, cs)
+	if !c.Indicators.HasDaily || c.Indicators.RSI <= 50 {
+		t.Fatalf("indicators not computed: %+v", c.Indicators)
+	}
+	for _, label := range []string{"✨ゴールデンクロス", "📶RSIブレイク", "〽️MACD好転", "🎈+2σ突破"} {
+		if !hasSignal(c.Signals, label) {
+			t.Errorf("want %s signal, got %v", label, c.Signals)
+		}
+	}
+	if c.SurgeScore != 20 {
+		t.Errorf("score: got %f, want 20 (4 components × 5)", c.SurgeScore)
+	}
+}
+
+func TestAnalyzeWith_NoCrossOnSteadyDecline(t *testing.T) {
+	closes := declineThenJump(60, 925.95)
+	q := model.Quote{Symbol: "TEST.T", Price: 925.95, Valid: true}
+	in := analyzer.Inputs{Daily: map[string][]model.Bar{"TEST.T": dailyBars(closes...)}}
+
+	c := findCandidate(t, analyzer.DefaultProfile().AnalyzeWith([]model.Quote{q}, 0, in))
+	if c.SurgeScore != 0 {
+		t.Errorf("score: got %f, want 0 (signals %v)", c.SurgeScore, c.Signals)
+	}
+}
+
+func TestAnalyzeWith_VWAPAndRangeExpansion(t *testing.T) {
+	at := time.Date(2024, 6, 14, 9, 0, 0, 0, jst)
+	intraday := []model.Bar{
+		{Time: at, High: 1000, Low: 1000, Close: 1000, Volume: 100},
+		{Time: at.Add(5 * time.Minute), High: 1000, Low: 1000, Close: 1000, Volume: 100},
+	}
+	// ATR ≈ 20（値幅 ±1%）、当日値幅 40 → 2 倍で満点
+	daily := dailyBars(make30(1000)...)
+	q := model.Quote{Symbol: "TEST.T", Price: 1010, ChangePercent: 1, DayHigh: 1020, DayLow: 980, Valid: true}
+	in := analyzer.Inputs{
+		Daily:    map[string][]model.Bar{"TEST.T": daily},
+		Intraday: map[string][]model.Bar{"TEST.T": intraday},
+	}
+
+	c := findCandidate(t, analyzer.DefaultProfile().AnalyzeWith([]model.Quote{q}, 0, in))
+	if !c.Indicators.HasIntraday || c.Indicators.VWAP != 1000 {
+		t.Errorf("VWAP: %+v", c.Indicators)
+	}
+	if !hasSignal(c.Signals, "🧭VWAP上") || !hasSignal(c.Signals, "📏値幅拡大") {
+		t.Errorf("want VWAP and range signals, got %v", c.Signals)
+	}
+}
+
+func TestAnalyzeWith_WithoutBarsMatchesAnalyze(t *testing.T) {
+	q := newQuote(4.0, 3.0, 1000, 1005, 1100)
+	base := analyzer.Analyze([]model.Quote{q}, 0)
+	with := analyzer.DefaultProfile().AnalyzeWith([]model.Quote{q}, 0, analyzer.Inputs{Daily: map[string][]model.Bar{}})
+	if base[0].SurgeScore != with[0].SurgeScore {
+		t.Errorf("score changed without bars: %f vs %f", base[0].SurgeScore, with[0].SurgeScore)
+	}
+}
+
+func make30(v float64) []float64 {
+	out := make([]float64, 30)
+	for i := range out {
+		out[i] = v
+	}
+	return out
+}
@@ -0,0 +1,23 @@
+package analyzer
+
+import (
+	"tse-scanner/exchange"
+	"tse-scanner/model"
+)
+
+// limitSignals flags stocks at or near their daily price limit (値幅制限).
+// They are display-only: a stock stuck at ストップ高 has no sellers, so the
+// move is real but cannot be bought into, which the reader should see rather
+// than have folded into the score.
+func limitSignals(q model.Quote) []model.Signal {
+	switch exchange.StatusOf(q.Price, q.PrevClose) {
+	case exchange.AtUpper:
+		return []model.Signal{{Label: "🔒ストップ高張り付き", Score: 0}}
+	case exchange.NearUpper:
+		return []model.Signal{{Label: "🔝ストップ高接近", Score: 0}}
+	case exchange.AtLower:
+		return []model.Signal{{Label: "🧱ストップ安", Score: 0}}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,46 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+)
+
+// ---- tests ----
+
+func TestAnalyze_PriceLimitSignals(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  string
+	}{
+		{1300, "🔒ストップ高張り付き"},
+		{1250, "🔝ストップ高接近"},
+		{700, "🧱ストップ安"},
+	}
+	for _, tt := range tests {
+		q := newQuote((tt.price-1000)/10, 3, tt.price, tt.price, 2000)
+		q.PrevClose = 1000
+		got := analyzer.Analyze([]model.Quote{q}, -100)
+		if len(got) != 1 {
+			t.Fatalf("price %v: want 1 candidate, got %d", tt.price, len(got))
+		}
+		c := got[0]
+		if !hasSignal(c.Signals, tt.want) {
+			t.Errorf("price %v: missing %s in %v", tt.price, tt.want, c.Signals)
+		}
+		if c.StopLow != 700 || c.StopHigh != 1300 {
+			t.Errorf("band = %v–%v, want 700–1300", c.StopLow, c.StopHigh)
+		}
+	}
+}
+
+func TestAnalyze_PriceLimitIsDisplayOnly(t *testing.T) {
+	q := newQuote(30, 3, 1300, 1300, 2000)
+	withoutLimit := analyzer.Analyze([]model.Quote{q}, 0)
+	q.PrevClose = 1000
+	withLimit := analyzer.Analyze([]model.Quote{q}, 0)
+	if withLimit[0].SurgeScore != withoutLimit[0].SurgeScore {
+		t.Errorf("limit signal changed score: %v vs %v", withLimit[0].SurgeScore, withoutLimit[0].SurgeScore)
+	}
+}
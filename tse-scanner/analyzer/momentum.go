@@ -0,0 +1,126 @@
+package analyzer
+
+import (
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// Momentum components (default profile, added to Analyze's score and capped
+// at MaxScore):
+//
+//	[E] 短期上昇率スコア   (0–10 点): 直近 window の上昇率。+2% で満点。
+//	[F] 出来高ペーススコア (0–10 点): 立会経過時間で按分した平均出来高との比。4 倍で満点。
+//	[G] 加速スコア         (0–5 点) : 直近 window と その前の window の上昇率の差。+1pt で満点。
+const (
+	DefaultMomentumWindow = 5 * time.Minute
+
+	// minSessionElapsed avoids dividing by a near-zero elapsed time right
+	// after the open, when pace would be wildly inflated.
+	minSessionElapsed = 5 * time.Minute
+)
+
+// AnalyzeWithHistory scores quotes and history with the default profile.
+func AnalyzeWithHistory(quotes []model.Quote, minScore float64, h history.Reader, window time.Duration) []model.Candidate {
+	return DefaultProfile().AnalyzeWithHistory(quotes, minScore, h, window)
+}
+
+// AnalyzeWithHistory is Analyze plus rate-of-change signals computed from
+// recent snapshots in h. window is the look-back for price velocity
+// (DefaultMomentumWindow when zero). Stocks without enough history are scored
+// exactly as Analyze would.
+func (p *Profile) AnalyzeWithHistory(quotes []model.Quote, minScore float64, h history.Reader, window time.Duration) []model.Candidate {
+	return p.analyze(quotes, minScore, p.momentumExtra(h, window))
+}
+
+// momentumExtra scores [E]–[G] from history.
+func (p *Profile) momentumExtra(h history.Reader, window time.Duration) extraFunc {
+	if window <= 0 {
+		window = DefaultMomentumWindow
+	}
+	return func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+		c.Momentum = momentumOf(q, h, window)
+		return p.scoreMomentum(c.Momentum)
+	}
+}
+
+// momentumOf derives velocity, acceleration and volume pace for q.
+// Reference points older than one extra window (e.g. yesterday's last scan)
+// are ignored so gaps never masquerade as moves.
+func momentumOf(q model.Quote, h history.Reader, window time.Duration) model.Momentum {
+	m := model.Momentum{Window: window, VolumePace: volumePace(q)}
+	if h == nil || q.FetchedAt.IsZero() || q.Price <= 0 {
+		return m
+	}
+	now := q.FetchedAt
+
+	p1, ok, err := h.Latest(q.Symbol, now.Add(-window))
+	if err != nil || !ok || p1.At.Before(now.Add(-2*window)) || p1.Price <= 0 {
+		return m
+	}
+	m.Velocity = (q.Price - p1.Price) / p1.Price * 100
+	m.HasVelocity = true
+
+	p2, ok, err := h.Latest(q.Symbol, now.Add(-2*window))
+	if err != nil || !ok || p2.At.Before(now.Add(-3*window)) || p2.Price <= 0 {
+		return m
+	}
+	prev := (p1.Price - p2.Price) / p2.Price * 100
+	m.Acceleration = m.Velocity - prev
+	m.HasAcceleration = true
+	return m
+}
+
+// volumePace compares today's volume with the share of the 3-month average
+// expected by this point of the session. It returns 0 when unavailable.
+func volumePace(q model.Quote) float64 {
+	if q.AvgVolume3M <= 0 || q.FetchedAt.IsZero() {
+		return 0
+	}
+	// 昼休みを除いた立会経過時間（半日立会・2024/11/5 の大引け延長も考慮）
+	elapsed, total := calendar.Default().Elapsed(q.FetchedAt)
+	if elapsed < minSessionElapsed {
+		return 0
+	}
+	expected := float64(q.AvgVolume3M) * float64(elapsed) / float64(total)
+	return float64(q.Volume) / expected
+}
+
+// scoreMomentum scores the [E]–[G] components.
+func (p *Profile) scoreMomentum(m model.Momentum) (float64, []model.Signal) {
+	var signals []model.Signal
+	total := 0.0
+
+	// ---- [E] 短期上昇率スコア ----
+	if c := p.Velocity; c.Weight > 0 && m.HasVelocity && m.Velocity > 0 {
+		velScore := c.linear(m.Velocity)
+		total += velScore
+		signals = append(signals, model.Signal{Label: "短期上昇率", Score: velScore})
+		if m.Velocity >= c.Signal {
+			signals = append(signals, model.Signal{Label: "🔥短期急騰", Score: 0})
+		}
+	}
+
+	// ---- [F] 出来高ペーススコア ----
+	if c := p.VolumePace; c.Weight > 0 && m.VolumePace > c.Threshold {
+		paceScore := c.linear(m.VolumePace)
+		total += paceScore
+		signals = append(signals, model.Signal{Label: "出来高ペース", Score: paceScore})
+		if m.VolumePace >= c.Signal {
+			signals = append(signals, model.Signal{Label: "💥出来高ペース急増", Score: 0})
+		}
+	}
+
+	// ---- [G] 加速スコア ----
+	if c := p.Acceleration; c.Weight > 0 && m.HasAcceleration && m.Acceleration > 0 && m.Velocity > 0 {
+		accScore := c.linear(m.Acceleration)
+		total += accScore
+		signals = append(signals, model.Signal{Label: "加速", Score: accScore})
+		if m.Acceleration >= c.Signal {
+			signals = append(signals, model.Signal{Label: "⏩上昇加速", Score: 0})
+		}
+	}
+	return total, signals
+}
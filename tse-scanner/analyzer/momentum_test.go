@@ -0,0 +1,179 @@
+package analyzer_test
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+// fakeHistory is an in-memory history.Reader.
+type fakeHistory map[string][]history.Point
+
+func (f fakeHistory) Range(symbol string, from, to time.Time) ([]history.Point, error) {
+	var out []history.Point
+	for _, p := range f[symbol] {
+		if !p.At.Before(from) && !p.At.After(to) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeHistory) Latest(symbol string, t time.Time) (history.Point, bool, error) {
+	points := f[symbol]
+	i := sort.Search(len(points), func(i int) bool { return points[i].At.After(t) })
+	if i == 0 {
+		return history.Point{}, false, nil
+	}
+	return points[i-1], true, nil
+}
+
+// minutePrices builds one point per minute ending one minute before end.
+func minutePrices(end time.Time, prices ...float64) []history.Point {
+	points := make([]history.Point, len(prices))
+	for i, p := range prices {
+		points[i] = history.Point{At: end.Add(time.Duration(i-len(prices)) * time.Minute), Price: p}
+	}
+	return points
+}
+
+func momentumQuote(price float64, at time.Time) model.Quote {
+	return model.Quote{Symbol: "TEST.T", Name: "テスト株式", Price: price, FetchedAt: at, Valid: true}
+}
+
+func findCandidate(t *testing.T, cs []model.Candidate) model.Candidate {
+	t.Helper()
+	if len(cs) != 1 {
+		t.Fatalf("want 1 candidate, got %d", len(cs))
+	}
+	return cs[0]
+}
+
+// ---- tests ----
+
+func TestMomentum_VelocitySignal(t *testing.T) {
+	now := time.Date(2024, 6, 14, 10, 0, 0, 0, jst)
+	h := fakeHistory{"TEST.T": minutePrices(now, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000)}
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory(
+		[]model.Quote{momentumQuote(1020, now)}, 0, h, 5*time.Minute))
+
+	if !c.Momentum.HasVelocity || math.Abs(c.Momentum.Velocity-2.0) > 1e-9 {
+		t.Errorf("velocity: got %+v, want 2.0%%", c.Momentum)
+	}
+	if !hasSignal(c.Signals, "🔥短期急騰") {
+		t.Error("want 🔥短期急騰 signal")
+	}
+	// 短期上昇率 10 点 + 加速 5 点（直前 window は横ばい）
+	if math.Abs(c.SurgeScore-15.0) > 1e-9 {
+		t.Errorf("score: got %f, want 15", c.SurgeScore)
+	}
+}
+
+func TestMomentum_Acceleration(t *testing.T) {
+	now := time.Date(2024, 6, 14, 10, 0, 0, 0, jst)
+	// 10 分前 1000 → 5 分前 1000 → 現在 1010: 加速 +1pt
+	h := fakeHistory{"TEST.T": minutePrices(now, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000, 1000)}
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory(
+		[]model.Quote{momentumQuote(1010, now)}, 0, h, 5*time.Minute))
+	if !c.Momentum.HasAcceleration || math.Abs(c.Momentum.Acceleration-1.0) > 1e-9 {
+		t.Errorf("acceleration: got %+v, want 1.0", c.Momentum)
+	}
+	if !hasSignal(c.Signals, "⏩上昇加速") {
+		t.Error("want ⏩上昇加速 signal")
+	}
+}
+
+func TestMomentum_StaleHistoryIgnored(t *testing.T) {
+	now := time.Date(2024, 6, 14, 9, 1, 0, 0, jst)
+	// 前日の終値しかない
+	h := fakeHistory{"TEST.T": {{At: now.Add(-18 * time.Hour), Price: 900}}}
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory(
+		[]model.Quote{momentumQuote(1000, now)}, 0, h, 5*time.Minute))
+	if c.Momentum.HasVelocity {
+		t.Errorf("stale point should not produce velocity: %+v", c.Momentum)
+	}
+	if c.SurgeScore != 0 {
+		t.Errorf("score: got %f, want 0", c.SurgeScore)
+	}
+}
+
+func TestMomentum_VolumePaceNormalisedBySessionTime(t *testing.T) {
+	// 9:33 は立会 330 分中 33 分経過 → 平均の 10% が期待値
+	at := time.Date(2025, 6, 13, 9, 33, 0, 0, jst)
+	q := momentumQuote(1000, at)
+	q.AvgVolume3M = 1000000
+	q.Volume = 300000 // 期待値の 3 倍、平均比では 0.3 倍
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory([]model.Quote{q}, 0, nil, 0))
+	if math.Abs(c.Momentum.VolumePace-3.0) > 1e-9 {
+		t.Errorf("pace: got %f, want 3.0", c.Momentum.VolumePace)
+	}
+	if !hasSignal(c.Signals, "💥出来高ペース急増") {
+		t.Error("want 💥出来高ペース急増 signal")
+	}
+	if hasSignal(c.Signals, "⚡出来高急増") {
+		t.Error("cumulative volume spike should not fire at 0.3x")
+	}
+}
+
+func TestMomentum_VolumePaceExcludesLunchBreak(t *testing.T) {
+	// 12:45 は前場 150 分 + 後場 15 分 = 165 分経過（半分）
+	at := time.Date(2025, 6, 13, 12, 45, 0, 0, jst)
+	q := momentumQuote(1000, at)
+	q.AvgVolume3M = 1000000
+	q.Volume = 1000000
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory([]model.Quote{q}, 0, nil, 0))
+	if math.Abs(c.Momentum.VolumePace-2.0) > 1e-9 {
+		t.Errorf("pace: got %f, want 2.0", c.Momentum.VolumePace)
+	}
+}
+
+func TestMomentum_VolumePaceBeforeCloseExtension(t *testing.T) {
+	// 2024/11/5 より前は 15:00 大引けで立会 300 分 → 9:30 は 10% 経過
+	at := time.Date(2024, 6, 14, 9, 30, 0, 0, jst)
+	q := momentumQuote(1000, at)
+	q.AvgVolume3M = 1000000
+	q.Volume = 300000
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory([]model.Quote{q}, 0, nil, 0))
+	if math.Abs(c.Momentum.VolumePace-3.0) > 1e-9 {
+		t.Errorf("pace: got %f, want 3.0", c.Momentum.VolumePace)
+	}
+}
+
+func TestMomentum_NoVolumePaceRightAfterOpen(t *testing.T) {
+	at := time.Date(2024, 6, 14, 9, 2, 0, 0, jst)
+	q := momentumQuote(1000, at)
+	q.AvgVolume3M = 1000000
+	q.Volume = 100000
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory([]model.Quote{q}, 0, nil, 0))
+	if c.Momentum.VolumePace != 0 {
+		t.Errorf("pace should be unavailable before 5 minutes, got %f", c.Momentum.VolumePace)
+	}
+}
+
+func TestAnalyzeWithHistory_ScoreCappedAt100(t *testing.T) {
+	now := time.Date(2024, 6, 14, 10, 0, 0, 0, jst)
+	q := newQuote(10.0, 10.0, 1000, 1000, 1000)
+	q.FetchedAt = now
+	h := fakeHistory{"TEST.T": minutePrices(now, 900, 900, 900, 900, 900, 950, 950, 950, 950, 950)}
+
+	c := findCandidate(t, analyzer.AnalyzeWithHistory([]model.Quote{q}, 0, h, 5*time.Minute))
+	if c.SurgeScore != 100 {
+		t.Errorf("score: got %f, want 100", c.SurgeScore)
+	}
+}
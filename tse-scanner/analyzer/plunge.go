@@ -0,0 +1,193 @@
+package analyzer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"tse-scanner/model"
+)
+
+// Plunge mode mirrors the surge model for short-selling and risk scans. The
+// same profile components are used with the direction reversed:
+//
+//	[A] 下落率スコア    : 前日比の下落率。−5% で満点。
+//	[B] 出来高スコア    : 下落している銘柄の出来高倍率のみ加点。
+//	[C] 安値圏スコア    : 当日安値と現値の乖離が小さいほど高い。
+//	[D] 新安値スコア    : 52 週安値の更新・接近で加点。
+//	[E]–[G] モメンタム  : 短期下落率・下落中の出来高ペース・下落の加速。
+//
+// Indicator, sector and index components only measure strength, so in
+// plunge mode they fill in the candidate's fields without adding points.
+
+// Mode selects which direction the scanner looks for.
+type Mode string
+
+const (
+	ModeSurge  Mode = "surge"  // 急騰（既定）
+	ModePlunge Mode = "plunge" // 急落
+	ModeBoth   Mode = "both"   // 急騰と急落をスコア順に混ぜる
+)
+
+// Modes lists the accepted -mode values.
+var Modes = []Mode{ModeSurge, ModePlunge, ModeBoth}
+
+// ParseMode validates a -mode value.
+func ParseMode(s string) (Mode, error) {
+	for _, m := range Modes {
+		if string(m) == strings.ToLower(strings.TrimSpace(s)) {
+			return m, nil
+		}
+	}
+	return "", fmt.Errorf("未知のモードです: %s（surge / plunge / both）", s)
+}
+
+func (p *Profile) analyzePlunge(quotes []model.Quote, minScore float64, in Inputs) []model.Candidate {
+	extras := []extraFunc{func(_ model.Quote, c *model.Candidate) (float64, []model.Signal) {
+		c.Plunge = true
+		return 0, nil
+	}}
+	if in.History != nil {
+		window := in.Window
+		if window <= 0 {
+			window = DefaultMomentumWindow
+		}
+		extras = append(extras, func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+			c.Momentum = momentumOf(q, in.History, window)
+			return p.scoreMomentumDown(q, c.Momentum)
+		})
+	}
+	if in.Daily != nil || in.Intraday != nil {
+		extras = append(extras, func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+			c.Indicators = computeIndicators(in.Daily[q.Symbol], in.Intraday[q.Symbol])
+			return 0, nil
+		})
+	}
+	if in.Benchmark.Valid {
+		benchBars := in.Daily[in.Benchmark.Symbol]
+		extras = append(extras, func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+			c.Relative = relativeOf(q, in.Benchmark, in.Daily[q.Symbol], benchBars)
+			return 0, nil
+		})
+	}
+	if in.Events != nil {
+		extras = append(extras, eventExtra(in.Events))
+	}
+	return p.rank(quotes, minScore, p.scorePlunge, extras)
+}
+
+// mergeModes combines surge and plunge candidates by score. A stock scored
+// both ways (e.g. flat on heavy volume) is kept in its stronger direction.
+func mergeModes(surge, plunge []model.Candidate) []model.Candidate {
+	best := make(map[string]int, len(surge)+len(plunge))
+	var out []model.Candidate
+	for _, c := range append(surge, plunge...) {
+		if i, ok := best[c.Symbol]; ok {
+			if c.SurgeScore > out[i].SurgeScore {
+				out[i] = c
+			}
+			continue
+		}
+		best[c.Symbol] = len(out)
+		out = append(out, c)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].SurgeScore > out[j].SurgeScore })
+	return out
+}
+
+// scorePlunge is scoreQuote with the direction reversed.
+func (p *Profile) scorePlunge(q model.Quote, volRatio float64) (float64, []model.Signal) {
+	var signals []model.Signal
+	total := 0.0
+
+	// ---- [A] 下落率スコア ----
+	if c := p.PriceChange; c.Weight > 0 && q.ChangePercent < 0 {
+		drop := -q.ChangePercent
+		priceScore := c.linear(drop)
+		total += priceScore
+		signals = append(signals, model.Signal{Label: "下落率", Score: priceScore})
+
+		if drop >= c.Strong {
+			signals = append(signals, model.Signal{Label: "🩸大幅下落", Score: 0})
+		} else if drop >= c.Signal {
+			signals = append(signals, model.Signal{Label: "📉下落トレンド", Score: 0})
+		}
+	}
+
+	// ---- [B] 出来高スコア（下落時のみ）----
+	if c := p.VolumeRatio; c.Weight > 0 && q.ChangePercent < 0 && volRatio > c.Threshold {
+		volScore := c.linear(volRatio)
+		total += volScore
+		signals = append(signals, model.Signal{Label: "出来高", Score: volScore})
+
+		if volRatio >= c.Signal {
+			signals = append(signals, model.Signal{Label: "⚡出来高急増", Score: 0})
+		}
+	}
+
+	// ---- [C] 安値圏スコア ----
+	if c := p.DayHigh; c.Weight > 0 && q.DayLow > 0 && q.Price > 0 {
+		proximity := q.DayLow / q.Price // 1.0 = 当日最安値ぴったり
+		if proximity >= c.Threshold {
+			lowScore := proximity * c.Weight
+			total += lowScore
+			signals = append(signals, model.Signal{Label: "安値圏", Score: lowScore})
+			signals = append(signals, model.Signal{Label: "🔽安値圏推移", Score: 0})
+		}
+	}
+
+	// ---- [D] 52 週安値スコア ----
+	if c := p.Week52High; c.Weight > 0 && q.WeekLow52 > 0 && q.Price > 0 {
+		ratio52 := q.WeekLow52 / q.Price
+		if ratio52 >= c.Threshold {
+			w52Score := ratio52 * c.Weight
+			total += w52Score
+			signals = append(signals, model.Signal{Label: "52週安値", Score: w52Score})
+			if ratio52 >= 1.0 {
+				signals = append(signals, model.Signal{Label: "🕳52週新安値", Score: 0})
+			} else {
+				signals = append(signals, model.Signal{Label: "🔻52週安値圏", Score: 0})
+			}
+		}
+	}
+
+	return math.Min(total, p.MaxScore), signals
+}
+
+// scoreMomentumDown is scoreMomentum with the direction reversed.
+func (p *Profile) scoreMomentumDown(q model.Quote, m model.Momentum) (float64, []model.Signal) {
+	var signals []model.Signal
+	total := 0.0
+
+	// ---- [E] 短期下落率スコア ----
+	if c := p.Velocity; c.Weight > 0 && m.HasVelocity && m.Velocity < 0 {
+		velScore := c.linear(-m.Velocity)
+		total += velScore
+		signals = append(signals, model.Signal{Label: "短期下落率", Score: velScore})
+		if -m.Velocity >= c.Signal {
+			signals = append(signals, model.Signal{Label: "🧊短期急落", Score: 0})
+		}
+	}
+
+	// ---- [F] 出来高ペーススコア（下落時のみ）----
+	if c := p.VolumePace; c.Weight > 0 && q.ChangePercent < 0 && m.VolumePace > c.Threshold {
+		paceScore := c.linear(m.VolumePace)
+		total += paceScore
+		signals = append(signals, model.Signal{Label: "出来高ペース", Score: paceScore})
+		if m.VolumePace >= c.Signal {
+			signals = append(signals, model.Signal{Label: "💥出来高ペース急増", Score: 0})
+		}
+	}
+
+	// ---- [G] 下落加速スコア ----
+	if c := p.Acceleration; c.Weight > 0 && m.HasAcceleration && m.Acceleration < 0 && m.Velocity < 0 {
+		accScore := c.linear(-m.Acceleration)
+		total += accScore
+		signals = append(signals, model.Signal{Label: "下落加速", Score: accScore})
+		if -m.Acceleration >= c.Signal {
+			signals = append(signals, model.Signal{Label: "⏬下落加速", Score: 0})
+		}
+	}
+	return total, signals
+}
@@ -0,0 +1,76 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+// fallingQuote is down 5% on 4x volume, trading at its day low and a new
+// 52-week low.
+func fallingQuote(sym string) model.Quote {
+	return model.Quote{
+		Symbol: sym, Valid: true,
+		Price: 950, ChangePercent: -5, DayLow: 950, DayHigh: 1000, WeekLow52: 960, WeekHigh52: 1500,
+		Volume: 4_000_000, AvgVolume3M: 1_000_000,
+	}
+}
+
+// ---- tests ----
+
+func TestParseMode(t *testing.T) {
+	if m, err := analyzer.ParseMode(" Plunge "); err != nil || m != analyzer.ModePlunge {
+		t.Errorf("got %q, %v", m, err)
+	}
+	if _, err := analyzer.ParseMode("short"); err == nil {
+		t.Error("want error for unknown mode")
+	}
+}
+
+func TestAnalyzeWith_PlungeMirrorsSurge(t *testing.T) {
+	got := analyzer.DefaultProfile().AnalyzeWith([]model.Quote{fallingQuote("DOWN.T")}, 0, analyzer.Inputs{Mode: analyzer.ModePlunge})
+	if len(got) != 1 || !got[0].Plunge {
+		t.Fatalf("want one plunge candidate, got %+v", got)
+	}
+	c := got[0]
+	// 40（−5%）+ 30（出来高 4 倍）+ 20（安値ぴったり）+ 10 超（52 週安値更新）は上限 100
+	if c.SurgeScore != 100 {
+		t.Errorf("score: got %v, want 100", c.SurgeScore)
+	}
+	for _, label := range []string{"下落率", "🩸大幅下落", "出来高", "🔽安値圏推移", "🕳52週新安値"} {
+		if !hasSignal(c.Signals, label) {
+			t.Errorf("missing %s: %+v", label, c.Signals)
+		}
+	}
+
+	// 上昇銘柄の出来高は急落スコアに数えない
+	up := newQuote(3, 4, 1000, 1000, 2000)
+	got = analyzer.DefaultProfile().AnalyzeWith([]model.Quote{up}, 0, analyzer.Inputs{Mode: analyzer.ModePlunge})
+	if len(got) != 1 || got[0].SurgeScore != 0 {
+		t.Errorf("rising stock should not score as a plunge, got %+v", got)
+	}
+}
+
+func TestAnalyzeWith_BothMergesDirections(t *testing.T) {
+	up := newQuote(5, 4, 1000, 1000, 2000)
+	up.Symbol = "UP.T"
+	quotes := []model.Quote{up, fallingQuote("DOWN.T")}
+
+	got := analyzer.DefaultProfile().AnalyzeWith(quotes, 10, analyzer.Inputs{Mode: analyzer.ModeBoth})
+	if len(got) != 2 {
+		t.Fatalf("want each stock once, got %+v", got)
+	}
+	dirs := map[string]bool{}
+	for _, c := range got {
+		dirs[c.Symbol] = c.Plunge
+	}
+	if dirs["UP.T"] || !dirs["DOWN.T"] {
+		t.Errorf("wrong directions: %v", dirs)
+	}
+	if got[0].SurgeScore < got[1].SurgeScore {
+		t.Errorf("want highest score first: %v, %v", got[0].SurgeScore, got[1].SurgeScore)
+	}
+}
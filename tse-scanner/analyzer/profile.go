@@ -0,0 +1,158 @@
+package analyzer
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed default_profile.yaml
+var defaultProfileYAML []byte
+
+// Component configures one scoring component. Which fields apply depends on
+// the component; see default_profile.yaml for the meaning of each.
+type Component struct {
+	Weight    float64 `yaml:"weight" json:"weight"`                           // 満点（0 で無効）
+	FullAt    float64 `yaml:"full_at,omitempty" json:"full_at,omitempty"`     // 満点となる値
+	Threshold float64 `yaml:"threshold,omitempty" json:"threshold,omitempty"` // 加点が始まる値
+	Signal    float64 `yaml:"signal,omitempty" json:"signal,omitempty"`       // 表示シグナルの閾値
+	Strong    float64 `yaml:"strong,omitempty" json:"strong,omitempty"`       // 強い表示シグナルの閾値
+	Within    int     `yaml:"within,omitempty" json:"within,omitempty"`       // クロスを有効とする直近の本数
+}
+
+// linear scores v from Threshold (0 pt) to FullAt (Weight pt).
+func (c Component) linear(v float64) float64 {
+	if c.Weight <= 0 || v <= c.Threshold {
+		return 0
+	}
+	return min((v-c.Threshold)/(c.FullAt-c.Threshold), 1.0) * c.Weight
+}
+
+// Profile is a complete scoring model: the weights, thresholds and cap of
+// every component. Profiles are loaded from YAML or JSON files.
+type Profile struct {
+	Name     string  `yaml:"name" json:"name"`
+	MaxScore float64 `yaml:"max_score" json:"max_score"`
+
+	PriceChange Component `yaml:"price_change" json:"price_change"`
+	VolumeRatio Component `yaml:"volume_ratio" json:"volume_ratio"`
+	DayHigh     Component `yaml:"day_high" json:"day_high"`
+	Week52High  Component `yaml:"week52_high" json:"week52_high"`
+
+	Velocity     Component `yaml:"velocity" json:"velocity"`
+	VolumePace   Component `yaml:"volume_pace" json:"volume_pace"`
+	Acceleration Component `yaml:"acceleration" json:"acceleration"`
+
+	GoldenCross Component `yaml:"golden_cross" json:"golden_cross"`
+	RSI         Component `yaml:"rsi" json:"rsi"`
+	MACD        Component `yaml:"macd" json:"macd"`
+	Bollinger   Component `yaml:"bollinger" json:"bollinger"`
+	VWAP        Component `yaml:"vwap" json:"vwap"`
+	ATR         Component `yaml:"atr" json:"atr"`
+
+	SectorSurge Component `yaml:"sector_surge" json:"sector_surge"`
+
+	RelativeStrength Component `yaml:"relative_strength" json:"relative_strength"`
+	ExcessReturn     Component `yaml:"excess_return" json:"excess_return"`
+
+	// EventDiscount is the share of the score removed on the day of a
+	// scheduled event (0 = no discount).
+	EventDiscount float64 `yaml:"event_discount,omitempty" json:"event_discount,omitempty"`
+}
+
+// defaultProfile is parsed once; DefaultProfile hands out copies.
+var defaultProfile = func() Profile {
+	p, err := ParseProfile(defaultProfileYAML)
+	if err != nil {
+		panic("analyzer: 組み込みプロファイルが不正です: " + err.Error())
+	}
+	return *p
+}()
+
+// DefaultProfile returns a copy of the built-in profile (40/30/20/10 + momentum).
+func DefaultProfile() *Profile {
+	p := defaultProfile
+	return &p
+}
+
+// LoadProfile reads a scoring profile file (.yaml / .yml / .json).
+func LoadProfile(path string) (*Profile, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("プロファイルを開けません: %w", err)
+	}
+	p, err := ParseProfile(b)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ParseProfile decodes a YAML or JSON profile. Unknown keys are rejected so
+// typos do not silently fall back to zero weights.
+func ParseProfile(b []byte) (*Profile, error) {
+	var p Profile
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&p); err != nil {
+		return nil, fmt.Errorf("プロファイルのパースエラー: %w", err)
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// Validate checks that every enabled component can be scored.
+func (p *Profile) Validate() error {
+	var errs []error
+	if p.MaxScore <= 0 {
+		errs = append(errs, errors.New("max_score は正の値にしてください"))
+	}
+	if p.EventDiscount < 0 || p.EventDiscount >= 1 {
+		errs = append(errs, errors.New("event_discount は 0 以上 1 未満にしてください"))
+	}
+	// signal / strong を使う成分は省略（0）すると全銘柄で表示シグナルが出るため拒否する
+	for _, c := range []struct {
+		name    string
+		c       Component
+		needsFA bool
+		signal  bool
+		strong  bool
+	}{
+		{"price_change", p.PriceChange, true, true, true},
+		{"volume_ratio", p.VolumeRatio, true, true, false},
+		{"day_high", p.DayHigh, false, false, false},
+		{"week52_high", p.Week52High, false, false, false},
+		{"velocity", p.Velocity, true, true, false},
+		{"volume_pace", p.VolumePace, true, true, false},
+		{"acceleration", p.Acceleration, true, true, false},
+		{"golden_cross", p.GoldenCross, false, false, false},
+		{"rsi", p.RSI, false, false, false},
+		{"macd", p.MACD, false, false, false},
+		{"bollinger", p.Bollinger, false, false, false},
+		{"vwap", p.VWAP, true, false, false},
+		{"atr", p.ATR, true, false, false},
+		{"sector_surge", p.SectorSurge, true, false, false},
+		{"relative_strength", p.RelativeStrength, true, false, false},
+		{"excess_return", p.ExcessReturn, true, false, false},
+	} {
+		switch {
+		case c.c.Weight < 0:
+			errs = append(errs, fmt.Errorf("%s.weight は 0 以上にしてください", c.name))
+		case c.c.Weight > 0 && c.needsFA && c.c.FullAt <= c.c.Threshold:
+			errs = append(errs, fmt.Errorf("%s.full_at は threshold（%g）より大きくしてください", c.name, c.c.Threshold))
+		case c.c.Weight > 0 && c.signal && c.c.Signal <= c.c.Threshold:
+			errs = append(errs, fmt.Errorf("%s.signal は threshold（%g）より大きくしてください", c.name, c.c.Threshold))
+		case c.c.Weight > 0 && c.strong && c.c.Strong < c.c.Signal:
+			errs = append(errs, fmt.Errorf("%s.strong は signal（%g）以上にしてください", c.name, c.c.Signal))
+		case c.c.Within < 0:
+			errs = append(errs, fmt.Errorf("%s.within は 0 以上にしてください", c.name))
+		}
+	}
+	return errors.Join(errs...)
+}
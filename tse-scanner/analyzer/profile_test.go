@@ -0,0 +1,113 @@
+package analyzer_test
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+)
+
+func TestDefaultProfile_MatchesBuiltInWeights(t *testing.T) {
+	p := analyzer.DefaultProfile()
+	got := []float64{p.PriceChange.Weight, p.VolumeRatio.Weight, p.DayHigh.Weight, p.Week52High.Weight, p.MaxScore}
+	want := []float64{40, 30, 20, 10, 100}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("weights: got %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestDefaultProfile_ReturnsIndependentCopies(t *testing.T) {
+	p := analyzer.DefaultProfile()
+	p.PriceChange.Weight = 0
+	if analyzer.DefaultProfile().PriceChange.Weight != 40 {
+		t.Error("modifying a returned profile changed the default")
+	}
+}
+
+func TestProfile_CustomWeightsAndThresholds(t *testing.T) {
+	p, err := analyzer.ParseProfile([]byte(`
+name: volume-heavy
+max_score: 100
+price_change: {weight: 20, full_at: 10.0, signal: 3.0, strong: 8.0}
+volume_ratio: {weight: 60, threshold: 1.0, full_at: 3.0, signal: 1.5}
+day_high: {weight: 0}
+week52_high: {weight: 20, threshold: 0.95}
+`))
+	if err != nil {
+		t.Fatalf("ParseProfile: %v", err)
+	}
+	q := newQuote(5.0, 2.0, 990, 1000, 1000)
+	cs := p.Analyze([]model.Quote{q}, 0)
+	if len(cs) != 1 {
+		t.Fatalf("want 1 candidate, got %d", len(cs))
+	}
+	c := cs[0]
+	// 騰落率 5/10×20 = 10、出来高 (2−1)/(3−1)×60 = 30、高値圏 無効、52週 0.99×20 = 19.8
+	if want := 59.8; math.Abs(c.SurgeScore-want) > 1e-9 {
+		t.Errorf("score: got %f, want %f", c.SurgeScore, want)
+	}
+	if hasSignal(c.Signals, "高値圏") {
+		t.Error("disabled day_high component should not score")
+	}
+	if !hasSignal(c.Signals, "📈上昇トレンド") || hasSignal(c.Signals, "🚀大幅上昇") {
+		t.Error("price signals should follow the profile's signal/strong thresholds")
+	}
+	if !hasSignal(c.Signals, "⚡出来高急増") {
+		t.Error("want ⚡出来高急増 at 2x with signal threshold 1.5")
+	}
+}
+
+func TestProfile_MaxScoreCap(t *testing.T) {
+	p := analyzer.DefaultProfile()
+	p.MaxScore = 50
+	cs := p.Analyze([]model.Quote{newQuote(10.0, 10.0, 1000, 1000, 1000)}, 0)
+	if cs[0].SurgeScore != 50 {
+		t.Errorf("score: got %f, want 50", cs[0].SurgeScore)
+	}
+}
+
+func TestParseProfile_RejectsUnknownKeys(t *testing.T) {
+	_, err := analyzer.ParseProfile([]byte("max_score: 100\nprice_chnage: {weight: 40}\n"))
+	if err == nil {
+		t.Error("want error for misspelled component")
+	}
+}
+
+func TestParseProfile_RejectsInvalidComponents(t *testing.T) {
+	cases := map[string]string{
+		"zero max":        "max_score: 0\n",
+		"negative weight": "max_score: 100\nday_high: {weight: -1}\n",
+		"full_at too low": "max_score: 100\nvolume_ratio: {weight: 30, threshold: 1.0, full_at: 1.0}\n",
+		"event discount":  "max_score: 100\nevent_discount: 1\n",
+		"missing signal":  "max_score: 100\nvolume_ratio: {weight: 30, threshold: 1.0, full_at: 4.0}\n",
+		"signal at floor": "max_score: 100\nvelocity: {weight: 10, full_at: 2.0, signal: 0}\n",
+		"missing strong":  "max_score: 100\nprice_change: {weight: 40, full_at: 5.0, signal: 3.0}\n",
+	}
+	for name, doc := range cases {
+		if _, err := analyzer.ParseProfile([]byte(doc)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestLoadProfile_JSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "p.json")
+	doc := `{"name": "price-only", "max_score": 100, "price_change": {"weight": 100, "full_at": 4.0, "signal": 2.0, "strong": 4.0}}`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	p, err := analyzer.LoadProfile(path)
+	if err != nil {
+		t.Fatalf("LoadProfile: %v", err)
+	}
+	cs := p.Analyze([]model.Quote{newQuote(2.0, 5.0, 1000, 1000, 1000)}, 0)
+	if cs[0].SurgeScore != 50 {
+		t.Errorf("score: got %f, want 50", cs[0].SurgeScore)
+	}
+}
@@ -0,0 +1,82 @@
+package analyzer
+
+import (
+	"tse-scanner/calendar"
+	"tse-scanner/indicator"
+	"tse-scanner/model"
+)
+
+// Market-relative components (default profile, scored when Inputs.Benchmark
+// is a valid index quote):
+//
+//	[O] 対指数スコア       (0–10 点): 騰落率 − 指数騰落率。+3pt で満点。
+//	[P] 超過リターンスコア (0–5 点) : 騰落率 − β × 指数騰落率。+3pt で満点。
+//	                                  β は日足（-indicators 有効時）から推定し、なければ 1。
+
+// relativeExtra scores [O]–[P] against the benchmark quote.
+func (p *Profile) relativeExtra(bench model.Quote, daily map[string][]model.Bar) extraFunc {
+	benchBars := daily[bench.Symbol]
+	return func(q model.Quote, c *model.Candidate) (float64, []model.Signal) {
+		c.Relative = relativeOf(q, bench, daily[q.Symbol], benchBars)
+		return p.scoreRelative(c.Relative)
+	}
+}
+
+// relativeOf compares q with bench, estimating beta from daily bars when
+// both series are available.
+func relativeOf(q, bench model.Quote, bars, benchBars []model.Bar) model.Relative {
+	r := model.Relative{Benchmark: bench.Symbol, MarketChange: bench.ChangePercent, Beta: 1}
+	if q.Symbol != bench.Symbol {
+		closes, market := alignCloses(bars, benchBars)
+		if beta, ok := indicator.Last(indicator.Beta(closes, market, indicator.BetaPeriod)); ok {
+			r.Beta, r.HasBeta = beta, true
+		}
+	}
+	r.Strength = q.ChangePercent - bench.ChangePercent
+	r.Excess = q.ChangePercent - r.Beta*bench.ChangePercent
+	return r
+}
+
+// alignCloses returns the closes of the days present in both series, in
+// order. Suspended days or a late listing would otherwise shift one series
+// against the other.
+func alignCloses(bars, benchBars []model.Bar) (closes, market []float64) {
+	if len(bars) == 0 || len(benchBars) == 0 {
+		return nil, nil
+	}
+	byDay := make(map[string]float64, len(benchBars))
+	for _, b := range benchBars {
+		byDay[b.Time.In(calendar.JST).Format("2006-01-02")] = b.Close
+	}
+	for _, b := range bars {
+		if m, ok := byDay[b.Time.In(calendar.JST).Format("2006-01-02")]; ok {
+			closes = append(closes, b.Close)
+			market = append(market, m)
+		}
+	}
+	return closes, market
+}
+
+// scoreRelative scores the [O]–[P] components.
+func (p *Profile) scoreRelative(r model.Relative) (float64, []model.Signal) {
+	var signals []model.Signal
+	total := 0.0
+
+	// ---- [O] 対指数スコア ----
+	if c := p.RelativeStrength; c.Weight > 0 && r.Strength > c.Threshold {
+		score := c.linear(r.Strength)
+		total += score
+		signals = append(signals, model.Signal{Label: "対指数", Score: score})
+		if c.Signal > 0 && r.Strength >= c.Signal {
+			signals = append(signals, model.Signal{Label: "💪指数超え", Score: 0})
+		}
+	}
+
+	// ---- [P] 超過リターンスコア ----
+	if c := p.ExcessReturn; c.Weight > 0 && r.Excess > c.Threshold {
+		score := c.linear(r.Excess)
+		total += score
+		signals = append(signals, model.Signal{Label: "超過リターン", Score: score})
+	}
+	return total, signals
+}
@@ -0,0 +1,88 @@
+package analyzer_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var topix = model.Quote{Symbol: "1306.T", ChangePercent: 1, Valid: true}
+
+// relQuote is a quote that scores nothing on the base components, so only
+// [O]–[P] contribute.
+func relQuote(change float64) model.Quote {
+	return model.Quote{Symbol: "TEST.T", Price: 90, DayHigh: 100, WeekHigh52: 200, ChangePercent: change, Valid: true}
+}
+
+func relativeScore(c model.Candidate) float64 {
+	total := 0.0
+	for _, s := range c.Signals {
+		if s.Label == "対指数" || s.Label == "超過リターン" {
+			total += s.Score
+		}
+	}
+	return total
+}
+
+// ---- tests ----
+
+func TestAnalyzeWith_RelativeStrength(t *testing.T) {
+	p := analyzer.DefaultProfile()
+	p.PriceChange.Weight = 0 // 騰落率そのものの加点を除く
+
+	got := p.AnalyzeWith([]model.Quote{relQuote(4)}, 0, analyzer.Inputs{Benchmark: topix})[0]
+	r := got.Relative
+	if r.Benchmark != "1306.T" || r.Strength != 3 || r.Excess != 3 || r.Beta != 1 || r.HasBeta {
+		t.Errorf("unexpected relative: %+v", r)
+	}
+	// [O] 3pt で満点 10 + [P] 3pt で満点 5
+	if s := relativeScore(got); s != 15 || !hasSignal(got.Signals, "💪指数超え") {
+		t.Errorf("want 15 relative points, got %v %+v", s, got.Signals)
+	}
+
+	// 指数が +3% なら同じ +4% でも加点は小さい
+	strongMarket := topix
+	strongMarket.ChangePercent = 3
+	got = p.AnalyzeWith([]model.Quote{relQuote(4)}, 0, analyzer.Inputs{Benchmark: strongMarket})[0]
+	if s := relativeScore(got); math.Abs(s-5) > 1e-9 {
+		t.Errorf("want 10×1/3 + 5×1/3 = 5 points, got %v", s)
+	}
+
+	// ベンチマークなしなら従来どおり
+	got = p.AnalyzeWith([]model.Quote{relQuote(4)}, 0, analyzer.Inputs{})[0]
+	if got.Relative.Benchmark != "" || relativeScore(got) != 0 {
+		t.Errorf("no benchmark: %+v", got)
+	}
+}
+
+func TestAnalyzeWith_BetaFromAlignedDailyBars(t *testing.T) {
+	start := time.Date(2025, 1, 6, 15, 0, 0, 0, jst)
+	var stock, market []model.Bar
+	m, s := 1000.0, 500.0
+	for i := 0; i < 80; i++ {
+		ret := 0.01 * math.Sin(float64(i))
+		m, s = m*(1+ret), s*(1+1.5*ret)
+		day := start.AddDate(0, 0, i)
+		market = append(market, model.Bar{Time: day, Close: m})
+		if i != 40 { // 売買停止日は指数側だけにある
+			stock = append(stock, model.Bar{Time: day, Close: s})
+		}
+	}
+	in := analyzer.Inputs{
+		Benchmark: model.Quote{Symbol: "1306.T", ChangePercent: 2, Valid: true},
+		Daily:     map[string][]model.Bar{"TEST.T": stock, "1306.T": market},
+	}
+	r := analyzer.DefaultProfile().AnalyzeWith([]model.Quote{relQuote(2)}, 0, in)[0].Relative
+	if !r.HasBeta || math.Abs(r.Beta-1.5) > 0.05 {
+		t.Fatalf("want beta ≈ 1.5, got %+v", r)
+	}
+	// 指数 +2% に対し β1.5 なら期待値は +3%、+2% は超過リターンがマイナス
+	if r.Strength != 0 || math.Abs(r.Excess-(2-r.Beta*2)) > 1e-9 {
+		t.Errorf("unexpected relative: %+v", r)
+	}
+}
@@ -0,0 +1,47 @@
+package analyzer
+
+import (
+	"tse-scanner/model"
+	"tse-scanner/sector"
+)
+
+// Sector component (default profile, scored when Inputs.Sectors is set):
+//
+//	[N] 業種連動 (0–5 点): 所属業種の売買代金加重騰落率。+1% から加点、+3% で満点。
+//	                        上昇銘柄比率が 60% 未満、または 3 銘柄未満の業種は対象外。
+
+// minSectorMembers is the smallest sector that can "move as a whole"; with
+// fewer stocks one name's move would be mistaken for a sector move.
+const minSectorMembers = 3
+
+// sectorExtra scores [N] from this scan's sector aggregates.
+func (p *Profile) sectorExtra(stats []sector.Stats) extraFunc {
+	by := make(map[string]sector.Stats, len(stats))
+	for _, s := range stats {
+		by[s.Sector] = s
+	}
+	return func(q model.Quote, _ *model.Candidate) (float64, []model.Signal) {
+		s, ok := by[q.Sector]
+		if !ok {
+			return 0, nil
+		}
+		return p.scoreSector(q, s)
+	}
+}
+
+// scoreSector scores the [N] component for a stock in sector s.
+func (p *Profile) scoreSector(q model.Quote, s sector.Stats) (float64, []model.Signal) {
+	c := p.SectorSurge
+	if c.Weight <= 0 || s.Count < minSectorMembers || s.Breadth < c.Signal || q.ChangePercent <= 0 {
+		return 0, nil
+	}
+	score := c.linear(s.Strength)
+	if score <= 0 {
+		return 0, nil
+	}
+	signals := []model.Signal{{Label: "業種連動", Score: score}}
+	if s.Strength >= c.FullAt {
+		signals = append(signals, model.Signal{Label: "🌊セクター急騰", Score: 0})
+	}
+	return score, signals
+}
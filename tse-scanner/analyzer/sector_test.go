@@ -0,0 +1,64 @@
+package analyzer_test
+
+import (
+	"testing"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/model"
+	"tse-scanner/sector"
+)
+
+// ---- helpers ----
+
+// sectorQuotes returns n valid quotes in sec, all changing by change%.
+func sectorQuotes(sec string, n int, change float64) []model.Quote {
+	quotes := make([]model.Quote, n)
+	for i := range quotes {
+		quotes[i] = model.Quote{
+			Symbol: sec + string(rune('A'+i)), Sector: sec, Valid: true,
+			Price: 100, PrevClose: 100 / (1 + change/100), ChangePercent: change, Volume: 100,
+		}
+	}
+	return quotes
+}
+
+// ---- tests ----
+
+func TestAnalyzeWith_SectorSurge(t *testing.T) {
+	quotes := append(sectorQuotes("電気機器", 3, 3), sectorQuotes("銀行業", 2, 3)...)
+	in := analyzer.Inputs{Sectors: sector.Aggregate(quotes)}
+	with := analyzer.DefaultProfile().AnalyzeWith(quotes, 0, in)
+	without := analyzer.DefaultProfile().AnalyzeWith(quotes, 0, analyzer.Inputs{})
+
+	base := make(map[string]float64)
+	for _, c := range without {
+		base[c.Symbol] = c.SurgeScore
+	}
+	for _, c := range with {
+		switch c.Sector {
+		case "電気機器":
+			if c.SurgeScore != base[c.Symbol]+5 || !hasSignal(c.Signals, "業種連動") || !hasSignal(c.Signals, "🌊セクター急騰") {
+				t.Errorf("%s: want +5 sector surge, got %v (base %v) %+v", c.Symbol, c.SurgeScore, base[c.Symbol], c.Signals)
+			}
+		case "銀行業":
+			// 2 銘柄では業種全体の動きとみなさない
+			if c.SurgeScore != base[c.Symbol] || hasSignal(c.Signals, "業種連動") {
+				t.Errorf("%s: small sector should not score, got %+v", c.Symbol, c.Signals)
+			}
+		}
+	}
+}
+
+func TestAnalyzeWith_SectorSurgeNeedsBreadth(t *testing.T) {
+	quotes := sectorQuotes("電気機器", 5, 3)
+	for i := 0; i < 3; i++ {
+		quotes[i].ChangePercent = -0.5 // 上昇 2/5 = 40% < 60%
+		quotes[i].Volume = 1
+	}
+	in := analyzer.Inputs{Sectors: sector.Aggregate(quotes)}
+	for _, c := range analyzer.DefaultProfile().AnalyzeWith(quotes, 0, in) {
+		if hasSignal(c.Signals, "業種連動") {
+			t.Errorf("%s: narrow advance should not score: %+v", c.Symbol, c.Signals)
+		}
+	}
+}
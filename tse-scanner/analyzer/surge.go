@@ -1,35 +1,50 @@
 // Package analyzer computes surge scores and detects bullish signals.
 //
-// Scoring model (最大 100 点):
+// Scores are computed from a Profile. The default profile (最大 100 点):
 //
 //	[A] 騰落率スコア  (0–40 点): 終値比上昇率に比例。+5% で満点。
 //	[B] 出来高スコア  (0–30 点): 3ヶ月平均比の出来高倍率に比例。4 倍で満点。
 //	[C] 高値圏スコア  (0–20 点): 当日高値と現値の乖離が小さいほど高い。
 //	[D] 新高値スコア  (0–10 点): 52 週高値更新・接近で加点。
+//
+// AnalyzeWithHistory additionally scores intraday momentum from stored
+// snapshots (see momentum.go). Weights and thresholds can be overridden with
+// a profile file (see default_profile.yaml).
 package analyzer
 
 import (
 	"math"
 	"sort"
 
+	"tse-scanner/exchange"
 	"tse-scanner/model"
 )
 
-// thresholds for signal generation
-const (
-	thresholdVolumeSpike  = 2.0  // 出来高急増と判定する倍率（平均の 2 倍以上）
-	thresholdBigRise      = 5.0  // 大幅上昇と判定する騰落率（%）
-	thresholdRise         = 3.0  // 上昇トレンドと判定する騰落率（%）
-	thresholdNearHigh     = 0.98 // 当日高値の 98% 以上を「高値圏」と判定
-	thresholdNear52W      = 0.99 // 52 週高値の 99% 以上を「新高値圏」と判定
-	thresholdAboveOpen    = 0.0  // 寄り付き以上なら「陽線」
-	maxPriceChangeForFull = 5.0  // 騰落率スコア満点となる上昇率（%）
-	maxVolumeRatioForFull = 4.0  // 出来高スコア満点となる倍率
-)
+// Analyze scores quotes with the default profile.
+func Analyze(quotes []model.Quote, minScore float64) []model.Candidate {
+	return DefaultProfile().Analyze(quotes, minScore)
+}
 
 // Analyze returns surge candidates from the given quotes, sorted by SurgeScore desc.
 // Quotes with Valid=false or SurgeScore below minScore are excluded.
-func Analyze(quotes []model.Quote, minScore float64) []model.Candidate {
+func (p *Profile) Analyze(quotes []model.Quote, minScore float64) []model.Candidate {
+	return p.analyze(quotes, minScore)
+}
+
+// extraFunc scores additional components for one quote and may fill in
+// derived fields of the candidate (Momentum, Indicators).
+type extraFunc func(q model.Quote, c *model.Candidate) (float64, []model.Signal)
+
+// baseFunc scores the snapshot components [A]–[D] of one quote.
+type baseFunc func(q model.Quote, volRatio float64) (float64, []model.Signal)
+
+func (p *Profile) analyze(quotes []model.Quote, minScore float64, extras ...extraFunc) []model.Candidate {
+	return p.rank(quotes, minScore, p.scoreQuote, extras)
+}
+
+// rank scores every valid quote with base plus extras and returns those
+// reaching minScore, highest score first.
+func (p *Profile) rank(quotes []model.Quote, minScore float64, base baseFunc, extras []extraFunc) []model.Candidate {
 	candidates := make([]model.Candidate, 0, len(quotes))
 
 	for _, q := range quotes {
@@ -37,17 +52,22 @@ func Analyze(quotes []model.Quote, minScore float64) []model.Candidate {
 			continue
 		}
 		volRatio := volumeRatio(q)
-		score, signals := scoreQuote(q, volRatio)
+		score, signals := base(q, volRatio)
+
+		c := model.Candidate{Quote: q, VolumeRatio: volRatio}
+		for _, extra := range extras {
+			s, sigs := extra(q, &c)
+			score += s
+			signals = append(signals, sigs...)
+		}
+		score = math.Min(score, p.MaxScore)
 
 		if score < minScore {
 			continue
 		}
-		candidates = append(candidates, model.Candidate{
-			Quote:       q,
-			VolumeRatio: volRatio,
-			SurgeScore:  score,
-			Signals:     signals,
-		})
+		c.StopLow, c.StopHigh = exchange.Band(q.PrevClose)
+		c.SurgeScore, c.Signals = score, append(signals, limitSignals(q)...)
+		candidates = append(candidates, c)
 	}
 
 	sort.Slice(candidates, func(i, j int) bool {
@@ -57,50 +77,50 @@ func Analyze(quotes []model.Quote, minScore float64) []model.Candidate {
 }
 
 // scoreQuote computes the composite surge score and collects triggered signals.
-func scoreQuote(q model.Quote, volRatio float64) (float64, []model.Signal) {
+func (p *Profile) scoreQuote(q model.Quote, volRatio float64) (float64, []model.Signal) {
 	var signals []model.Signal
 	total := 0.0
 
-	// ---- [A] 騰落率スコア (0–40 pt) ----
-	if q.ChangePercent > 0 {
-		priceScore := math.Min(q.ChangePercent/maxPriceChangeForFull, 1.0) * 40.0
+	// ---- [A] 騰落率スコア ----
+	if c := p.PriceChange; c.Weight > 0 && q.ChangePercent > 0 {
+		priceScore := c.linear(q.ChangePercent)
 		total += priceScore
 		signals = append(signals, model.Signal{Label: "騰落率", Score: priceScore})
 
-		if q.ChangePercent >= thresholdBigRise {
+		if q.ChangePercent >= c.Strong {
 			signals = append(signals, model.Signal{Label: "🚀大幅上昇", Score: 0})
-		} else if q.ChangePercent >= thresholdRise {
+		} else if q.ChangePercent >= c.Signal {
 			signals = append(signals, model.Signal{Label: "📈上昇トレンド", Score: 0})
 		}
 	}
 
-	// ---- [B] 出来高スコア (0–30 pt) ----
-	if volRatio > 1.0 {
-		volScore := math.Min((volRatio-1.0)/(maxVolumeRatioForFull-1.0), 1.0) * 30.0
+	// ---- [B] 出来高スコア ----
+	if c := p.VolumeRatio; c.Weight > 0 && volRatio > c.Threshold {
+		volScore := c.linear(volRatio)
 		total += volScore
 		signals = append(signals, model.Signal{Label: "出来高", Score: volScore})
 
-		if volRatio >= thresholdVolumeSpike {
+		if volRatio >= c.Signal {
 			signals = append(signals, model.Signal{Label: "⚡出来高急増", Score: 0})
 		}
 	}
 
-	// ---- [C] 高値圏スコア (0–20 pt) ----
-	if q.DayHigh > 0 && q.Price > 0 {
+	// ---- [C] 高値圏スコア ----
+	if c := p.DayHigh; c.Weight > 0 && q.DayHigh > 0 && q.Price > 0 {
 		proximity := q.Price / q.DayHigh // 1.0 = 当日最高値ぴったり
-		if proximity >= thresholdNearHigh {
-			highScore := proximity * 20.0
+		if proximity >= c.Threshold {
+			highScore := proximity * c.Weight
 			total += highScore
 			signals = append(signals, model.Signal{Label: "高値圏", Score: highScore})
 			signals = append(signals, model.Signal{Label: "🔼高値圏推移", Score: 0})
 		}
 	}
 
-	// ---- [D] 52 週高値スコア (0–10 pt) ----
-	if q.WeekHigh52 > 0 && q.Price > 0 {
+	// ---- [D] 52 週高値スコア ----
+	if c := p.Week52High; c.Weight > 0 && q.WeekHigh52 > 0 && q.Price > 0 {
 		ratio52 := q.Price / q.WeekHigh52
-		if ratio52 >= thresholdNear52W {
-			w52Score := ratio52 * 10.0
+		if ratio52 >= c.Threshold {
+			w52Score := ratio52 * c.Weight
 			total += w52Score
 			signals = append(signals, model.Signal{Label: "52週高値", Score: w52Score})
 			if ratio52 >= 1.0 {
@@ -111,7 +131,7 @@ func scoreQuote(q model.Quote, volRatio float64) (float64, []model.Signal) {
 		}
 	}
 
-	return math.Min(total, 100.0), signals
+	return math.Min(total, p.MaxScore), signals
 }
 
 // volumeRatio returns current volume / 3-month average volume.
@@ -0,0 +1,248 @@
+// Package backtest replays stored snapshots through the analyzer and
+// simulates trading the resulting surge candidates.
+//
+// The simulation is deliberately simple: at every snapshot, each candidate
+// scoring at least Config.Threshold is bought at the snapshot price unless a
+// position in that symbol is already open. Positions are closed on the first
+// later snapshot that hits the take-profit, stop-loss or time stop, or at the
+// end of the data. Returns are per trade, equal-weighted and not compounded.
+package backtest
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/fetcher"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// Exit reasons.
+const (
+	ExitTakeProfit = "利確"
+	ExitStopLoss   = "損切"
+	ExitTimeStop   = "時間切れ"
+	ExitEndOfData  = "期間終了"
+)
+
+// Config configures one backtest run.
+type Config struct {
+	Profile    *analyzer.Profile // nil で標準プロファイル
+	Threshold  float64           // エントリーする最小 SurgeScore
+	TakeProfit float64           // 利確ライン（%、0 で無効）
+	StopLoss   float64           // 損切ライン（%、正の値で指定、0 で無効）
+	TimeStop   time.Duration     // 最大保有時間（0 で無効）
+
+	// History, when set, lets the analyzer score momentum point-in-time.
+	History history.Reader
+	Window  time.Duration
+}
+
+// Trade is one simulated round trip.
+type Trade struct {
+	Symbol     string
+	Name       string
+	EntryAt    time.Time
+	ExitAt     time.Time
+	EntryPrice float64
+	ExitPrice  float64
+	Return     float64 // %
+	Reason     string
+	Score      float64  // エントリー時の SurgeScore
+	Signals    []string // エントリー時に発動していたシグナル
+}
+
+// SignalStat attributes results to one signal label.
+type SignalStat struct {
+	Label     string
+	Trades    int
+	HitRate   float64 // %
+	AvgReturn float64 // %
+}
+
+// Report summarises a run.
+type Report struct {
+	Snapshots   int
+	From, To    time.Time
+	Trades      []Trade
+	HitRate     float64 // 勝率（%）
+	AvgReturn   float64 // 1 トレードあたり平均リターン（%）
+	TotalReturn float64 // リターン合計（%pt）
+	MaxDrawdown float64 // 累積リターン曲線の最大ドローダウン（%pt）
+	BySignal    []SignalStat
+}
+
+// ErrNoSnapshots is returned when there is nothing to replay.
+var ErrNoSnapshots = errors.New("バックテスト対象のスナップショットがありません")
+
+// Run replays snapshots (oldest first) and returns the report.
+func Run(snapshots []fetcher.Snapshot, cfg Config) (Report, error) {
+	if len(snapshots) == 0 {
+		return Report{}, ErrNoSnapshots
+	}
+	profile := cfg.Profile
+	if profile == nil {
+		profile = analyzer.DefaultProfile()
+	}
+	in := analyzer.Inputs{History: cfg.History, Window: cfg.Window}
+
+	rep := Report{Snapshots: len(snapshots), From: snapshots[0].RecordedAt, To: snapshots[len(snapshots)-1].RecordedAt}
+	open := make(map[string]Trade)
+	lastPrice := make(map[string]model.Quote)
+
+	for _, snap := range snapshots {
+		// 1. 保有ポジションの決済判定
+		for _, q := range snap.Quotes {
+			if !q.Valid || q.Price <= 0 {
+				continue
+			}
+			lastPrice[q.Symbol] = q
+			pos, ok := open[q.Symbol]
+			if !ok {
+				continue
+			}
+			if reason := exitReason(pos, q, snap.RecordedAt, cfg); reason != "" {
+				rep.Trades = append(rep.Trades, closeTrade(pos, q.Price, snap.RecordedAt, reason))
+				delete(open, q.Symbol)
+			}
+		}
+
+		// 2. 新規エントリー
+		for _, c := range profile.AnalyzeWith(snap.Quotes, cfg.Threshold, in) {
+			if _, ok := open[c.Symbol]; ok || c.Price <= 0 {
+				continue
+			}
+			if closedAt(rep.Trades, c.Symbol, snap.RecordedAt) {
+				continue // 同じスナップショットでの即時再エントリーはしない
+			}
+			open[c.Symbol] = Trade{
+				Symbol:     c.Symbol,
+				Name:       c.Name,
+				EntryAt:    snap.RecordedAt,
+				EntryPrice: c.Price,
+				Score:      c.SurgeScore,
+				Signals:    signalLabels(c.Signals),
+			}
+		}
+	}
+
+	// 3. 期間終了で残りを決済
+	end := rep.To
+	for sym, pos := range open {
+		price := pos.EntryPrice
+		if q, ok := lastPrice[sym]; ok {
+			price = q.Price
+		}
+		rep.Trades = append(rep.Trades, closeTrade(pos, price, end, ExitEndOfData))
+	}
+
+	summarise(&rep)
+	return rep, nil
+}
+
+func exitReason(t Trade, q model.Quote, at time.Time, cfg Config) string {
+	if !at.After(t.EntryAt) {
+		return ""
+	}
+	ret := (q.Price - t.EntryPrice) / t.EntryPrice * 100
+	switch {
+	case cfg.TakeProfit > 0 && ret >= cfg.TakeProfit:
+		return ExitTakeProfit
+	case cfg.StopLoss > 0 && ret <= -cfg.StopLoss:
+		return ExitStopLoss
+	case cfg.TimeStop > 0 && at.Sub(t.EntryAt) >= cfg.TimeStop:
+		return ExitTimeStop
+	}
+	return ""
+}
+
+func closeTrade(t Trade, price float64, at time.Time, reason string) Trade {
+	t.ExitAt, t.ExitPrice, t.Reason = at, price, reason
+	t.Return = (price - t.EntryPrice) / t.EntryPrice * 100
+	return t
+}
+
+// closedAt reports whether symbol was closed at exactly at.
+func closedAt(trades []Trade, symbol string, at time.Time) bool {
+	for i := len(trades) - 1; i >= 0 && trades[i].ExitAt.Equal(at); i-- {
+		if trades[i].Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// signalLabels returns the labels of signals that contributed points.
+func signalLabels(signals []model.Signal) []string {
+	var labels []string
+	for _, s := range signals {
+		if s.Score > 0 {
+			labels = append(labels, s.Label)
+		}
+	}
+	return labels
+}
+
+// summarise fills the aggregate fields of rep from rep.Trades.
+func summarise(rep *Report) {
+	sort.Slice(rep.Trades, func(i, j int) bool {
+		if !rep.Trades[i].ExitAt.Equal(rep.Trades[j].ExitAt) {
+			return rep.Trades[i].ExitAt.Before(rep.Trades[j].ExitAt)
+		}
+		return rep.Trades[i].Symbol < rep.Trades[j].Symbol
+	})
+	if len(rep.Trades) == 0 {
+		return
+	}
+
+	type acc struct {
+		n, wins int
+		sum     float64
+	}
+	bySignal := make(map[string]*acc)
+	var wins int
+	var equity, peak float64
+	for _, t := range rep.Trades {
+		if t.Return > 0 {
+			wins++
+		}
+		rep.TotalReturn += t.Return
+		equity += t.Return
+		peak = math.Max(peak, equity)
+		rep.MaxDrawdown = math.Max(rep.MaxDrawdown, peak-equity)
+
+		for _, l := range t.Signals {
+			a := bySignal[l]
+			if a == nil {
+				a = &acc{}
+				bySignal[l] = a
+			}
+			a.n++
+			a.sum += t.Return
+			if t.Return > 0 {
+				a.wins++
+			}
+		}
+	}
+	n := float64(len(rep.Trades))
+	rep.HitRate = float64(wins) / n * 100
+	rep.AvgReturn = rep.TotalReturn / n
+
+	for l, a := range bySignal {
+		rep.BySignal = append(rep.BySignal, SignalStat{
+			Label:     l,
+			Trades:    a.n,
+			HitRate:   float64(a.wins) / float64(a.n) * 100,
+			AvgReturn: a.sum / float64(a.n),
+		})
+	}
+	sort.Slice(rep.BySignal, func(i, j int) bool {
+		if rep.BySignal[i].AvgReturn != rep.BySignal[j].AvgReturn {
+			return rep.BySignal[i].AvgReturn > rep.BySignal[j].AvgReturn
+		}
+		return rep.BySignal[i].Label < rep.BySignal[j].Label
+	})
+}
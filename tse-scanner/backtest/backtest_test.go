@@ -0,0 +1,174 @@
+package backtest_test
+
+import (
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tse-scanner/backtest"
+	"tse-scanner/fetcher"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var t0 = time.Date(2024, 6, 14, 9, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+
+// surging is a quote scoring 100 with the default profile (騰落率・出来高・高値圏・52週).
+func surging(symbol string, price float64) model.Quote {
+	return model.Quote{
+		Symbol: symbol, Name: "銘柄" + symbol, Price: price, ChangePercent: 6,
+		Volume: 5000000, AvgVolume3M: 1000000, DayHigh: price, WeekHigh52: price, Valid: true,
+	}
+}
+
+// quiet is a quote scoring 0.
+func quiet(symbol string, price float64) model.Quote {
+	return model.Quote{Symbol: symbol, Price: price, Valid: true}
+}
+
+func snap(minutes int, quotes ...model.Quote) fetcher.Snapshot {
+	at := t0.Add(time.Duration(minutes) * time.Minute)
+	for i := range quotes {
+		quotes[i].FetchedAt = at
+	}
+	return fetcher.Snapshot{RecordedAt: at, Quotes: quotes}
+}
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-9 {
+		t.Errorf("%s: got %f, want %f", name, got, want)
+	}
+}
+
+var cfg = backtest.Config{Threshold: 60, TakeProfit: 3, StopLoss: 2, TimeStop: 30 * time.Minute}
+
+// ---- tests ----
+
+func TestRun_ExitRules(t *testing.T) {
+	snaps := []fetcher.Snapshot{
+		snap(0, surging("A.T", 1000), surging("B.T", 1000), surging("C.T", 1000)),
+		snap(5, quiet("A.T", 1030), quiet("B.T", 980), quiet("C.T", 1010)),
+		snap(30, quiet("A.T", 1000), quiet("B.T", 1000), quiet("C.T", 1005)),
+	}
+	rep, err := backtest.Run(snaps, cfg)
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(rep.Trades) != 3 {
+		t.Fatalf("want 3 trades, got %d: %+v", len(rep.Trades), rep.Trades)
+	}
+	want := map[string]struct {
+		reason string
+		ret    float64
+	}{
+		"A.T": {backtest.ExitTakeProfit, 3},
+		"B.T": {backtest.ExitStopLoss, -2},
+		"C.T": {backtest.ExitTimeStop, 0.5},
+	}
+	for _, tr := range rep.Trades {
+		w := want[tr.Symbol]
+		if tr.Reason != w.reason {
+			t.Errorf("%s reason: got %s, want %s", tr.Symbol, tr.Reason, w.reason)
+		}
+		approx(t, tr.Symbol+" return", tr.Return, w.ret)
+	}
+	approx(t, "hit rate", rep.HitRate, 200.0/3)
+	approx(t, "avg return", rep.AvgReturn, 1.5/3)
+}
+
+func TestRun_EndOfDataClosesAtLastPrice(t *testing.T) {
+	snaps := []fetcher.Snapshot{
+		snap(0, surging("A.T", 1000)),
+		snap(1, quiet("A.T", 1010)),
+	}
+	rep, _ := backtest.Run(snaps, backtest.Config{Threshold: 60})
+	if len(rep.Trades) != 1 || rep.Trades[0].Reason != backtest.ExitEndOfData {
+		t.Fatalf("unexpected trades: %+v", rep.Trades)
+	}
+	approx(t, "return", rep.Trades[0].Return, 1)
+}
+
+func TestRun_NoPyramidingAndNoSameSnapshotReentry(t *testing.T) {
+	snaps := []fetcher.Snapshot{
+		snap(0, surging("A.T", 1000)),
+		snap(1, surging("A.T", 1010)), // 保有中: 追加エントリーしない
+		snap(2, surging("A.T", 1030)), // 利確、同時刻の再エントリーなし
+		snap(3, surging("A.T", 1040)), // 再エントリー
+	}
+	rep, _ := backtest.Run(snaps, cfg)
+	if len(rep.Trades) != 2 {
+		t.Fatalf("want 2 trades, got %+v", rep.Trades)
+	}
+	if !rep.Trades[1].EntryAt.Equal(t0.Add(3 * time.Minute)) {
+		t.Errorf("second entry at %v, want minute 3", rep.Trades[1].EntryAt)
+	}
+}
+
+func TestRun_DrawdownAndSignalAttribution(t *testing.T) {
+	snaps := []fetcher.Snapshot{
+		snap(0, surging("A.T", 1000)),
+		snap(1, quiet("A.T", 1030)), // +3
+		snap(2, surging("B.T", 1000)),
+		snap(3, quiet("B.T", 980)), // −2
+		snap(4, surging("C.T", 1000)),
+		snap(5, quiet("C.T", 980)), // −2
+	}
+	rep, _ := backtest.Run(snaps, cfg)
+	approx(t, "total", rep.TotalReturn, -1)
+	approx(t, "max drawdown", rep.MaxDrawdown, 4)
+
+	var found bool
+	for _, s := range rep.BySignal {
+		if s.Label == "出来高" {
+			found = true
+			if s.Trades != 3 {
+				t.Errorf("出来高 trades: got %d, want 3", s.Trades)
+			}
+			approx(t, "出来高 avg", s.AvgReturn, -1.0/3)
+		}
+	}
+	if !found {
+		t.Errorf("want attribution for 出来高, got %+v", rep.BySignal)
+	}
+}
+
+func TestRun_NoSnapshots(t *testing.T) {
+	if _, err := backtest.Run(nil, cfg); !errors.Is(err, backtest.ErrNoSnapshots) {
+		t.Errorf("err: got %v, want ErrNoSnapshots", err)
+	}
+}
+
+func TestSnapshotsFromHistory_GroupsScans(t *testing.T) {
+	store, err := history.Open(filepath.Join(t.TempDir(), "h.db"), history.Options{Retention: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+
+	at := func(sec int) time.Time { return t0.Add(time.Duration(sec) * time.Second) }
+	a, b := surging("A.T", 1000), surging("B.T", 2000)
+	a.FetchedAt, b.FetchedAt = at(0), at(3) // 1 回目のスキャン（3 秒に分散）
+	a2 := quiet("A.T", 1030)
+	a2.FetchedAt = at(60)                 // 2 回目
+	store.Append([]model.Quote{a, b, a2}) //nolint:errcheck
+
+	snaps, err := backtest.SnapshotsFromHistory(store, t0.Add(-time.Hour), t0.Add(time.Hour), 0,
+		[]model.Stock{{Symbol: "A.T", Name: "エー"}})
+	if err != nil {
+		t.Fatalf("SnapshotsFromHistory: %v", err)
+	}
+	if len(snaps) != 2 {
+		t.Fatalf("want 2 snapshots, got %d", len(snaps))
+	}
+	if len(snaps[0].Quotes) != 1 || snaps[0].Quotes[0].Name != "エー" {
+		t.Errorf("watchlist filter/name not applied: %+v", snaps[0].Quotes)
+	}
+	if q := snaps[0].Quotes[0]; q.AvgVolume3M != 1000000 || q.WeekHigh52 != 1000 {
+		t.Errorf("stored fields not restored: %+v", q)
+	}
+}
@@ -0,0 +1,57 @@
+package backtest
+
+import (
+	"sort"
+	"time"
+
+	"tse-scanner/fetcher"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// DefaultGap is the default SnapshotsFromHistory grouping gap. A single scan
+// of a large universe spreads FetchedAt over several seconds.
+const DefaultGap = 30 * time.Second
+
+// SnapshotsFromHistory rebuilds scan snapshots from a history store.
+// Points are grouped into one snapshot while each is within gap of the
+// group's first point; the snapshot is stamped with the group's last time.
+// stocks, when given, restores names and limits the symbols replayed.
+func SnapshotsFromHistory(s *history.Store, from, to time.Time, gap time.Duration, stocks []model.Stock) ([]fetcher.Snapshot, error) {
+	if gap <= 0 {
+		gap = DefaultGap
+	}
+	names := make(map[string]model.Stock, len(stocks))
+	for _, st := range stocks {
+		names[st.Symbol] = st
+	}
+
+	var quotes []model.Quote
+	err := s.Each(from, to, func(symbol string, p history.Point) error {
+		st, known := names[symbol]
+		if len(names) > 0 && !known {
+			return nil
+		}
+		q := p.Quote(symbol)
+		q.Name, q.Sector = st.Name, st.Sector
+		quotes = append(quotes, q)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(quotes, func(i, j int) bool { return quotes[i].FetchedAt.Before(quotes[j].FetchedAt) })
+
+	var snaps []fetcher.Snapshot
+	var start time.Time
+	for _, q := range quotes {
+		if len(snaps) == 0 || q.FetchedAt.Sub(start) > gap {
+			start = q.FetchedAt
+			snaps = append(snaps, fetcher.Snapshot{})
+		}
+		cur := &snaps[len(snaps)-1]
+		cur.Quotes = append(cur.Quotes, q)
+		cur.RecordedAt = q.FetchedAt
+	}
+	return snaps, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/fetcher"
+	"tse-scanner/model"
+)
+
+// barSource fetches the bars used for technical indicators. Daily bars are
+// cached for the trading day; intraday bars are refetched on every scan.
+type barSource struct {
+	client *fetcher.Client
+	day    string
+	daily  map[string][]model.Bar
+}
+
+func newBarSource(c *fetcher.Client) *barSource {
+	return &barSource{client: c, daily: make(map[string][]model.Bar)}
+}
+
+// load returns daily and intraday bars for symbols. Symbols whose bars could
+// not be fetched are absent from the maps (their indicators are not scored).
+func (b *barSource) load(ctx context.Context, symbols []string) (daily, intraday map[string][]model.Bar) {
+	if today := time.Now().In(calendar.JST).Format("2006-01-02"); today != b.day {
+		b.day, b.daily = today, make(map[string][]model.Bar)
+	}
+	var missing []string
+	for _, s := range symbols {
+		if _, ok := b.daily[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+	for s, bars := range b.client.FetchBarsAll(ctx, missing, fetcher.IntervalDaily, fetcher.RangeDaily) {
+		b.daily[s] = bars
+	}
+
+	daily = make(map[string][]model.Bar, len(symbols))
+	for _, s := range symbols {
+		if bars, ok := b.daily[s]; ok {
+			daily[s] = bars
+		}
+	}
+	intraday = b.client.FetchBarsAll(ctx, symbols, fetcher.Interval5Min, fetcher.RangeIntraday)
+	return daily, intraday
+}
@@ -0,0 +1,264 @@
+// Package calendar knows when the Tokyo Stock Exchange is open: business
+// days (weekends, national holidays and the 12/31–1/3 year-end break are
+// closed), half-day sessions and the intraday phases from pre-open to the
+// closing auction.
+//
+// The afternoon session was extended from 15:00 to 15:30 on 2024-11-05,
+// with a closing auction from 15:25; sessions before that date use the old
+// 15:00 close.
+package calendar
+
+import (
+	"bytes"
+	_ "embed"
+	"fmt"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed holidays.yaml
+var holidaysYAML []byte
+
+// JST is the exchange's time zone.
+var JST = time.FixedZone("JST", 9*60*60)
+
+// ExtendedClose is the first day of the 15:30 close and closing auction.
+var ExtendedClose = time.Date(2024, 11, 5, 0, 0, 0, 0, JST)
+
+// Phase is the state of the market at a point in time.
+type Phase int
+
+const (
+	PhaseHoliday          Phase = iota // 休場日（土日・祝日・年末年始）
+	PhaseBeforeOpen                    // 8:00 まで
+	PhasePreOpen                       // 8:00–9:00 寄付前（注文受付）
+	PhaseMorning                       // 9:00–11:30 前場
+	PhaseLunch                         // 11:30–12:05 昼休み
+	PhaseAfternoonPreOpen              // 12:05–12:30 後場寄付前
+	PhaseAfternoon                     // 12:30–15:25 後場（2024/11/5 より前は 15:00 まで）
+	PhaseClosingAuction                // 15:25–15:30 クロージング・オークション
+	PhaseClosed                        // 大引け後
+)
+
+var phaseNames = [...]string{
+	"休場", "取引開始前", "寄付前", "前場", "昼休み", "後場寄付前", "後場", "クロージング・オークション", "取引終了",
+}
+
+func (p Phase) String() string { return phaseNames[p] }
+
+// Trading reports whether prices move continuously (or at the closing
+// auction) during p.
+func (p Phase) Trading() bool {
+	return p == PhaseMorning || p == PhaseAfternoon || p == PhaseClosingAuction
+}
+
+// Session is one phase of a business day.
+type Session struct {
+	Phase      Phase
+	Start, End time.Time
+}
+
+// Calendar answers business-day and session questions for the TSE.
+type Calendar struct {
+	holidays map[string]string // "2006-01-02" → 名称
+	halfDays map[string]string // 前場のみの立会日
+}
+
+// file is the holiday data format (holidays.yaml and -calendar files).
+type file struct {
+	Holidays map[string]string `yaml:"holidays"`
+	HalfDays map[string]string `yaml:"half_days"`
+}
+
+// builtin is parsed once; Default hands out the same read-only Calendar.
+var builtin = func() *Calendar {
+	c := &Calendar{holidays: make(map[string]string), halfDays: make(map[string]string)}
+	if err := c.merge(holidaysYAML); err != nil {
+		panic("calendar: 組み込みの休業日データが不正です: " + err.Error())
+	}
+	return c
+}()
+
+var current atomic.Pointer[Calendar]
+
+func init() { current.Store(builtin) }
+
+// Default returns the calendar used by the analyzer and display: the
+// built-in data unless SetDefault replaced it.
+func Default() *Calendar { return current.Load() }
+
+// SetDefault replaces the calendar returned by Default (e.g. with -calendar).
+func SetDefault(c *Calendar) { current.Store(c) }
+
+// Load returns the built-in calendar extended with the holidays and half
+// days in path (same YAML format as the built-in holidays.yaml).
+func Load(path string) (*Calendar, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("休業日ファイルを開けません: %w", err)
+	}
+	c := &Calendar{holidays: make(map[string]string), halfDays: make(map[string]string)}
+	for k, v := range builtin.holidays {
+		c.holidays[k] = v
+	}
+	for k, v := range builtin.halfDays {
+		c.halfDays[k] = v
+	}
+	if err := c.merge(b); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+func (c *Calendar) merge(b []byte) error {
+	var f file
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil {
+		return fmt.Errorf("休業日データのパースエラー: %w", err)
+	}
+	for _, m := range []struct {
+		src, dst map[string]string
+	}{{f.Holidays, c.holidays}, {f.HalfDays, c.halfDays}} {
+		for day, name := range m.src {
+			if _, err := time.ParseInLocation(dateLayout, day, JST); err != nil {
+				return fmt.Errorf("日付が不正です: %s", day)
+			}
+			m.dst[day] = name
+		}
+	}
+	return nil
+}
+
+const dateLayout = "2006-01-02"
+
+// Holiday returns the reason the exchange is closed on t's day, if it is.
+func (c *Calendar) Holiday(t time.Time) (string, bool) {
+	t = t.In(JST)
+	switch wd := t.Weekday(); wd {
+	case time.Saturday, time.Sunday:
+		return "週末", true
+	}
+	if m, d := t.Month(), t.Day(); (m == time.December && d == 31) || (m == time.January && d <= 3) {
+		return "年末年始", true
+	}
+	name, ok := c.holidays[t.Format(dateLayout)]
+	return name, ok
+}
+
+// IsTradingDay reports whether the exchange opens on t's day.
+func (c *Calendar) IsTradingDay(t time.Time) bool {
+	_, closed := c.Holiday(t)
+	return !closed
+}
+
+// IsHalfDay reports whether t's day only has a morning session.
+func (c *Calendar) IsHalfDay(t time.Time) bool {
+	_, ok := c.halfDays[t.In(JST).Format(dateLayout)]
+	return ok && c.IsTradingDay(t)
+}
+
+// Sessions returns the phases of t's day from pre-open to the close, or
+// nil on a holiday.
+func (c *Calendar) Sessions(t time.Time) []Session {
+	if !c.IsTradingDay(t) {
+		return nil
+	}
+	t = t.In(JST)
+	at := func(h, m int) time.Time {
+		return time.Date(t.Year(), t.Month(), t.Day(), h, m, 0, 0, JST)
+	}
+	sessions := []Session{
+		{PhasePreOpen, at(8, 0), at(9, 0)},
+		{PhaseMorning, at(9, 0), at(11, 30)},
+	}
+	if c.IsHalfDay(t) {
+		return sessions
+	}
+	sessions = append(sessions,
+		Session{PhaseLunch, at(11, 30), at(12, 5)},
+		Session{PhaseAfternoonPreOpen, at(12, 5), at(12, 30)},
+	)
+	if at(0, 0).Before(ExtendedClose) {
+		return append(sessions, Session{PhaseAfternoon, at(12, 30), at(15, 0)})
+	}
+	return append(sessions,
+		Session{PhaseAfternoon, at(12, 30), at(15, 25)},
+		Session{PhaseClosingAuction, at(15, 25), at(15, 30)},
+	)
+}
+
+// PhaseAt returns the market phase at t.
+func (c *Calendar) PhaseAt(t time.Time) Phase {
+	sessions := c.Sessions(t)
+	if sessions == nil {
+		return PhaseHoliday
+	}
+	if t.Before(sessions[0].Start) {
+		return PhaseBeforeOpen
+	}
+	for _, s := range sessions {
+		if t.Before(s.End) {
+			return s.Phase
+		}
+	}
+	return PhaseClosed
+}
+
+// Status is a short label for display, e.g. "🟢 前場" or "🔴 休場（元日）".
+func (c *Calendar) Status(t time.Time) string {
+	switch p := c.PhaseAt(t); p {
+	case PhaseHoliday:
+		name, _ := c.Holiday(t)
+		return "🔴 休場（" + name + "）"
+	case PhaseMorning, PhaseAfternoon:
+		return "🟢 " + p.String()
+	case PhaseClosingAuction:
+		return "🟠 引け前"
+	case PhaseClosed:
+		return "🔴 " + p.String()
+	default:
+		return "🟡 " + p.String()
+	}
+}
+
+// Elapsed returns how much trading time has passed on t's day and the
+// day's total trading time, excluding breaks. Both are zero on holidays.
+func (c *Calendar) Elapsed(t time.Time) (elapsed, total time.Duration) {
+	for _, s := range c.Sessions(t) {
+		if !s.Phase.Trading() {
+			continue
+		}
+		total += s.End.Sub(s.Start)
+		switch {
+		case !t.Before(s.End):
+			elapsed += s.End.Sub(s.Start)
+		case t.After(s.Start):
+			elapsed += t.Sub(s.Start)
+		}
+	}
+	return elapsed, total
+}
+
+// NextTrading returns t if the market is trading at t, otherwise the start
+// of the next trading phase (the afternoon session, or the next business
+// day's morning session).
+func (c *Calendar) NextTrading(t time.Time) time.Time {
+	day := t.In(JST)
+	for i := 0; i < 366; i++ {
+		for _, s := range c.Sessions(day) {
+			if !s.Phase.Trading() || !t.Before(s.End) {
+				continue
+			}
+			if t.Before(s.Start) {
+				return s.Start
+			}
+			return t
+		}
+		day = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, JST)
+	}
+	return t // 休業日データが 1 年以上休場を示すことはないが念のため
+}
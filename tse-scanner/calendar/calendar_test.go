@@ -0,0 +1,160 @@
+package calendar_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tse-scanner/calendar"
+)
+
+// ---- helpers ----
+
+func at(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, calendar.JST)
+}
+
+// ---- tests ----
+
+func TestHoliday(t *testing.T) {
+	cal := calendar.Default()
+	cases := []struct {
+		day  time.Time
+		want string
+	}{
+		{at(2025, 1, 1, 10, 0), "年末年始"},
+		{at(2025, 1, 3, 10, 0), "年末年始"},
+		{at(2024, 12, 31, 10, 0), "年末年始"},
+		{at(2025, 1, 13, 10, 0), "成人の日"},
+		{at(2026, 9, 22, 10, 0), "国民の休日"},
+		{at(2025, 6, 14, 10, 0), "週末"},
+		{at(2025, 1, 6, 10, 0), ""},   // 大発会
+		{at(2025, 12, 30, 10, 0), ""}, // 大納会
+	}
+	for _, tc := range cases {
+		name, closed := cal.Holiday(tc.day)
+		if closed != (tc.want != "") || name != tc.want {
+			t.Errorf("%s: got %q (closed=%v), want %q", tc.day.Format("2006-01-02"), name, closed, tc.want)
+		}
+	}
+}
+
+func TestPhaseAt(t *testing.T) {
+	cal := calendar.Default()
+	cases := []struct {
+		t    time.Time
+		want calendar.Phase
+	}{
+		{at(2025, 6, 13, 7, 59), calendar.PhaseBeforeOpen},
+		{at(2025, 6, 13, 8, 0), calendar.PhasePreOpen},
+		{at(2025, 6, 13, 9, 0), calendar.PhaseMorning},
+		{at(2025, 6, 13, 11, 30), calendar.PhaseLunch},
+		{at(2025, 6, 13, 12, 10), calendar.PhaseAfternoonPreOpen},
+		{at(2025, 6, 13, 15, 10), calendar.PhaseAfternoon},
+		{at(2025, 6, 13, 15, 27), calendar.PhaseClosingAuction},
+		{at(2025, 6, 13, 15, 30), calendar.PhaseClosed},
+		{at(2024, 6, 14, 15, 10), calendar.PhaseClosed}, // 延長前は 15:00 大引け
+		{at(2025, 6, 14, 10, 0), calendar.PhaseHoliday},
+	}
+	for _, tc := range cases {
+		if got := cal.PhaseAt(tc.t); got != tc.want {
+			t.Errorf("%s: got %s, want %s", tc.t.Format("2006-01-02 15:04"), got, tc.want)
+		}
+	}
+}
+
+func TestElapsed(t *testing.T) {
+	cal := calendar.Default()
+	cases := []struct {
+		t              time.Time
+		elapsed, total time.Duration
+	}{
+		{at(2025, 6, 13, 8, 30), 0, 330 * time.Minute},
+		{at(2025, 6, 13, 10, 0), 60 * time.Minute, 330 * time.Minute},
+		{at(2025, 6, 13, 12, 0), 150 * time.Minute, 330 * time.Minute},
+		{at(2025, 6, 13, 15, 28), 328 * time.Minute, 330 * time.Minute},
+		{at(2025, 6, 13, 18, 0), 330 * time.Minute, 330 * time.Minute},
+		{at(2024, 6, 14, 18, 0), 300 * time.Minute, 300 * time.Minute},
+		{at(2025, 6, 14, 12, 0), 0, 0},
+	}
+	for _, tc := range cases {
+		e, total := cal.Elapsed(tc.t)
+		if e != tc.elapsed || total != tc.total {
+			t.Errorf("%s: got %v/%v, want %v/%v", tc.t.Format("2006-01-02 15:04"), e, total, tc.elapsed, tc.total)
+		}
+	}
+}
+
+func TestNextTrading(t *testing.T) {
+	cal := calendar.Default()
+	cases := []struct {
+		t, want time.Time
+	}{
+		{at(2025, 6, 13, 10, 0), at(2025, 6, 13, 10, 0)},   // 取引中
+		{at(2025, 6, 13, 7, 0), at(2025, 6, 13, 9, 0)},     // 寄付前
+		{at(2025, 6, 13, 11, 45), at(2025, 6, 13, 12, 30)}, // 昼休み
+		{at(2025, 6, 13, 16, 0), at(2025, 6, 16, 9, 0)},    // 金曜引け後 → 月曜
+		{at(2025, 1, 10, 16, 0), at(2025, 1, 14, 9, 0)},    // 成人の日の 3 連休
+		{at(2025, 12, 30, 16, 0), at(2026, 1, 5, 9, 0)},    // 年末年始
+	}
+	for _, tc := range cases {
+		if got := cal.NextTrading(tc.t); !got.Equal(tc.want) {
+			t.Errorf("%s: got %s, want %s", tc.t.Format("2006-01-02 15:04"), got.Format("2006-01-02 15:04"), tc.want.Format("2006-01-02 15:04"))
+		}
+	}
+}
+
+func TestLoad_AddsHolidaysAndHalfDays(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.yaml")
+	doc := `
+holidays:
+  "2028-01-10": 成人の日
+half_days:
+  "2025-12-30": 大納会
+`
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cal, err := calendar.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if name, ok := cal.Holiday(at(2028, 1, 10, 10, 0)); !ok || name != "成人の日" {
+		t.Errorf("added holiday: got %q, %v", name, ok)
+	}
+	if _, ok := cal.Holiday(at(2025, 1, 13, 10, 0)); !ok {
+		t.Error("built-in holidays should be kept")
+	}
+
+	half := at(2025, 12, 30, 13, 0)
+	if !cal.IsHalfDay(half) || cal.PhaseAt(half) != calendar.PhaseClosed {
+		t.Errorf("half day: phase %s at 13:00", cal.PhaseAt(half))
+	}
+	if _, total := cal.Elapsed(half); total != 150*time.Minute {
+		t.Errorf("half day total: got %v, want 2h30m", total)
+	}
+	if calendar.Default().IsHalfDay(half) {
+		t.Error("Load must not modify the default calendar")
+	}
+}
+
+func TestLoad_RejectsInvalidDates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calendar.yaml")
+	if err := os.WriteFile(path, []byte("holidays:\n  \"2025/01/01\": 元日\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := calendar.Load(path); err == nil {
+		t.Error("want error for invalid date")
+	}
+}
+
+func TestStatus(t *testing.T) {
+	cal := calendar.Default()
+	if got := cal.Status(at(2025, 1, 13, 10, 0)); got != "🔴 休場（成人の日）" {
+		t.Errorf("holiday status: %q", got)
+	}
+	if got := cal.Status(at(2025, 6, 13, 13, 0)); got != "🟢 後場" {
+		t.Errorf("afternoon status: %q", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"tse-scanner/analyzer"
+	"tse-scanner/backtest"
+	"tse-scanner/calendar"
+	"tse-scanner/fetcher"
+	"tse-scanner/history"
+	"tse-scanner/model"
+	"tse-scanner/watchlist"
+)
+
+const backtestUsage = `使い方: tse-scanner backtest [flags]
+
+  保存済みスナップショット（-history の DB、または -record の NDJSON）を
+  急騰スコアで再生し、エントリー／決済をシミュレーションします。
+
+フラグ:
+`
+
+// runBacktest implements the "tse-scanner backtest" subcommand.
+func runBacktest(args []string) error {
+	fs := flag.NewFlagSet("backtest", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, backtestUsage)
+		fs.PrintDefaults()
+	}
+	var (
+		dbPath      = fs.String("db", history.DefaultPath(), "履歴データベースのパス")
+		replayFile  = fs.String("replay-file", "", "-db の代わりに使う記録ファイル（NDJSON）")
+		fromArg     = fs.String("from", "", "開始日時（2006-01-02 または RFC3339、既定: 全期間）")
+		toArg       = fs.String("to", "", "終了日時（2006-01-02 はその日の終わりまで）")
+		gap         = fs.Duration("gap", backtest.DefaultGap, "-db のポイントを 1 スナップショットにまとめる間隔")
+		threshold   = fs.Float64("threshold", 60, "エントリーする最小急騰スコア")
+		takeProfit  = fs.Float64("tp", 3, "利確ライン（%、0 で無効）")
+		stopLoss    = fs.Float64("sl", 2, "損切ライン（%、0 で無効）")
+		timeStop    = fs.Duration("hold", time.Hour, "最大保有時間（0 で無効）")
+		profilePath = fs.String("profile", "", "スコアリングプロファイル（YAML / JSON）")
+		momentumWin = fs.Duration("momentum-window", analyzer.DefaultMomentumWindow, "短期モメンタムを測る期間（-db 使用時）")
+		wlArg       = fs.String("watchlist", "", "対象を絞るウォッチリスト（名前またはファイル、既定: 全銘柄）")
+		wlDir       = fs.String("watchlist-dir", watchlist.DefaultDir(), "名前付きウォッチリストの保存ディレクトリ")
+		showTrades  = fs.Bool("trades", false, "個別トレードを一覧表示")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	from, err := parseTime(*fromArg, false)
+	if err != nil {
+		return err
+	}
+	to, err := parseTime(*toArg, true)
+	if err != nil {
+		return err
+	}
+	cfg := backtest.Config{
+		Profile:    analyzer.DefaultProfile(),
+		Threshold:  *threshold,
+		TakeProfit: *takeProfit,
+		StopLoss:   *stopLoss,
+		TimeStop:   *timeStop,
+		Window:     *momentumWin,
+	}
+	if *profilePath != "" {
+		if cfg.Profile, err = analyzer.LoadProfile(*profilePath); err != nil {
+			return err
+		}
+	}
+	var stocks []model.Stock
+	if *wlArg != "" {
+		if stocks, err = watchlist.NewStore(*wlDir).Resolve(*wlArg); err != nil {
+			return err
+		}
+	}
+
+	var snaps []fetcher.Snapshot
+	if *replayFile != "" {
+		r, err := fetcher.NewReplay(*replayFile)
+		if err != nil {
+			return err
+		}
+		snaps = filterSnapshots(r.Snapshots(), from, to, stocks)
+	} else {
+		store, err := openHistory(*dbPath)
+		if err != nil {
+			return err
+		}
+		defer store.Close()
+		if snaps, err = backtest.SnapshotsFromHistory(store, from, to, *gap, stocks); err != nil {
+			return err
+		}
+		cfg.History = store
+	}
+
+	rep, err := backtest.Run(snaps, cfg)
+	if err != nil {
+		return err
+	}
+	printBacktest(rep, cfg, *showTrades)
+	return nil
+}
+
+// parseTime parses a -from/-to value in JST. A bare date used as an upper
+// bound means the end of that day. Empty means unbounded.
+func parseTime(v string, end bool) (time.Time, error) {
+	switch {
+	case v == "" && end:
+		return time.Now().Add(24 * time.Hour), nil
+	case v == "":
+		return time.Unix(0, 0), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", v, calendar.JST); err == nil {
+		if end {
+			t = t.Add(24*time.Hour - time.Nanosecond)
+		}
+		return t, nil
+	}
+	t, err := time.Parse(time.RFC3339, v)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("日時の形式が不正です: %q（2006-01-02 または RFC3339）", v)
+	}
+	return t, nil
+}
+
+// filterSnapshots keeps snapshots in [from, to], restricted to stocks when given.
+func filterSnapshots(snaps []fetcher.Snapshot, from, to time.Time, stocks []model.Stock) []fetcher.Snapshot {
+	keep := make(map[string]bool, len(stocks))
+	for _, s := range stocks {
+		keep[s.Symbol] = true
+	}
+	var out []fetcher.Snapshot
+	for _, s := range snaps {
+		if s.RecordedAt.Before(from) || s.RecordedAt.After(to) {
+			continue
+		}
+		if len(keep) > 0 {
+			var quotes []model.Quote
+			for _, q := range s.Quotes {
+				if keep[q.Symbol] {
+					quotes = append(quotes, q)
+				}
+			}
+			s.Quotes = quotes
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+func printBacktest(rep backtest.Report, cfg backtest.Config, showTrades bool) {
+	fmt.Printf("📊 バックテスト結果（%s 〜 %s、%d スナップショット）\n",
+		rep.From.In(calendar.JST).Format("2006-01-02 15:04"), rep.To.In(calendar.JST).Format("2006-01-02 15:04"), rep.Snapshots)
+	fmt.Printf("   条件: スコア %.0f 以上 / 利確 %+.1f%% / 損切 -%.1f%% / 最大保有 %s / プロファイル %s\n\n",
+		cfg.Threshold, cfg.TakeProfit, cfg.StopLoss, cfg.TimeStop, cfg.Profile.Name)
+
+	if len(rep.Trades) == 0 {
+		fmt.Println("   エントリー条件を満たす銘柄はありませんでした。")
+		return
+	}
+	fmt.Printf("   トレード数      %d\n", len(rep.Trades))
+	fmt.Printf("   勝率            %.1f%%\n", rep.HitRate)
+	fmt.Printf("   平均リターン    %+.2f%%\n", rep.AvgReturn)
+	fmt.Printf("   リターン合計    %+.2f%%pt\n", rep.TotalReturn)
+	fmt.Printf("   最大ドローダウン %.2f%%pt\n\n", rep.MaxDrawdown)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "シグナル\t件数\t勝率\t平均リターン")
+	for _, s := range rep.BySignal {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%+.2f%%\n", s.Label, s.Trades, s.HitRate, s.AvgReturn)
+	}
+	tw.Flush() //nolint:errcheck
+
+	if !showTrades {
+		return
+	}
+	fmt.Println()
+	tw = tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "コード\t銘柄名\tエントリー\t決済\t買値\t売値\tリターン\t理由\tスコア\tシグナル")
+	for _, t := range rep.Trades {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%.1f\t%.1f\t%+.2f%%\t%s\t%.0f\t%s\n",
+			t.Symbol, t.Name, t.EntryAt.In(calendar.JST).Format("01/02 15:04"), t.ExitAt.In(calendar.JST).Format("01/02 15:04"),
+			t.EntryPrice, t.ExitPrice, t.Return, t.Reason, t.Score, strings.Join(t.Signals, ","))
+	}
+	tw.Flush() //nolint:errcheck
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"tse-scanner/history"
+	"tse-scanner/watchlist"
+)
+
+const historyUsage = `使い方: tse-scanner history [flags] <銘柄コード>...
+
+  保存済みのスナップショットを時系列で表示します。
+
+フラグ:
+`
+
+// runHistory implements the "tse-scanner history" subcommand.
+func runHistory(args []string) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, historyUsage)
+		fs.PrintDefaults()
+	}
+	var (
+		dbPath = fs.String("db", history.DefaultPath(), "履歴データベースのパス")
+		since  = fs.Duration("since", 30*time.Minute, "表示する期間（例: 30m, 2h）")
+		list   = fs.Bool("symbols", false, "履歴のある銘柄コードを一覧表示")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if !*list && fs.NArg() == 0 {
+		fs.Usage()
+		return fmt.Errorf("銘柄コードを指定してください")
+	}
+
+	store, err := openHistory(*dbPath)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	if *list {
+		symbols, err := store.Symbols()
+		if err != nil {
+			return err
+		}
+		for _, s := range symbols {
+			fmt.Println(s)
+		}
+		return nil
+	}
+
+	now := time.Now()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+	for _, arg := range fs.Args() {
+		symbol := watchlist.NormalizeSymbol(arg)
+		points, err := store.Range(symbol, now.Add(-*since), now)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(tw, "%s\t時刻\t株価\t騰落率\t出来高\t\n", symbol)
+		for _, p := range points {
+			fmt.Fprintf(tw, "\t%s\t%.1f\t%+.2f%%\t%d\t\n", p.At.Format("01/02 15:04:05"), p.Price, p.ChangePercent, p.Volume)
+		}
+		if len(points) == 0 {
+			fmt.Fprintf(tw, "\t（直近 %s の履歴なし）\t\t\t\t\n", *since)
+		}
+	}
+	return tw.Flush()
+}
+
+// openHistory opens a history database read-only for the history and
+// backtest subcommands, explaining the lock held by a running scanner.
+func openHistory(path string) (*history.Store, error) {
+	store, err := history.Open(path, history.Options{Retention: -1, ReadOnly: true})
+	if errors.Is(err, history.ErrLocked) {
+		return nil, fmt.Errorf("%w（-history で書き込み中のスキャナーを停止してから実行してください）", err)
+	}
+	return store, err
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"tse-scanner/calendar"
+	"tse-scanner/exchange"
+	"tse-scanner/paper"
+	"tse-scanner/portfolio"
+)
+
+const paperUsage = `使い方: tse-scanner paper [flags]
+
+  -paper で仮想売買した口座の評価額・日次損益・トレード履歴を表示します。
+
+フラグ:
+`
+
+// runPaper implements the "tse-scanner paper" subcommand.
+func runPaper(args []string) error {
+	fs := flag.NewFlagSet("paper", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprint(os.Stderr, paperUsage)
+		fs.PrintDefaults()
+	}
+	var (
+		accountPath = fs.String("account", paper.DefaultPath(), "仮想口座ファイル（JSON）")
+		days        = fs.Int("days", 20, "表示する日次損益の日数（0 で全期間）")
+		showTrades  = fs.Bool("trades", false, "決済済みトレードを一覧表示")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if _, err := os.Stat(*accountPath); err != nil {
+		return fmt.Errorf("仮想口座 %s がありません（-paper でスキャンすると作成されます）", *accountPath)
+	}
+	acct, err := paper.LoadAccount(*accountPath, 0)
+	if err != nil {
+		return err
+	}
+	printPaper(acct, *days, *showTrades)
+	return nil
+}
+
+func printPaper(acct *paper.Account, days int, showTrades bool) {
+	equity := acct.Equity()
+	stats := acct.Stats()
+	fmt.Printf("📝 ペーパートレード口座（最終更新 %s）\n\n", acct.Updated.In(calendar.JST).Format("2006-01-02 15:04"))
+	fmt.Printf("   初期資金        %s\n", portfolio.Yen(acct.Initial))
+	fmt.Printf("   評価額          %s（%s、%+.2f%%）\n", portfolio.Yen(equity),
+		portfolio.SignedYen(equity-acct.Initial), (equity-acct.Initial)/acct.Initial*100)
+	fmt.Printf("   現金            %s\n", portfolio.Yen(acct.Cash))
+	fmt.Printf("   確定損益        %s（%d トレード、勝率 %.1f%%）\n", portfolio.SignedYen(stats.Realized), stats.Trades, stats.HitRate)
+	fmt.Printf("   手数料合計      %s\n", portfolio.Yen(stats.Commission))
+	fmt.Printf("   最大ドローダウン %.2f%%\n", stats.MaxDrawdown)
+
+	if len(acct.Positions) > 0 {
+		fmt.Println()
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "コード\t銘柄名\t株数\t取得値\t時価\t含み損益\tエントリー\tスコア")
+		for _, p := range acct.Positions {
+			fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%.0f\n",
+				p.Symbol, p.Name, p.Shares, exchange.Format(p.EntryPrice), exchange.Format(p.Last),
+				portfolio.SignedYen((p.Last-p.EntryPrice)*float64(p.Shares)), p.EntryAt.In(calendar.JST).Format("01/02 15:04"), p.Score)
+		}
+		tw.Flush() //nolint:errcheck
+	}
+
+	if len(acct.Days) > 0 {
+		fmt.Println()
+		list := acct.Days
+		if days > 0 && len(list) > days {
+			list = list[len(list)-days:]
+		}
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(tw, "日付\t評価額\t日次損益\t確定損益\t手数料\t買\t売\t")
+		for _, d := range list {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%d\t%d\t\n", d.Date, portfolio.Yen(d.Equity),
+				portfolio.SignedYen(d.PnL()), portfolio.SignedYen(d.Realized), portfolio.Yen(d.Commission), d.Buys, d.Sells)
+		}
+		tw.Flush() //nolint:errcheck
+	}
+
+	if !showTrades || len(acct.Trades) == 0 {
+		return
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "コード\t銘柄名\t株数\tエントリー\t決済\t買値\t売値\t損益\tリターン\t理由\tスコア\tシグナル")
+	for _, t := range acct.Trades {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\t%+.2f%%\t%s\t%.0f\t%s\n",
+			t.Symbol, t.Name, t.Shares, t.EntryAt.In(calendar.JST).Format("01/02 15:04"), t.ExitAt.In(calendar.JST).Format("01/02 15:04"),
+			exchange.Format(t.EntryPrice), exchange.Format(t.ExitPrice), portfolio.SignedYen(t.PnL), t.Return,
+			t.Reason, t.Score, strings.Join(t.Signals, ","))
+	}
+	tw.Flush() //nolint:errcheck
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"tse-scanner/model"
+	"tse-scanner/watchlist"
+)
+
+const watchlistUsage = `使い方: tse-scanner watchlist <list|add|remove> [flags]
+
+  list   [-list 名前]                          ウォッチリスト一覧、または指定リストの銘柄一覧
+  add    -list 名前 -symbol 7203.T [-name 銘柄名] [-sector 業種] [-tags a,b]
+                                               銘柄を追加（既存なら上書き）
+  remove -list 名前 -symbol 7203.T             銘柄を削除
+
+共通フラグ:
+  -dir   ウォッチリストの保存ディレクトリ（既定: %s）
+`
+
+// runWatchlist implements the "tse-scanner watchlist" subcommand.
+func runWatchlist(args []string) error {
+	if len(args) == 0 {
+		fmt.Fprintf(os.Stderr, watchlistUsage, watchlist.DefaultDir())
+		return fmt.Errorf("サブコマンドを指定してください")
+	}
+	action, args := args[0], args[1:]
+
+	fs := flag.NewFlagSet("watchlist "+action, flag.ContinueOnError)
+	var (
+		dir    = fs.String("dir", watchlist.DefaultDir(), "ウォッチリストの保存ディレクトリ")
+		list   = fs.String("list", "", "ウォッチリスト名")
+		symbol = fs.String("symbol", "", "銘柄コード（例: 7203.T）")
+		name   = fs.String("name", "", "日本語銘柄名")
+		sector = fs.String("sector", "", "業種")
+		tags   = fs.String("tags", "", "タグ（カンマ区切り）")
+	)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	store := watchlist.NewStore(*dir)
+
+	switch action {
+	case "list":
+		if *list == "" {
+			return printWatchlistNames(store)
+		}
+		w, err := store.Load(*list)
+		if err != nil {
+			return err
+		}
+		printWatchlist(w)
+		return nil
+
+	case "add":
+		if *list == "" || *symbol == "" {
+			return fmt.Errorf("add には -list と -symbol が必要です")
+		}
+		st := model.Stock{Symbol: *symbol, Name: *name, Sector: *sector, Tags: splitList(*tags)}
+		if err := store.Add(*list, st); err != nil {
+			return err
+		}
+		fmt.Printf("✅ %s に %s を追加しました\n", *list, watchlist.NormalizeSymbol(*symbol))
+		return nil
+
+	case "remove", "rm":
+		if *list == "" || *symbol == "" {
+			return fmt.Errorf("remove には -list と -symbol が必要です")
+		}
+		if err := store.Remove(*list, *symbol); err != nil {
+			return err
+		}
+		fmt.Printf("🗑  %s から %s を削除しました\n", *list, watchlist.NormalizeSymbol(*symbol))
+		return nil
+
+	default:
+		fmt.Fprintf(os.Stderr, watchlistUsage, watchlist.DefaultDir())
+		return fmt.Errorf("未知のサブコマンドです: %s", action)
+	}
+}
+
+func printWatchlistNames(store *watchlist.Store) error {
+	names, err := store.Names()
+	if err != nil {
+		return err
+	}
+	hasDefault := false
+	for _, n := range names {
+		hasDefault = hasDefault || n == watchlist.DefaultName
+	}
+	if !hasDefault {
+		names = append([]string{watchlist.DefaultName}, names...)
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "名前\t銘柄数")
+	for _, n := range names {
+		w, err := store.Load(n)
+		if err != nil {
+			fmt.Fprintf(tw, "%s\t（読み込みエラー: %v）\n", n, err)
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\n", n, len(w.Stocks))
+	}
+	return tw.Flush()
+}
+
+func printWatchlist(w watchlist.Watchlist) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "コード\t銘柄名\t業種\tタグ\n")
+	for _, s := range w.Stocks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", s.Symbol, s.Name, s.Sector, strings.Join(s.Tags, ","))
+	}
+	tw.Flush() //nolint:errcheck
+	fmt.Printf("\n%s: %d 銘柄\n", w.Name, len(w.Stocks))
+}
+
+// splitList splits a comma-separated flag value, dropping blanks.
+func splitList(s string) []string {
+	var items []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			items = append(items, v)
+		}
+	}
+	return items
+}
@@ -0,0 +1,203 @@
+// Package dashboard serves the scanner results over HTTP: a browser
+// dashboard (sortable candidate table, sector heat map and per-stock
+// sparklines), a JSON API of the latest scan and a Server-Sent Events
+// stream that pushes every new scan as it completes.
+package dashboard
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"sync"
+	"time"
+
+	"tse-scanner/history"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
+)
+
+const (
+	// DefaultSparkline is the look-back of /api/history when ?since is absent.
+	DefaultSparkline = 8 * time.Hour
+
+	heartbeatEvery = 15 * time.Second // プロキシに切断されないための SSE コメント送信間隔
+)
+
+//go:embed static
+var static embed.FS
+
+// Scan is one completed scan as published to the dashboard.
+type Scan struct {
+	At         time.Time
+	Universe   int                // スキャン対象の銘柄数
+	Candidates []model.Candidate  // -min-score / -top 適用後の候補
+	Sectors    []sector.Stats     // 全銘柄の業種別集計（ヒートマップ用）
+	Report     *model.ScanReport  `json:",omitempty"` // 取得結果（取得率・失敗理由）
+	Portfolio  *portfolio.Summary `json:",omitempty"` // 保有銘柄の評価損益
+}
+
+// NewScan builds a Scan from all scored candidates (for the sector heat map)
+// and the filtered candidates shown in the table.
+func NewScan(at time.Time, universe int, scored, candidates []model.Candidate) Scan {
+	if candidates == nil {
+		candidates = []model.Candidate{}
+	}
+	return Scan{At: at, Universe: universe, Candidates: candidates, Sectors: sector.AggregateCandidates(scored)}
+}
+
+// SparkPoint is one price observation returned by /api/history.
+type SparkPoint struct {
+	At    time.Time
+	Price float64
+}
+
+// Server holds the latest scan and fans it out to SSE subscribers.
+type Server struct {
+	hist history.Reader // nil なら /api/history は 404
+
+	mu     sync.RWMutex
+	latest []byte // JSON エンコード済みの最新 Scan（未スキャンなら nil）
+	scan   Scan
+	subs   map[chan []byte]struct{}
+}
+
+// New returns a Server. hist may be nil, in which case sparklines are disabled.
+func New(hist history.Reader) *Server {
+	return &Server{hist: hist, subs: make(map[chan []byte]struct{})}
+}
+
+// Publish makes scan the latest result and pushes it to every SSE client.
+// Slow clients only ever receive the newest scan; intermediate ones are dropped.
+func (s *Server) Publish(scan Scan) error {
+	b, err := json.Marshal(scan)
+	if err != nil {
+		return fmt.Errorf("スキャン結果のエンコードに失敗しました: %w", err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.latest, s.scan = b, scan
+	for ch := range s.subs {
+		select {
+		case <-ch: // 未送信の古いスキャンを捨てる
+		default:
+		}
+		ch <- b
+	}
+	return nil
+}
+
+// Handler returns the HTTP routes of the dashboard.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	sub, _ := fs.Sub(static, "static")
+	mux.Handle("GET /", http.FileServerFS(sub))
+	mux.HandleFunc("GET /api/scan", s.handleScan)
+	mux.HandleFunc("GET /api/candidates", s.handleCandidates)
+	mux.HandleFunc("GET /api/events", s.handleEvents)
+	mux.HandleFunc("GET /api/history/{symbol}", s.handleHistory)
+	return mux
+}
+
+func (s *Server) handleScan(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	b := s.latest
+	s.mu.RUnlock()
+	if b == nil {
+		http.Error(w, "まだスキャン結果がありません", http.StatusServiceUnavailable)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Write(b) //nolint:errcheck
+}
+
+func (s *Server) handleCandidates(w http.ResponseWriter, _ *http.Request) {
+	s.mu.RLock()
+	ok, cands := s.latest != nil, s.scan.Candidates
+	s.mu.RUnlock()
+	if !ok {
+		http.Error(w, "まだスキャン結果がありません", http.StatusServiceUnavailable)
+		return
+	}
+	writeJSON(w, cands)
+}
+
+func (s *Server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "ストリーミングに対応していません", http.StatusInternalServerError)
+		return
+	}
+	ch := make(chan []byte, 1)
+	s.mu.Lock()
+	if s.latest != nil {
+		ch <- s.latest
+	}
+	s.subs[ch] = struct{}{}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.subs, ch)
+		s.mu.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(heartbeatEvery)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case b := <-ch:
+			if _, err := fmt.Fprintf(w, "event: scan\ndata: %s\n\n", b); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+func (s *Server) handleHistory(w http.ResponseWriter, r *http.Request) {
+	if s.hist == nil {
+		http.Error(w, "履歴データベースが無効です（-history）", http.StatusNotFound)
+		return
+	}
+	since := DefaultSparkline
+	if v := r.URL.Query().Get("since"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			http.Error(w, "since が不正です: "+v, http.StatusBadRequest)
+			return
+		}
+		since = d
+	}
+	now := time.Now()
+	points, err := s.hist.Range(r.PathValue("symbol"), now.Add(-since), now)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	spark := make([]SparkPoint, len(points))
+	for i, p := range points {
+		spark[i] = SparkPoint{At: p.At, Price: p.Price}
+	}
+	writeJSON(w, spark)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
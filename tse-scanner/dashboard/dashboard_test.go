@@ -0,0 +1,198 @@
+package dashboard_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"tse-scanner/dashboard"
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+type fakeHistory struct{ points []history.Point }
+
+func (f fakeHistory) Range(_ string, from, to time.Time) ([]history.Point, error) {
+	var out []history.Point
+	for _, p := range f.points {
+		if !p.At.Before(from) && !p.At.After(to) {
+			out = append(out, p)
+		}
+	}
+	return out, nil
+}
+
+func (f fakeHistory) Latest(string, time.Time) (history.Point, bool, error) {
+	return history.Point{}, false, nil
+}
+
+func sampleScan() dashboard.Scan {
+	cands := []model.Candidate{
+		{Quote: model.Quote{Symbol: "7203.T", Name: "トヨタ自動車", Sector: "輸送用機器", ChangePercent: 4}, SurgeScore: 60},
+		{Quote: model.Quote{Symbol: "8306.T", Name: "三菱UFJ", Sector: "銀行業", ChangePercent: -1}, SurgeScore: 5},
+	}
+	return dashboard.NewScan(time.Now(), 2, cands, cands[:1])
+}
+
+// ---- tests ----
+
+func TestScanAPI_UnavailableUntilPublished(t *testing.T) {
+	srv := httptest.NewServer(dashboard.New(nil).Handler())
+	defer srv.Close()
+
+	for _, path := range []string{"/api/scan", "/api/candidates"} {
+		res, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: want 503, got %d", path, res.StatusCode)
+		}
+	}
+}
+
+func TestScanAPI_ReturnsLatest(t *testing.T) {
+	d := dashboard.New(nil)
+	if err := d.Publish(sampleScan()); err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(d.Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/candidates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var cands []model.Candidate
+	if err := json.NewDecoder(res.Body).Decode(&cands); err != nil {
+		t.Fatal(err)
+	}
+	if len(cands) != 1 || cands[0].Symbol != "7203.T" {
+		t.Errorf("unexpected candidates: %+v", cands)
+	}
+
+	res2, err := http.Get(srv.URL + "/api/scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res2.Body.Close()
+	var scan dashboard.Scan
+	if err := json.NewDecoder(res2.Body).Decode(&scan); err != nil {
+		t.Fatal(err)
+	}
+	if len(scan.Sectors) != 2 || scan.Universe != 2 {
+		t.Errorf("want sectors from all scored quotes, got %+v", scan.Sectors)
+	}
+}
+
+func TestEvents_StreamsPublishedScans(t *testing.T) {
+	d := dashboard.New(nil)
+	srv := httptest.NewServer(d.Handler())
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/api/events", nil)
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	if ct := res.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("Content-Type = %q", ct)
+	}
+
+	if err := d.Publish(sampleScan()); err != nil {
+		t.Fatal(err)
+	}
+	sc := bufio.NewScanner(res.Body)
+	sc.Buffer(nil, 1<<20)
+	var event string
+	for sc.Scan() {
+		line := sc.Text()
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = v
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			var scan dashboard.Scan
+			if err := json.Unmarshal([]byte(v), &scan); err != nil {
+				t.Fatal(err)
+			}
+			if event != "scan" || len(scan.Candidates) != 1 {
+				t.Errorf("unexpected event %q: %+v", event, scan)
+			}
+			return
+		}
+	}
+	t.Fatalf("stream ended without data: %v", sc.Err())
+}
+
+func TestHistory_Sparkline(t *testing.T) {
+	now := time.Now()
+	h := fakeHistory{points: []history.Point{
+		{At: now.Add(-10 * time.Hour), Price: 900},
+		{At: now.Add(-time.Hour), Price: 1000},
+		{At: now.Add(-time.Minute), Price: 1010},
+	}}
+	srv := httptest.NewServer(dashboard.New(h).Handler())
+	defer srv.Close()
+
+	res, err := http.Get(srv.URL + "/api/history/7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var pts []dashboard.SparkPoint
+	if err := json.NewDecoder(res.Body).Decode(&pts); err != nil {
+		t.Fatal(err)
+	}
+	if len(pts) != 2 || pts[1].Price != 1010 {
+		t.Errorf("want last 8h of points, got %+v", pts)
+	}
+
+	res, err = http.Get(srv.URL + "/api/history/7203.T?since=bogus")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("bad since: want 400, got %d", res.StatusCode)
+	}
+}
+
+func TestHistory_DisabledWithoutStore(t *testing.T) {
+	srv := httptest.NewServer(dashboard.New(nil).Handler())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/api/history/7203.T")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusNotFound {
+		t.Errorf("want 404, got %d", res.StatusCode)
+	}
+}
+
+func TestIndexServed(t *testing.T) {
+	srv := httptest.NewServer(dashboard.New(nil).Handler())
+	defer srv.Close()
+	res, err := http.Get(srv.URL + "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer res.Body.Close()
+	var b strings.Builder
+	bufio.NewReader(res.Body).WriteTo(&b) //nolint:errcheck
+	if res.StatusCode != http.StatusOK || !strings.Contains(b.String(), "EventSource") {
+		t.Errorf("index not served: %d", res.StatusCode)
+	}
+}
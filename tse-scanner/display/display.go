@@ -3,12 +3,18 @@ package display
 
 import (
 	"fmt"
-	"math"
+	"io"
+	"os"
+	"sort"
 	"strings"
 	"time"
 	"unicode/utf8"
 
+	"tse-scanner/calendar"
+	"tse-scanner/exchange"
 	"tse-scanner/model"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
 )
 
 // ANSI escape codes
@@ -29,48 +35,221 @@ const (
 )
 
 // Render clears the screen and draws the full scan report.
-func Render(candidates []model.Candidate, fetchedAt time.Time, interval time.Duration, totalScanned int) {
-	fmt.Print(clearScr)
-	printHeader(fetchedAt, interval, totalScanned, len(candidates))
-	if len(candidates) == 0 {
-		fmt.Printf("\n  %s急騰候補が見つかりませんでした。しばらくお待ちください。%s\n", yellow, reset)
+func Render(s Scan, interval time.Duration) {
+	r := renderer{w: os.Stdout, color: true}
+	r.print(clearScr)
+	r.scan(s, interval)
+	r.printf("\n  %s%s⚠ 投資は自己責任です。このツールは情報提供のみを目的としています。%s\n",
+		r.c(bold), r.c(yellow), r.c(reset))
+}
+
+// renderer draws the report to w, with or without ANSI colours.
+type renderer struct {
+	w     io.Writer
+	color bool
+}
+
+// c returns the ANSI code, or "" when colour is off.
+func (r renderer) c(code string) string {
+	if !r.color {
+		return ""
+	}
+	return code
+}
+
+func (r renderer) printf(format string, args ...any) {
+	fmt.Fprintf(r.w, format, args...)
+}
+
+func (r renderer) print(code string) {
+	fmt.Fprint(r.w, r.c(code))
+}
+
+func (r renderer) scan(s Scan, interval time.Duration) {
+	label := candidateLabel(s.Mode)
+	r.printHeader(s.At, interval, s.Universe, len(s.Candidates), label)
+	if s.Report != nil {
+		r.printCoverage(s.Report)
+	}
+	if len(s.Indices) > 0 {
+		r.printIndices(s.Indices)
+	}
+	if len(s.Sectors) > 0 {
+		r.printSectors(s.Sectors)
+	}
+	if s.Portfolio != nil {
+		r.printPortfolio(*s.Portfolio)
+	}
+	if len(s.Candidates) == 0 {
+		r.printf("\n  %s%sが見つかりませんでした。しばらくお待ちください。%s\n", r.c(yellow), label, r.c(reset))
 	} else {
-		printTable(candidates)
+		r.printTable(s.Candidates)
+	}
+}
+
+// candidateLabel names the candidates of a scan mode (all the same width,
+// so the header box stays aligned).
+func candidateLabel(mode string) string {
+	switch mode {
+	case "plunge":
+		return "急落候補"
+	case "both":
+		return "騰落候補"
+	default:
+		return "急騰候補"
+	}
+}
+
+func (r renderer) printHeader(fetchedAt time.Time, interval time.Duration, total, found int, label string) {
+	ts := fetchedAt.In(calendar.JST).Format("2006-01-02 15:04:05")
+	status := calendar.Default().Status(fetchedAt)
+	next := "-"
+	if interval > 0 {
+		next = interval.Round(time.Second).String()
+	}
+
+	r.print(bold + cyan)
+	r.printf("╔══════════════════════════════════════════════════════════════════════════════╗\n")
+	r.printf("║  🔥 東証急騰スキャナー  %-20s  次回更新: %-8s       ║\n", ts, next)
+	r.printf("║  市場: %-10s  スキャン: %3d 銘柄  %s: %3d 銘柄                  ║\n",
+		status, total, label, found)
+	r.printf("╚══════════════════════════════════════════════════════════════════════════════╝\n")
+	r.print(reset)
+}
+
+// printCoverage shows how much of the watchlist was actually fetched, so a
+// half-failed scan is not mistaken for a quiet market.
+func (r renderer) printCoverage(report *model.ScanReport) {
+	color := green
+	switch cov := report.Coverage(); {
+	case cov < 0.5:
+		color = red
+	case cov < 1:
+		color = yellow
+	}
+	r.printf("  %s取得: %d/%d 銘柄（%.1f%%）%s  失敗バッチ: %d/%d  所要: %s  鮮度: %s\n",
+		r.c(color), report.Valid, report.Requested, report.Coverage()*100, r.c(reset),
+		report.FailedBatches(), len(report.Batches),
+		report.Latency.Round(10*time.Millisecond), report.Staleness.Round(time.Second))
+	if len(report.Failures) == 0 {
+		return
+	}
+	// 失敗理由ごとに件数をまとめる（全銘柄の一覧は機械可読出力で確認できる）
+	var reasons []string
+	counts := make(map[string]int)
+	for _, f := range report.Failures {
+		if counts[f.Reason] == 0 {
+			reasons = append(reasons, f.Reason)
+		}
+		counts[f.Reason]++
+	}
+	for _, reason := range reasons {
+		r.printf("  %s⚠ %d 銘柄: %s%s\n", r.c(yellow), counts[reason], reason, r.c(reset))
+	}
+}
+
+// printIndices shows the index quotes candidates are measured against.
+func (r renderer) printIndices(indices []model.Quote) {
+	parts := make([]string, 0, len(indices))
+	for _, q := range indices {
+		name := q.Name
+		if name == "" {
+			name = q.Symbol
+		}
+		if !q.Valid {
+			parts = append(parts, name+" 取得失敗")
+			continue
+		}
+		color := green
+		if q.ChangePercent < 0 {
+			color = red
+		}
+		parts = append(parts, fmt.Sprintf("%s %s%+.2f%%%s", name, r.c(color), q.ChangePercent, r.c(reset)))
 	}
-	printFooter()
+	r.printf("  %s指数:%s %s\n", r.c(bold), r.c(reset), strings.Join(parts, " / "))
 }
 
-func printHeader(fetchedAt time.Time, interval time.Duration, total, found int) {
-	jst := time.FixedZone("JST", 9*60*60)
-	ts := fetchedAt.In(jst).Format("2006-01-02 15:04:05")
-	status := marketStatus(fetchedAt.In(jst))
-	nextIn := interval.Round(time.Second)
+// topSectors is how many sectors the header lists per ranking.
+const topSectors = 3
 
-	fmt.Printf("%s%s", bold, cyan)
-	fmt.Println("╔══════════════════════════════════════════════════════════════════════════════╗")
-	fmt.Printf("║  🔥 東証急騰スキャナー  %-20s  次回更新: %-8v       ║\n", ts, nextIn)
-	fmt.Printf("║  市場: %-10s  スキャン: %3d 銘柄  急騰候補: %3d 銘柄                  ║\n",
-		status, total, found)
-	fmt.Println("╚══════════════════════════════════════════════════════════════════════════════╝")
-	fmt.Print(reset)
+// printSectors lists the strongest sectors and, once the session has enough
+// history, the sectors gaining strength fastest (資金流入).
+func (r renderer) printSectors(stats []sector.Stats) {
+	var strong, rising []string
+	for _, s := range stats {
+		if s.Rank <= topSectors {
+			strong = append(strong, fmt.Sprintf("%s %+.2f%%（上昇 %.0f%%）", s.Sector, s.Strength, s.Breadth))
+		}
+	}
+	byMomentum := make([]sector.Stats, 0, len(stats))
+	for _, s := range stats {
+		if s.HasMomentum {
+			byMomentum = append(byMomentum, s)
+		}
+	}
+	sort.Slice(byMomentum, func(i, j int) bool { return byMomentum[i].MomentumRank < byMomentum[j].MomentumRank })
+	for _, s := range byMomentum {
+		if len(rising) == topSectors || s.Momentum <= 0 {
+			break
+		}
+		rising = append(rising, fmt.Sprintf("%s %+.2fpt", s.Sector, s.Momentum))
+	}
+	r.printf("  %s強い業種:%s %s\n", r.c(bold), r.c(reset), strings.Join(strong, " / "))
+	if len(rising) > 0 {
+		r.printf("  %s資金流入:%s %s\n", r.c(bold), r.c(reset), strings.Join(rising, " / "))
+	}
 }
 
-func printTable(candidates []model.Candidate) {
-	header := fmt.Sprintf("  %s%-6s  %-8s  %-18s  %-8s  %10s  %7s  %6s  %s%s",
-		bold,
-		"SCORE", "コード", "銘柄名", "業種", "現在値(円)", "騰落率", "出来高比", "シグナル",
-		reset)
-	fmt.Println()
-	fmt.Println(header)
-	fmt.Printf("  %s\n", strings.Repeat("─", 80))
+// printPortfolio shows the valuation of the user's holdings, one line per
+// position under the totals.
+func (r renderer) printPortfolio(s portfolio.Summary) {
+	r.printf("  %s保有:%s 評価額 %s  損益 %s%s（%+.2f%%）%s  本日 %s%s%s  税引後 %s\n",
+		r.c(bold), r.c(reset), portfolio.Yen(s.Value),
+		r.c(signColor(s.PnL)), portfolio.SignedYen(s.PnL), s.PnLPercent, r.c(reset),
+		r.c(signColor(s.DayPnL)), portfolio.SignedYen(s.DayPnL), r.c(reset),
+		portfolio.SignedYen(s.PnL-s.Tax))
+	for _, h := range s.Holdings {
+		code := strings.TrimSuffix(h.Symbol, ".T")
+		if !h.Valid {
+			r.printf("    %-6s %s  %s取得失敗%s\n", code, padJP(h.Name, 18), r.c(yellow), r.c(reset))
+			continue
+		}
+		hit := ""
+		switch h.Hit {
+		case portfolio.HitTakeProfit:
+			hit = "  " + r.c(bold+green) + "🎯利確ライン到達" + r.c(reset)
+		case portfolio.HitStopLoss:
+			hit = "  " + r.c(bold+red) + "🛑損切ライン到達" + r.c(reset)
+		}
+		r.printf("    %-6s %s  %6d株  %10s  %s%s（%+.2f%%）%s  %s%s\n",
+			code, padJP(h.Name, 18), h.Shares, exchange.Format(h.Price),
+			r.c(signColor(h.PnL)), portfolio.SignedYen(h.PnL), h.PnLPercent, r.c(reset), h.Account, hit)
+	}
+}
+
+func (r renderer) printTable(candidates []model.Candidate) {
+	// 対指数・超過リターンの列はベンチマークがある時のみ表示する
+	relative := candidates[0].Relative.Benchmark != ""
+	extra, width := "", 80
+	if relative {
+		extra, width = fmt.Sprintf("  %5s  %6s", "対指数", "超過"), 100 // 全角は 2 桁幅
+	}
+	r.printf("\n  %s%-6s  %-8s  %-18s  %-8s  %10s  %7s  %6s%s  %s%s\n",
+		r.c(bold),
+		"SCORE", "コード", "銘柄名", "業種", "現在値(円)", "騰落率", "出来高比", extra, "シグナル",
+		r.c(reset))
+	r.printf("  %s\n", strings.Repeat("─", width))
 
 	for _, c := range candidates {
-		printRow(c)
+		r.printRow(c, relative)
 	}
 }
 
-func printRow(c model.Candidate) {
+func (r renderer) printRow(c model.Candidate, relative bool) {
 	scoreColor := scoreToColor(c.SurgeScore)
+	if c.Plunge {
+		scoreColor = plungeScoreColor(c.SurgeScore)
+	}
 	changeColor := green
 	sign := "+"
 	if c.ChangePercent < 0 {
@@ -96,44 +275,37 @@ func printRow(c model.Candidate) {
 	paddedName := padJP(c.Name, 18)
 
 	codeStr := strings.TrimSuffix(c.Symbol, ".T")
+	if c.Held {
+		codeStr = "★" + codeStr // 保有銘柄
+	}
 
-	fmt.Printf("  %s%6.1f%s  %-8s  %s  %-8s  %10s  %s%s%7.2f%%%s  %6s  %s\n",
-		scoreColor, c.SurgeScore, reset,
+	relStr := ""
+	if relative {
+		relStr = fmt.Sprintf("  %s%+6.2fpt%s  %s%+6.2fpt%s",
+			r.c(signColor(c.Relative.Strength)), c.Relative.Strength, r.c(reset),
+			r.c(signColor(c.Relative.Excess)), c.Relative.Excess, r.c(reset))
+	}
+
+	r.printf("  %s%6.1f%s  %-8s  %s  %-8s  %10s  %s%s%7.2f%%%s  %6s%s  %s\n",
+		r.c(scoreColor), c.SurgeScore, r.c(reset),
 		codeStr,
 		paddedName,
 		c.Sector,
 		formatPrice(c.Price),
-		changeColor, sign, c.ChangePercent, reset,
+		r.c(changeColor), sign, c.ChangePercent, r.c(reset),
 		volStr,
+		relStr,
 		sigStr,
 	)
 }
 
-func printFooter() {
-	fmt.Printf("\n  %s%s⚠ 投資は自己責任です。このツールは情報提供のみを目的としています。%s\n",
-		bold, yellow, reset)
-}
-
 // ---- helpers ----
 
-func marketStatus(now time.Time) string {
-	h, m, _ := now.Clock()
-	mins := h*60 + m
-	wd := now.Weekday()
-	if wd == time.Saturday || wd == time.Sunday {
-		return "🔴 休場（週末）"
-	}
-	// 前場 9:00–11:30、後場 12:30–15:30
-	if (mins >= 9*60 && mins < 11*60+30) || (mins >= 12*60+30 && mins < 15*60+30) {
-		return "🟢 取引中"
+func signColor(v float64) string {
+	if v < 0 {
+		return red
 	}
-	if mins >= 8*60 && mins < 9*60 {
-		return "🟡 前場前"
-	}
-	if mins >= 15*60+30 {
-		return "🔴 取引終了"
-	}
-	return "🟡 昼休み"
+	return green
 }
 
 func scoreToColor(score float64) string {
@@ -149,11 +321,24 @@ func scoreToColor(score float64) string {
 	}
 }
 
-func formatPrice(p float64) string {
-	if p >= 10000 {
-		return fmt.Sprintf("%10.0f", math.Round(p))
+// plungeScoreColor is scoreToColor for plunge candidates, in cold colours so
+// the two directions are told apart at a glance in -mode=both.
+func plungeScoreColor(score float64) string {
+	switch {
+	case score >= 80:
+		return bold + magenta
+	case score >= 60:
+		return bold + blue
+	case score >= 40:
+		return blue
+	default:
+		return white
 	}
-	return fmt.Sprintf("%10.1f", p)
+}
+
+// formatPrice right-aligns p on its 呼値 grid.
+func formatPrice(p float64) string {
+	return fmt.Sprintf("%10s", exchange.Format(p))
 }
 
 // isDisplaySignal returns true for emoji-prefixed signal labels (visual indicators).
@@ -0,0 +1,138 @@
+package display
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
+)
+
+// Format selects how scan results are written.
+type Format string
+
+const (
+	FormatTerminal Format = "terminal" // ANSI 付きで画面を書き換える（既定）
+	FormatTable    Format = "table"    // ANSI なしの表（パイプ・ファイル向け）
+	FormatJSON     Format = "json"     // スキャンごとに整形済み JSON
+	FormatNDJSON   Format = "ndjson"   // スキャンごとに 1 行の JSON
+	FormatCSV      Format = "csv"      // 候補 1 件につき 1 行（ヘッダーは最初の 1 回のみ）
+)
+
+// Formats lists the accepted -output values.
+var Formats = []Format{FormatTerminal, FormatTable, FormatJSON, FormatNDJSON, FormatCSV}
+
+// ParseFormat validates an -output value.
+func ParseFormat(s string) (Format, error) {
+	for _, f := range Formats {
+		if string(f) == strings.ToLower(strings.TrimSpace(s)) {
+			return f, nil
+		}
+	}
+	names := make([]string, len(Formats))
+	for i, f := range Formats {
+		names[i] = string(f)
+	}
+	return "", fmt.Errorf("未知の出力形式です: %s（%s）", s, strings.Join(names, " / "))
+}
+
+// Scan is one scan as written by the json and ndjson formats.
+type Scan struct {
+	At         time.Time
+	Universe   int // スキャン対象の銘柄数
+	Candidates []model.Candidate
+	Report     *model.ScanReport  `json:",omitempty"` // 取得結果（取得率・失敗理由・所要時間）
+	Sectors    []sector.Stats     `json:",omitempty"` // 業種別集計（Strength 順）
+	Indices    []model.Quote      `json:",omitempty"` // 指数の気配値（先頭がベンチマーク）
+	Mode       string             `json:",omitempty"` // surge / plunge / both（空なら surge）
+	Portfolio  *portfolio.Summary `json:",omitempty"` // 保有銘柄の評価損益（-portfolio 指定時）
+}
+
+// csvHeader is the column layout of the csv format.
+var csvHeader = []string{
+	"at", "symbol", "name", "sector", "price", "change", "change_percent",
+	"volume", "volume_ratio", "score", "signals", "relative_strength", "excess_return",
+}
+
+// Writer writes scan results in one Format.
+type Writer struct {
+	w           io.Writer
+	format      Format
+	wroteHeader bool
+}
+
+// NewWriter returns a Writer emitting format to w. FormatTerminal always
+// draws to stdout, so w is ignored for it.
+func NewWriter(w io.Writer, format Format) *Writer {
+	return &Writer{w: w, format: format}
+}
+
+// OmitHeader suppresses the csv header, e.g. when appending to an existing file.
+func (w *Writer) OmitHeader() { w.wroteHeader = true }
+
+// Write emits one scan. interval is only shown by the table formats, as are
+// the report (may be nil), sector summary and portfolio in the header; json and ndjson
+// embed the whole Scan. The csv format has one row per candidate.
+func (w *Writer) Write(s Scan, interval time.Duration) error {
+	switch w.format {
+	case FormatTerminal:
+		Render(s, interval)
+		return nil
+	case FormatTable:
+		renderer{w: w.w}.scan(s, interval)
+		_, err := fmt.Fprintln(w.w)
+		return err
+	case FormatJSON, FormatNDJSON:
+		if s.Candidates == nil {
+			s.Candidates = []model.Candidate{}
+		}
+		enc := json.NewEncoder(w.w)
+		if w.format == FormatJSON {
+			enc.SetIndent("", "  ")
+		}
+		return enc.Encode(s)
+	case FormatCSV:
+		return w.writeCSV(s.Candidates, s.At)
+	default:
+		return fmt.Errorf("未知の出力形式です: %s", w.format)
+	}
+}
+
+func (w *Writer) writeCSV(candidates []model.Candidate, fetchedAt time.Time) error {
+	cw := csv.NewWriter(w.w)
+	if !w.wroteHeader {
+		cw.Write(csvHeader) //nolint:errcheck // Flush でまとめて確認する
+		w.wroteHeader = true
+	}
+	at := fetchedAt.Format(time.RFC3339)
+	for _, c := range candidates {
+		labels := make([]string, len(c.Signals))
+		for i, s := range c.Signals {
+			labels[i] = s.Label
+		}
+		var relStrength, excess string // ベンチマークなしなら空欄
+		if c.Relative.Benchmark != "" {
+			relStrength, excess = formatFloat(c.Relative.Strength), formatFloat(c.Relative.Excess)
+		}
+		cw.Write([]string{ //nolint:errcheck
+			at, c.Symbol, c.Name, c.Sector,
+			formatFloat(c.Price), formatFloat(c.Change), formatFloat(c.ChangePercent),
+			strconv.FormatInt(c.Volume, 10), formatFloat(c.VolumeRatio), formatFloat(c.SurgeScore),
+			strings.Join(labels, ";"), relStrength, excess,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// formatFloat trims float noise (e.g. 2.3333333333333335) to 4 decimals.
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(math.Round(v*1e4)/1e4, 'f', -1, 64)
+}
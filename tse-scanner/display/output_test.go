@@ -0,0 +1,221 @@
+package display_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"tse-scanner/display"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
+)
+
+// ---- helpers ----
+
+var at = time.Date(2024, 6, 14, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+func candidates() []model.Candidate {
+	return []model.Candidate{{
+		Quote:       model.Quote{Symbol: "7203.T", Name: "トヨタ自動車", Sector: "自動車", Price: 3200, ChangePercent: 4.9180327868852, Volume: 1200, AvgVolume3M: 400},
+		VolumeRatio: 3,
+		SurgeScore:  62.5,
+		Signals:     []model.Signal{{Label: "前日比", Score: 20}, {Label: "🚀大幅上昇"}},
+	}}
+}
+
+// ---- tests ----
+
+func TestParseFormat(t *testing.T) {
+	if f, err := display.ParseFormat(" NDJSON "); err != nil || f != display.FormatNDJSON {
+		t.Errorf("got %q, %v", f, err)
+	}
+	if _, err := display.ParseFormat("xml"); err == nil {
+		t.Error("want error for unknown format")
+	}
+}
+
+func TestWriter_NDJSONOneLinePerScan(t *testing.T) {
+	var buf bytes.Buffer
+	w := display.NewWriter(&buf, display.FormatNDJSON)
+	for i := 0; i < 2; i++ {
+		if err := w.Write(display.Scan{At: at, Universe: 50, Candidates: candidates()}, time.Minute); err != nil {
+			t.Fatal(err)
+		}
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("want 2 lines, got %d", len(lines))
+	}
+	var scan display.Scan
+	if err := json.Unmarshal([]byte(lines[0]), &scan); err != nil {
+		t.Fatal(err)
+	}
+	if scan.Universe != 50 || len(scan.Candidates) != 1 || scan.Candidates[0].Symbol != "7203.T" {
+		t.Errorf("unexpected scan: %+v", scan)
+	}
+}
+
+func TestWriter_JSONEmptyCandidates(t *testing.T) {
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatJSON).Write(display.Scan{At: at, Universe: 10}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), `"Candidates": []`) {
+		t.Errorf("want empty array, got:\n%s", buf.String())
+	}
+}
+
+func TestWriter_CSVHeaderOnce(t *testing.T) {
+	var buf bytes.Buffer
+	w := display.NewWriter(&buf, display.FormatCSV)
+	w.Write(display.Scan{At: at, Universe: 1, Candidates: candidates()}, 0)                  //nolint:errcheck
+	w.Write(display.Scan{At: at.Add(time.Minute), Universe: 1, Candidates: candidates()}, 0) //nolint:errcheck
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 3 || rows[0][0] != "at" {
+		t.Fatalf("want header + 2 rows, got %v", rows)
+	}
+	want := []string{"2024-06-14T10:00:00+09:00", "7203.T", "トヨタ自動車", "自動車", "3200", "0", "4.918", "1200", "3", "62.5", "前日比;🚀大幅上昇", "", ""}
+	if strings.Join(rows[1], ",") != strings.Join(want, ",") {
+		t.Errorf("row = %v, want %v", rows[1], want)
+	}
+
+	buf.Reset()
+	w = display.NewWriter(&buf, display.FormatCSV)
+	w.OmitHeader()
+	w.Write(display.Scan{At: at, Universe: 1, Candidates: candidates()}, 0) //nolint:errcheck
+	if strings.HasPrefix(buf.String(), "at,") {
+		t.Error("OmitHeader: header written")
+	}
+}
+
+func TestWriter_TableHasNoEscapeCodes(t *testing.T) {
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 1, Candidates: candidates()}, time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if strings.Contains(out, "\033[") {
+		t.Errorf("table output contains ANSI codes:\n%q", out)
+	}
+	if !strings.Contains(out, "トヨタ自動車") || !strings.Contains(out, "🚀大幅上昇") {
+		t.Errorf("table missing row:\n%s", out)
+	}
+}
+
+func TestWriter_ReportInHeaderAndJSON(t *testing.T) {
+	report := &model.ScanReport{
+		Requested: 4, Valid: 2,
+		Batches: []model.BatchReport{{Symbols: 2}, {Symbols: 2, Err: "HTTPステータス 503"}},
+		Failures: []model.SymbolFailure{
+			{Symbol: "6758.T", Reason: "HTTPステータス 503"},
+			{Symbol: "9984.T", Reason: "HTTPステータス 503"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 4, Candidates: candidates(), Report: report}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "取得: 2/4 銘柄（50.0%）") ||
+		!strings.Contains(out, "失敗バッチ: 1/2") || !strings.Contains(out, "2 銘柄: HTTPステータス 503") {
+		t.Errorf("table header missing coverage:\n%s", out)
+	}
+
+	buf.Reset()
+	if err := display.NewWriter(&buf, display.FormatNDJSON).Write(display.Scan{At: at, Universe: 4, Candidates: candidates(), Report: report}, 0); err != nil {
+		t.Fatal(err)
+	}
+	var scan display.Scan
+	if err := json.Unmarshal(buf.Bytes(), &scan); err != nil {
+		t.Fatal(err)
+	}
+	if scan.Report == nil || scan.Report.Coverage() != 0.5 || len(scan.Report.Failures) != 2 {
+		t.Errorf("unexpected report: %+v", scan.Report)
+	}
+}
+
+func TestWriter_TableListsSectors(t *testing.T) {
+	sectors := []sector.Stats{
+		{Sector: "電気機器", Strength: 2.5, Breadth: 80, Rank: 1, Momentum: -0.2, MomentumRank: 2, HasMomentum: true},
+		{Sector: "銀行業", Strength: 1, Breadth: 60, Rank: 2, Momentum: 0.8, MomentumRank: 1, HasMomentum: true},
+	}
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 1, Sectors: sectors}, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	if !strings.Contains(out, "強い業種: 電気機器 +2.50%（上昇 80%） / 銀行業 +1.00%（上昇 60%）") {
+		t.Errorf("missing strongest sectors:\n%s", out)
+	}
+	// 強さが低下している業種は資金流入に含めない
+	if !strings.Contains(out, "資金流入: 銀行業 +0.80pt\n") {
+		t.Errorf("missing rising sectors:\n%s", out)
+	}
+}
+
+func TestWriter_TableRelativeColumnsAndIndices(t *testing.T) {
+	cands := candidates()
+	cands[0].Relative = model.Relative{Benchmark: "1306.T", MarketChange: 1, Strength: 3.918, Beta: 1.2, Excess: 3.718}
+	indices := []model.Quote{
+		{Symbol: "1306.T", Name: "TOPIX（1306）", ChangePercent: 1, Valid: true},
+		{Symbol: "^N225", Name: "日経平均"},
+	}
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 1, Candidates: cands, Indices: indices}, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"指数: TOPIX（1306） +1.00% / 日経平均 取得失敗", "対指数", "+3.92pt", "+3.72pt"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	display.NewWriter(&buf, display.FormatCSV).Write(display.Scan{At: at, Candidates: cands}, 0) //nolint:errcheck
+	rows, _ := csv.NewReader(&buf).ReadAll()
+	if row := rows[1]; row[len(row)-2] != "3.918" || row[len(row)-1] != "3.718" {
+		t.Errorf("csv relative columns: %v", row)
+	}
+}
+
+func TestWriter_TablePlungeLabel(t *testing.T) {
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 1, Mode: "plunge"}, 0); err != nil {
+		t.Fatal(err)
+	}
+	if out := buf.String(); !strings.Contains(out, "急落候補:   0 銘柄") || !strings.Contains(out, "急落候補が見つかりませんでした") {
+		t.Errorf("want plunge wording:\n%s", out)
+	}
+}
+
+func TestWriter_TablePortfolio(t *testing.T) {
+	cands := candidates()
+	cands[0].Held = true
+	summary := &portfolio.Summary{
+		Value: 320000, PnL: 20000, PnLPercent: 6.67, DayPnL: 15000,
+		Holdings: []portfolio.Holding{
+			{Position: portfolio.Position{Symbol: "7203.T", Name: "トヨタ自動車", Shares: 100, AvgCost: 3000, Account: portfolio.AccountSpecific},
+				Price: 3200, Valid: true, PnL: 20000, PnLPercent: 6.67, Hit: portfolio.HitTakeProfit},
+			{Position: portfolio.Position{Symbol: "9984.T", Name: "ソフトバンクG", Shares: 10, AvgCost: 9000, Account: portfolio.AccountNISA}},
+		},
+	}
+	var buf bytes.Buffer
+	if err := display.NewWriter(&buf, display.FormatTable).Write(display.Scan{At: at, Universe: 1, Candidates: cands, Portfolio: summary}, 0); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{"保有: 評価額 320,000円  損益 +20,000円（+6.67%）  本日 +15,000円", "🎯利確ライン到達", "取得失敗", "★7203"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+// Package events imports corporate events — earnings dates, timely
+// disclosures (TDnet 適時開示), ex-dividend and stock-split dates — from
+// local CSV files, so a candidate's move can be read against what was
+// scheduled for the day. A volume spike on earnings day is expected; on a
+// quiet day it is news.
+//
+// Files are CSV (UTF-8 or Shift_JIS) with a header row:
+//
+//	date,time,symbol,type,title
+//	（日本語見出し 日付,時刻,コード,種別,表題 も可。日時 列は "2024/06/14 15:30" 形式）
+//
+// type is one of earnings / ex_dividend / split / disclosure (決算 / 権利落ち /
+// 株式分割 / 適時開示). When it is empty the kind is inferred from the title,
+// so a TDnet list saved as CSV can be used as is. Codes may be the 4-character
+// code, the 5-digit TDnet code ("72030") or the symbol ("7203.T").
+//
+// An event takes effect on the trading day the market can first react to
+// it: the day itself if it was published before the close, otherwise the
+// next trading day. Disclosures without a time are assumed to be after the
+// close, as most earnings are.
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/jpx"
+	"tse-scanner/model"
+)
+
+// Kind is the type of a corporate event.
+type Kind string
+
+const (
+	KindEarnings   Kind = "決算発表"
+	KindExDividend Kind = "権利落ち"
+	KindSplit      Kind = "株式分割"
+	KindDisclosure Kind = "適時開示"
+)
+
+// Scheduled reports whether the event is known in advance, i.e. a move on
+// its day is expected rather than news.
+func (k Kind) Scheduled() bool { return k != KindDisclosure }
+
+// ParseKind accepts the English and Japanese type names.
+func ParseKind(s string) (Kind, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "earnings", "決算", "決算発表", "決算短信":
+		return KindEarnings, nil
+	case "ex_dividend", "ex-dividend", "dividend", "権利落ち", "配当落ち", "権利付最終日翌日":
+		return KindExDividend, nil
+	case "split", "株式分割", "分割":
+		return KindSplit, nil
+	case "disclosure", "適時開示", "開示":
+		return KindDisclosure, nil
+	default:
+		return "", fmt.Errorf("未知のイベント種別です: %s（earnings / ex_dividend / split / disclosure）", s)
+	}
+}
+
+// kindOf infers the kind of a TDnet disclosure from its title.
+func kindOf(title string) Kind {
+	switch {
+	case strings.Contains(title, "決算短信"):
+		return KindEarnings
+	case strings.Contains(title, "株式分割"):
+		return KindSplit
+	default:
+		return KindDisclosure
+	}
+}
+
+// Event is one corporate event of one stock.
+type Event struct {
+	Symbol  string
+	Kind    Kind
+	At      time.Time // 日付（JST）。HasTime なら発表時刻まで
+	HasTime bool
+	Title   string
+}
+
+// Book holds the imported events by symbol.
+type Book struct {
+	by map[string][]Event // 銘柄コード → 日付順のイベント
+}
+
+// New indexes events by symbol.
+func New(events []Event) *Book {
+	b := &Book{by: make(map[string][]Event)}
+	for _, e := range events {
+		b.by[e.Symbol] = append(b.by[e.Symbol], e)
+	}
+	for _, list := range b.by {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].At.Before(list[j].At) })
+	}
+	return b
+}
+
+// Len returns the number of events.
+func (b *Book) Len() int {
+	n := 0
+	for _, list := range b.by {
+		n += len(list)
+	}
+	return n
+}
+
+// On returns symbol's events taking effect on at's trading day.
+func (b *Book) On(symbol string, at time.Time) []model.Event {
+	if b == nil {
+		return nil
+	}
+	day := at.In(calendar.JST).Format(dateLayout)
+	var out []model.Event
+	for _, e := range b.by[symbol] {
+		effective := Effective(e)
+		if effective.Format(dateLayout) != day {
+			continue
+		}
+		out = append(out, model.Event{
+			Kind:      string(e.Kind),
+			At:        e.At,
+			Title:     e.Title,
+			Scheduled: e.Kind.Scheduled(),
+			NextDay:   !sameDay(effective, e.At),
+		})
+	}
+	return out
+}
+
+// Effective returns the trading day (midnight JST) on which the market can
+// first react to e.
+func Effective(e Event) time.Time {
+	cal := calendar.Default()
+	t := e.At.In(calendar.JST)
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, calendar.JST)
+	if cal.IsTradingDay(day) && !afterClose(e, cal) {
+		return day
+	}
+	for i := 0; i < 366; i++ {
+		day = day.AddDate(0, 0, 1)
+		if cal.IsTradingDay(day) {
+			break
+		}
+	}
+	return day
+}
+
+// afterClose reports whether e was published after its day's close.
+// 権利落ち・株式分割は日付そのものが効力発生日なので常に当日扱い。
+func afterClose(e Event, cal *calendar.Calendar) bool {
+	if e.Kind == KindExDividend || e.Kind == KindSplit {
+		return false
+	}
+	if !e.HasTime {
+		return true
+	}
+	sessions := cal.Sessions(e.At)
+	return !e.At.Before(sessions[len(sessions)-1].End)
+}
+
+func sameDay(a, b time.Time) bool {
+	return a.In(calendar.JST).Format(dateLayout) == b.In(calendar.JST).Format(dateLayout)
+}
+
+// Load reads and merges event files.
+func Load(paths ...string) (*Book, error) {
+	var all []Event
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("イベントファイルを開けません: %w", err)
+		}
+		records, err := jpx.ReadCSV(f)
+		f.Close()
+		if err == nil {
+			var events []Event
+			events, err = Parse(records)
+			all = append(all, events...)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return New(all), nil
+}
+
+const dateLayout = "2006-01-02"
+
+// csvColumns maps accepted header names to fields.
+var csvColumns = map[string]string{
+	"date": "date", "日付": "date", "発表日": "date", "権利落ち日": "date",
+	"time": "time", "時刻": "time",
+	"datetime": "datetime", "日時": "datetime",
+	"symbol": "symbol", "code": "symbol", "コード": "symbol", "銘柄コード": "symbol",
+	"type": "type", "kind": "type", "種別": "type",
+	"title": "title", "表題": "title", "件名": "title",
+}
+
+// Parse converts CSV records (header first) to events. Rows with an
+// unreadable date, code or type are reported together.
+func Parse(records [][]string) ([]Event, error) {
+	if len(records) == 0 {
+		return nil, nil
+	}
+	cols := make(map[string]int)
+	for i, h := range records[0] {
+		if name := csvColumns[strings.ToLower(strings.TrimSpace(h))]; name != "" {
+			if _, dup := cols[name]; !dup {
+				cols[name] = i
+			}
+		}
+	}
+	_, hasDate := cols["date"]
+	_, hasDateTime := cols["datetime"]
+	if _, ok := cols["symbol"]; !ok || (!hasDate && !hasDateTime) {
+		return nil, errors.New("CSV の見出しにコード列と日付（または日時）列が必要です")
+	}
+	get := func(rec []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(rec) {
+			return strings.TrimSpace(rec[i])
+		}
+		return ""
+	}
+
+	var (
+		events []Event
+		errs   []error
+	)
+	for n, rec := range records[1:] {
+		e := Event{Title: get(rec, "title")}
+		var err error
+		if e.Symbol, err = normalizeSymbol(get(rec, "symbol")); err != nil {
+			errs = append(errs, fmt.Errorf("%d 行目: %w", n+2, err))
+			continue
+		}
+		dateTime := get(rec, "datetime")
+		if dateTime == "" {
+			dateTime = strings.TrimSpace(get(rec, "date") + " " + get(rec, "time"))
+		}
+		if e.At, e.HasTime, err = parseDateTime(dateTime); err != nil {
+			errs = append(errs, fmt.Errorf("%d 行目: %w", n+2, err))
+			continue
+		}
+		e.Kind = kindOf(e.Title)
+		if t := get(rec, "type"); t != "" {
+			if e.Kind, err = ParseKind(t); err != nil {
+				errs = append(errs, fmt.Errorf("%d 行目: %w", n+2, err))
+				continue
+			}
+		}
+		events = append(events, e)
+	}
+	return events, errors.Join(errs...)
+}
+
+// codePattern matches 4-character codes with an optional TDnet check digit
+// ("72030") or ".T" suffix.
+var codePattern = regexp.MustCompile(`^([0-9][0-9A-Z]{3})(0|\.T)?$`)
+
+func normalizeSymbol(code string) (string, error) {
+	m := codePattern.FindStringSubmatch(strings.ToUpper(code))
+	if m == nil {
+		return "", fmt.Errorf("銘柄コード %q を解釈できません（例: 7203, 72030, 7203.T）", code)
+	}
+	return m[1] + ".T", nil
+}
+
+// parseDateTime parses "2024-06-14", "2024/06/14" or "20240614", optionally
+// followed by a time ("15:30").
+func parseDateTime(s string) (time.Time, bool, error) {
+	s = strings.ReplaceAll(s, "/", "-")
+	for _, layout := range []string{"2006-1-2 15:04", "2006-1-2 15:04:05", "20060102 15:04"} {
+		if t, err := time.ParseInLocation(layout, s, calendar.JST); err == nil {
+			return t, true, nil
+		}
+	}
+	for _, layout := range []string{"2006-1-2", "20060102"} {
+		if t, err := time.ParseInLocation(layout, s, calendar.JST); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("日付 %q を解釈できません（例: 2024-06-14, 2024/06/14 15:30）", s)
+}
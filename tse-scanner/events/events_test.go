@@ -0,0 +1,125 @@
+package events_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/events"
+)
+
+// ---- helpers ----
+
+func day(y int, m time.Month, d, h, min int) time.Time {
+	return time.Date(y, m, d, h, min, 0, 0, calendar.JST)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "events.csv")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// ---- tests ----
+
+func TestParse_TypesAndCodes(t *testing.T) {
+	evs, err := events.Parse([][]string{
+		{"日時", "コード", "会社名", "表題"},
+		{"2024/11/15 15:30", "72030", "トヨタ", "2025年3月期 第2四半期決算短信〔IFRS〕"},
+		{"2024/11/15 13:00", "6758", "ソニーG", "株式分割及び定款の一部変更に関するお知らせ"},
+		{"2024/11/15 16:00", "130A", "Veritas", "業績予想の修正に関するお知らせ"},
+	})
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := []struct {
+		symbol string
+		kind   events.Kind
+	}{
+		{"7203.T", events.KindEarnings},
+		{"6758.T", events.KindSplit},
+		{"130A.T", events.KindDisclosure},
+	}
+	for i, w := range want {
+		if evs[i].Symbol != w.symbol || evs[i].Kind != w.kind || !evs[i].HasTime {
+			t.Errorf("event %d = %+v, want %s %s", i, evs[i], w.symbol, w.kind)
+		}
+	}
+}
+
+func TestParse_ExplicitTypeAndErrors(t *testing.T) {
+	evs, err := events.Parse([][]string{
+		{"date", "symbol", "type"},
+		{"2024-09-27", "7203.T", "ex_dividend"},
+	})
+	if err != nil || len(evs) != 1 || evs[0].Kind != events.KindExDividend || evs[0].HasTime {
+		t.Fatalf("got %+v, %v", evs, err)
+	}
+
+	if _, err := events.Parse([][]string{{"title"}, {"x"}}); err == nil {
+		t.Error("want error for missing columns")
+	}
+	for _, row := range [][]string{
+		{"2024-09-27", "72", "earnings"},
+		{"2024-13-40", "7203", "earnings"},
+		{"2024-09-27", "7203", "iDeCo"},
+	} {
+		if _, err := events.Parse([][]string{{"date", "symbol", "type"}, row}); err == nil {
+			t.Errorf("row %v: want error", row)
+		}
+	}
+}
+
+func TestEffective(t *testing.T) {
+	tests := []struct {
+		name string
+		e    events.Event
+		want time.Time
+	}{
+		{"before close", events.Event{Kind: events.KindEarnings, At: day(2024, 11, 15, 15, 0), HasTime: true}, day(2024, 11, 15, 0, 0)},
+		{"at close", events.Event{Kind: events.KindEarnings, At: day(2024, 11, 15, 15, 30), HasTime: true}, day(2024, 11, 18, 0, 0)},
+		{"old close", events.Event{Kind: events.KindEarnings, At: day(2024, 6, 14, 15, 10), HasTime: true}, day(2024, 6, 17, 0, 0)},
+		{"no time", events.Event{Kind: events.KindDisclosure, At: day(2024, 11, 15, 0, 0)}, day(2024, 11, 18, 0, 0)},
+		{"weekend", events.Event{Kind: events.KindDisclosure, At: day(2024, 11, 16, 10, 0), HasTime: true}, day(2024, 11, 18, 0, 0)},
+		{"ex-dividend", events.Event{Kind: events.KindExDividend, At: day(2024, 9, 27, 0, 0)}, day(2024, 9, 27, 0, 0)},
+	}
+	for _, tt := range tests {
+		if got := events.Effective(tt.e); !got.Equal(tt.want) {
+			t.Errorf("%s: Effective = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestLoad_On(t *testing.T) {
+	book, err := events.Load(writeFile(t, "date,time,symbol,type,title\n"+
+		"2024-11-15,15:30,7203,earnings,決算短信\n"+
+		"2024-11-18,,7203,ex_dividend,\n"+
+		"2024-11-18,10:00,6758,disclosure,自己株式の取得\n"))
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if book.Len() != 3 {
+		t.Fatalf("Len = %d", book.Len())
+	}
+	got := book.On("7203.T", day(2024, 11, 18, 10, 0))
+	if len(got) != 2 {
+		t.Fatalf("want 2 events on 11/18, got %+v", got)
+	}
+	if got[0].Kind != "決算発表" || !got[0].NextDay || !got[0].Scheduled {
+		t.Errorf("earnings: %+v", got[0])
+	}
+	if got[1].Kind != "権利落ち" || got[1].NextDay {
+		t.Errorf("ex-dividend: %+v", got[1])
+	}
+	if sony := book.On("6758.T", day(2024, 11, 18, 10, 0)); len(sony) != 1 || sony[0].Scheduled {
+		t.Errorf("disclosure: %+v", sony)
+	}
+	if n := len(book.On("7203.T", day(2024, 11, 15, 10, 0))); n != 0 {
+		t.Errorf("after-close earnings on its own day: want 0, got %d", n)
+	}
+}
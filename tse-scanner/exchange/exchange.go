@@ -0,0 +1,152 @@
+// Package exchange implements the Tokyo Stock Exchange price rules that
+// affect how a move should be read: the daily price limit (値幅制限), which
+// caps a stock's move at a band around the previous close (ストップ高 /
+// ストップ安), and the tick size (呼値), the smallest price increment.
+//
+// Tick sizes differ for TOPIX500 constituents, which trade in finer
+// increments (down to 0.1 円). Membership is not part of the quote, so
+// Format rounds on the TOPIX500 grid, which is at least as fine as the
+// ordinary one and therefore never moves a valid price.
+package exchange
+
+import (
+	"math"
+	"strconv"
+)
+
+// NearLimit is the share of the limit width a stock must have moved to be
+// "approaching" its limit (ストップ高接近).
+const NearLimit = 0.8
+
+// step is one row of a price table: rows apply to prices up to and
+// including upTo (or below upTo for limits, see limitTable).
+type step struct {
+	upTo  float64
+	value float64
+}
+
+// limitTable is the 制限値幅 by 基準値段 (previous close): a base price
+// below upTo may move by value.
+var limitTable = []step{
+	{100, 30}, {200, 50}, {500, 80}, {700, 100},
+	{1000, 150}, {1500, 300}, {2000, 400}, {3000, 500},
+	{5000, 700}, {7000, 1000}, {10000, 1500}, {15000, 3000},
+	{20000, 4000}, {30000, 5000}, {50000, 7000}, {70000, 10000},
+	{100000, 15000}, {150000, 30000}, {200000, 40000}, {300000, 50000},
+	{500000, 70000}, {700000, 100000}, {1000000, 150000}, {1500000, 300000},
+	{2000000, 400000}, {3000000, 500000}, {5000000, 700000}, {7000000, 1000000},
+	{10000000, 1500000}, {15000000, 3000000}, {20000000, 4000000}, {30000000, 5000000},
+	{50000000, 7000000},
+}
+
+const maxLimit = 10000000 // 基準値段 5,000 万円以上
+
+// tickTable is the 呼値 for ordinary stocks: a price up to upTo trades in
+// increments of value.
+var tickTable = []step{
+	{3000, 1}, {5000, 5}, {30000, 10}, {50000, 50},
+	{300000, 100}, {500000, 500}, {3000000, 1000}, {5000000, 5000},
+	{30000000, 10000}, {50000000, 50000},
+}
+
+// topix500TickTable is the 呼値 for TOPIX500 constituents.
+var topix500TickTable = []step{
+	{1000, 0.1}, {3000, 0.5}, {10000, 1}, {30000, 5},
+	{100000, 10}, {300000, 50}, {1000000, 100}, {3000000, 500},
+	{10000000, 1000}, {30000000, 5000},
+}
+
+// LimitWidth returns the 制限値幅 for a stock whose base price (normally the
+// previous close) is prevClose, or 0 when prevClose is not positive.
+func LimitWidth(prevClose float64) float64 {
+	if prevClose <= 0 {
+		return 0
+	}
+	for _, s := range limitTable {
+		if prevClose < s.upTo {
+			return s.value
+		}
+	}
+	return maxLimit
+}
+
+// Band returns the ストップ安 and ストップ高 prices for prevClose. The lower
+// limit never goes below 1 円. Both are 0 when prevClose is not positive.
+func Band(prevClose float64) (lower, upper float64) {
+	w := LimitWidth(prevClose)
+	if w == 0 {
+		return 0, 0
+	}
+	return math.Max(prevClose-w, 1), prevClose + w
+}
+
+// Status is where a price sits within its daily limit band.
+type Status int
+
+const (
+	WithinBand Status = iota // 値幅内
+	NearUpper                // ストップ高接近（値幅の NearLimit 以上上昇）
+	AtUpper                  // ストップ高（張り付き）
+	NearLower                // ストップ安接近
+	AtLower                  // ストップ安
+)
+
+// StatusOf classifies price against the band around prevClose.
+func StatusOf(price, prevClose float64) Status {
+	lower, upper := Band(prevClose)
+	if upper == 0 || price <= 0 {
+		return WithinBand
+	}
+	w := LimitWidth(prevClose)
+	switch {
+	case price >= upper:
+		return AtUpper
+	case price <= lower:
+		return AtLower
+	case price-prevClose >= w*NearLimit:
+		return NearUpper
+	case prevClose-price >= w*NearLimit:
+		return NearLower
+	default:
+		return WithinBand
+	}
+}
+
+// TickSize returns the 呼値 at price. topix500 selects the finer table for
+// TOPIX500 constituents.
+func TickSize(price float64, topix500 bool) float64 {
+	table, top := tickTable, 100000.0
+	if topix500 {
+		table, top = topix500TickTable, 10000
+	}
+	for _, s := range table {
+		if price <= s.upTo {
+			return s.value
+		}
+	}
+	return top
+}
+
+// Round rounds price to the nearest valid tick.
+func Round(price float64, topix500 bool) float64 {
+	tick := TickSize(price, topix500)
+	rounded := math.Round(price/tick) * tick
+	if tick < 1 {
+		rounded = math.Round(rounded*10) / 10 // 0.1 刻みの誤差を除く
+	}
+	return rounded
+}
+
+// Format formats price on its tick grid, dropping float noise: whole yen,
+// or one decimal for a fractional tick (e.g. "3200", "812.5").
+func Format(price float64) string {
+	if price <= 0 {
+		return "0"
+	}
+	rounded := Round(price, true)
+	decimals := 0
+	if rounded != math.Trunc(rounded) {
+		decimals = 1
+	}
+	return strconv.FormatFloat(rounded, 'f', decimals, 64)
+}
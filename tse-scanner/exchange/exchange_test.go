@@ -0,0 +1,100 @@
+package exchange_test
+
+import (
+	"testing"
+
+	"tse-scanner/exchange"
+)
+
+// ---- tests ----
+
+func TestLimitWidth_Boundaries(t *testing.T) {
+	tests := []struct {
+		prevClose, want float64
+	}{
+		{0, 0},
+		{99, 30},
+		{100, 50}, // 100 円以上は次の区分
+		{999, 150},
+		{1000, 300},
+		{3000, 700},
+		{49999999, 7000000},
+		{50000000, 10000000},
+	}
+	for _, tt := range tests {
+		if got := exchange.LimitWidth(tt.prevClose); got != tt.want {
+			t.Errorf("LimitWidth(%v) = %v, want %v", tt.prevClose, got, tt.want)
+		}
+	}
+}
+
+func TestBand(t *testing.T) {
+	if lo, hi := exchange.Band(1000); lo != 700 || hi != 1300 {
+		t.Errorf("Band(1000) = %v, %v", lo, hi)
+	}
+	// ストップ安は 1 円を下回らない
+	if lo, hi := exchange.Band(20); lo != 1 || hi != 50 {
+		t.Errorf("Band(20) = %v, %v", lo, hi)
+	}
+	if lo, hi := exchange.Band(0); lo != 0 || hi != 0 {
+		t.Errorf("Band(0) = %v, %v", lo, hi)
+	}
+}
+
+func TestStatusOf(t *testing.T) {
+	tests := []struct {
+		price float64
+		want  exchange.Status
+	}{
+		{1300, exchange.AtUpper},
+		{1240, exchange.NearUpper}, // 値幅 300 の 80%
+		{1239, exchange.WithinBand},
+		{1000, exchange.WithinBand},
+		{760, exchange.NearLower},
+		{700, exchange.AtLower},
+		{0, exchange.WithinBand},
+	}
+	for _, tt := range tests {
+		if got := exchange.StatusOf(tt.price, 1000); got != tt.want {
+			t.Errorf("StatusOf(%v, 1000) = %v, want %v", tt.price, got, tt.want)
+		}
+	}
+}
+
+func TestTickSizeAndRound(t *testing.T) {
+	tests := []struct {
+		price    float64
+		topix500 bool
+		tick     float64
+		rounded  float64
+	}{
+		{3000, false, 1, 3000},
+		{3001, false, 5, 3000},
+		{3003, false, 5, 3005},
+		{812.34, true, 0.1, 812.3},
+		{1234.7, true, 0.5, 1234.5},
+		{12345, true, 5, 12345},
+	}
+	for _, tt := range tests {
+		if got := exchange.TickSize(tt.price, tt.topix500); got != tt.tick {
+			t.Errorf("TickSize(%v, %v) = %v, want %v", tt.price, tt.topix500, got, tt.tick)
+		}
+		if got := exchange.Round(tt.price, tt.topix500); got != tt.rounded {
+			t.Errorf("Round(%v, %v) = %v, want %v", tt.price, tt.topix500, got, tt.rounded)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	for price, want := range map[float64]string{
+		3200:               "3200",
+		812.5:              "812.5",
+		812.30000000000001: "812.3",
+		1234.5:             "1234.5",
+		0:                  "0",
+	} {
+		if got := exchange.Format(price); got != want {
+			t.Errorf("Format(%v) = %q, want %q", price, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"tse-scanner/model"
+)
+
+const chartURL = "https://query1.finance.yahoo.com/v8/finance/chart/"
+
+// Bar intervals and look-back ranges accepted by the Yahoo chart API.
+const (
+	IntervalDaily = "1d"
+	Interval5Min  = "5m"
+	RangeDaily    = "6mo" // 日足: 指標の助走期間を含め約 120 本
+	RangeIntraday = "1d"  // 分足: 当日分のみ
+)
+
+// BarProvider fetches OHLCV history for technical indicators.
+type BarProvider interface {
+	FetchBars(ctx context.Context, symbol, interval, rng string) ([]model.Bar, error)
+}
+
+var _ BarProvider = (*Client)(nil)
+
+// FetchBars fetches OHLCV bars for one symbol from the Yahoo chart API,
+// oldest first. Candles with missing values (売買不成立) are skipped.
+func (c *Client) FetchBars(ctx context.Context, symbol, interval, rng string) ([]model.Bar, error) {
+	q := url.Values{}
+	q.Set("interval", interval)
+	q.Set("range", rng)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, chartURL+url.PathEscape(symbol)+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.session.do(req)
+	if err != nil {
+		return nil, &transportError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("レスポンス読み込みエラー: %w", err)
+	}
+	return parseChart(body)
+}
+
+// FetchBarsAll fetches bars for every symbol under the client's concurrency,
+// rate limit and retry policy. Symbols that fail are omitted from the result.
+func (c *Client) FetchBarsAll(ctx context.Context, symbols []string, interval, rng string) map[string][]model.Bar {
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		out = make(map[string][]model.Bar, len(symbols))
+		sem = make(chan struct{}, c.concurrency)
+	)
+	for _, sym := range symbols {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(sym string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			var bars []model.Bar
+			err := c.retry(ctx, func() error {
+				if err := c.limiter.wait(ctx); err != nil {
+					return err
+				}
+				var err error
+				bars, err = c.FetchBars(ctx, sym, interval, rng)
+				return err
+			})
+			if err != nil || len(bars) == 0 {
+				return
+			}
+			mu.Lock()
+			out[sym] = bars
+			mu.Unlock()
+		}(sym)
+	}
+	wg.Wait()
+	return out
+}
+
+// ---- Yahoo Finance chart response types ----
+
+type chartResponse struct {
+	Chart struct {
+		Result []struct {
+			Timestamp  []int64 `json:"timestamp"`
+			Indicators struct {
+				Quote []struct {
+					Open   []*float64 `json:"open"`
+					High   []*float64 `json:"high"`
+					Low    []*float64 `json:"low"`
+					Close  []*float64 `json:"close"`
+					Volume []*int64   `json:"volume"`
+				} `json:"quote"`
+			} `json:"indicators"`
+		} `json:"result"`
+		Error *struct {
+			Code        string `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	} `json:"chart"`
+}
+
+func parseChart(body []byte) ([]model.Bar, error) {
+	var cr chartResponse
+	if err := json.Unmarshal(body, &cr); err != nil {
+		return nil, fmt.Errorf("JSONパースエラー: %w", err)
+	}
+	if cr.Chart.Error != nil {
+		return nil, fmt.Errorf("Yahoo Finance エラー: %s", cr.Chart.Error.Description)
+	}
+	if len(cr.Chart.Result) == 0 || len(cr.Chart.Result[0].Indicators.Quote) == 0 {
+		return nil, nil
+	}
+	r := cr.Chart.Result[0]
+	q := r.Indicators.Quote[0]
+	at := func(v []*float64, i int) (float64, bool) {
+		if i >= len(v) || v[i] == nil {
+			return 0, false
+		}
+		return *v[i], true
+	}
+
+	bars := make([]model.Bar, 0, len(r.Timestamp))
+	for i, ts := range r.Timestamp {
+		o, ok1 := at(q.Open, i)
+		h, ok2 := at(q.High, i)
+		l, ok3 := at(q.Low, i)
+		c, ok4 := at(q.Close, i)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			continue
+		}
+		var vol int64
+		if i < len(q.Volume) && q.Volume[i] != nil {
+			vol = *q.Volume[i]
+		}
+		bars = append(bars, model.Bar{
+			Time: time.Unix(ts, 0), Open: o, High: h, Low: l, Close: c, Volume: vol,
+		})
+	}
+	return bars, nil
+}
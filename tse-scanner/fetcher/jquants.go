@@ -0,0 +1,267 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"tse-scanner/model"
+)
+
+const (
+	jquantsBaseURL = "https://api.jquants.com/v1"
+	// JQuantsTokenEnv is the environment variable holding the J-Quants refresh token.
+	JQuantsTokenEnv = "JQUANTS_REFRESH_TOKEN"
+
+	jquantsTokenTTL  = 23 * time.Hour       // ID トークンの有効期限は 24 時間
+	jquantsLookback  = 365 * 24 * time.Hour // 52 週高安値の算出に使う期間
+	jquantsAvgVolDay = 63                   // 約 3 ヶ月分の営業日
+)
+
+// JQuants fetches daily bars from the J-Quants API (JPX 公式データ).
+//
+// Quotes are built from the latest daily bar, so intraday values are only as
+// fresh as the subscription plan allows. One request is made per stock; the
+// previous close, 3-month average volume and 52-week range are derived from
+// the past year of bars.
+type JQuants struct {
+	http         HTTPDoer
+	baseURL      string
+	refreshToken string
+	batcher
+
+	mu        sync.Mutex
+	idToken   string
+	expiresAt time.Time
+}
+
+var _ ReportingProvider = (*JQuants)(nil)
+
+// NewJQuants returns a J-Quants provider authenticating with refreshToken.
+func NewJQuants(refreshToken string, opts ...Option) *JQuants {
+	return NewJQuantsWithHTTP(&http.Client{Timeout: 10 * time.Second}, refreshToken, opts...)
+}
+
+// NewJQuantsWithHTTP returns a J-Quants provider using the provided HTTPDoer (for testing).
+func NewJQuantsWithHTTP(h HTTPDoer, refreshToken string, opts ...Option) *JQuants {
+	return &JQuants{
+		http:         h,
+		baseURL:      jquantsBaseURL,
+		refreshToken: refreshToken,
+		batcher:      newBatcher(opts),
+	}
+}
+
+// Name implements QuoteProvider.
+func (j *JQuants) Name() string { return "jquants" }
+
+// FetchQuotes implements QuoteProvider.
+func (j *JQuants) FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error) {
+	quotes, _, err := j.FetchQuotesWithReport(ctx, stocks)
+	return quotes, err
+}
+
+// FetchQuotesWithReport implements ReportingProvider.
+func (j *JQuants) FetchQuotesWithReport(ctx context.Context, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error) {
+	if j.refreshToken == "" {
+		return nil, nil, fmt.Errorf("J-Quants のリフレッシュトークンが未設定です（環境変数 %s）", JQuantsTokenEnv)
+	}
+	if _, err := j.token(ctx); err != nil {
+		return nil, nil, err
+	}
+	quotes, report, err := j.fetchAll(ctx, stocks, 1, func(ctx context.Context, batch []model.Stock) ([]model.Quote, error) {
+		q, err := j.fetchQuote(ctx, batch[0])
+		if err != nil {
+			return nil, err
+		}
+		return []model.Quote{q}, nil
+	})
+	if report != nil {
+		report.Provider = j.Name()
+	}
+	return quotes, report, err
+}
+
+// token returns a cached ID token, exchanging the refresh token when needed.
+// The exchange runs without holding j.mu; concurrent refreshes are harmless
+// since each yields a valid token.
+func (j *JQuants) token(ctx context.Context) (string, error) {
+	j.mu.Lock()
+	if j.idToken != "" && time.Now().Before(j.expiresAt) {
+		token := j.idToken
+		j.mu.Unlock()
+		return token, nil
+	}
+	j.mu.Unlock()
+
+	u := j.baseURL + "/token/auth_refresh?refreshtoken=" + url.QueryEscape(j.refreshToken)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u, nil)
+	if err != nil {
+		return "", fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	var body struct {
+		IDToken string `json:"idToken"`
+	}
+	if err := j.do(req, &body); err != nil {
+		return "", fmt.Errorf("J-Quants 認証エラー: %w", err)
+	}
+	if body.IDToken == "" {
+		return "", errors.New("J-Quants 認証エラー: idToken が空です")
+	}
+	j.mu.Lock()
+	j.idToken, j.expiresAt = body.IDToken, time.Now().Add(jquantsTokenTTL)
+	j.mu.Unlock()
+	return body.IDToken, nil
+}
+
+// invalidate drops the cached ID token if it is still token, so the next
+// call to token re-authenticates.
+func (j *JQuants) invalidate(token string) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if j.idToken == token {
+		j.idToken = ""
+	}
+}
+
+type jquantsBar struct {
+	Date   string   `json:"Date"`
+	Code   string   `json:"Code"`
+	Open   *float64 `json:"Open"`
+	High   *float64 `json:"High"`
+	Low    *float64 `json:"Low"`
+	Close  *float64 `json:"Close"`
+	Volume *float64 `json:"Volume"`
+}
+
+func (j *JQuants) fetchQuote(ctx context.Context, s model.Stock) (model.Quote, error) {
+	now := time.Now()
+	q := url.Values{}
+	q.Set("code", toJQuantsCode(s.Symbol))
+	q.Set("from", now.Add(-jquantsLookback).Format("20060102"))
+	q.Set("to", now.Format("20060102"))
+
+	var body struct {
+		DailyQuotes []jquantsBar `json:"daily_quotes"`
+	}
+	for retried := false; ; retried = true {
+		token, err := j.token(ctx)
+		if err != nil {
+			return model.Quote{}, err
+		}
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, j.baseURL+"/prices/daily_quotes?"+q.Encode(), nil)
+		if err != nil {
+			return model.Quote{}, fmt.Errorf("リクエスト作成エラー: %w", err)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+
+		err = j.do(req, &body)
+		var se *StatusError
+		if !retried && errors.As(err, &se) && se.Code == http.StatusUnauthorized {
+			j.invalidate(token) // 期限内でも失効した ID トークンは取り直して 1 回だけ再試行する
+			continue
+		}
+		if err != nil {
+			return model.Quote{}, err
+		}
+		return quoteFromBars(s, body.DailyQuotes, now), nil
+	}
+}
+
+func (j *JQuants) do(req *http.Request, v any) error {
+	req.Header.Set("Accept", "application/json")
+	resp, err := j.http.Do(req)
+	if err != nil {
+		return &transportError{err}
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return newStatusError(resp)
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("レスポンス読み込みエラー: %w", err)
+	}
+	if err := json.Unmarshal(b, v); err != nil {
+		return fmt.Errorf("JSONパースエラー: %w", err)
+	}
+	return nil
+}
+
+// toJQuantsCode maps "7203.T" to the 5-digit J-Quants code "72030".
+func toJQuantsCode(symbol string) string {
+	return strings.TrimSuffix(symbol, ".T") + "0"
+}
+
+// quoteFromBars builds a Quote from daily bars in ascending date order.
+// Bars without a close (売買不成立) are skipped.
+func quoteFromBars(s model.Stock, bars []jquantsBar, at time.Time) model.Quote {
+	valid := bars[:0:0]
+	for _, b := range bars {
+		if b.Close != nil {
+			valid = append(valid, b)
+		}
+	}
+	if len(valid) == 0 {
+		return invalidQuote(s, at)
+	}
+
+	last := valid[len(valid)-1]
+	q := model.Quote{
+		Symbol:    s.Symbol,
+		Name:      s.Name,
+		Sector:    s.Sector,
+		Price:     *last.Close,
+		Open:      deref(last.Open),
+		DayHigh:   deref(last.High),
+		DayLow:    deref(last.Low),
+		Volume:    int64(deref(last.Volume)),
+		FetchedAt: at,
+		Valid:     true,
+	}
+	if len(valid) > 1 {
+		q.PrevClose = *valid[len(valid)-2].Close
+		q.Change = q.Price - q.PrevClose
+		if q.PrevClose > 0 {
+			q.ChangePercent = q.Change / q.PrevClose * 100
+		}
+	}
+
+	// 3 ヶ月平均出来高は当日を除く直近 jquantsAvgVolDay 営業日から算出
+	hist := valid[:len(valid)-1]
+	if len(hist) > jquantsAvgVolDay {
+		hist = hist[len(hist)-jquantsAvgVolDay:]
+	}
+	if len(hist) > 0 {
+		var sum float64
+		for _, b := range hist {
+			sum += deref(b.Volume)
+		}
+		q.AvgVolume3M = int64(sum / float64(len(hist)))
+	}
+
+	q.WeekLow52 = q.Price
+	for _, b := range valid {
+		if h := deref(b.High); h > q.WeekHigh52 {
+			q.WeekHigh52 = h
+		}
+		if l := deref(b.Low); l > 0 && l < q.WeekLow52 {
+			q.WeekLow52 = l
+		}
+	}
+	return q
+}
+
+func deref(f *float64) float64 {
+	if f == nil {
+		return 0
+	}
+	return *f
+}
@@ -0,0 +1,252 @@
+package fetcher
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"tse-scanner/model"
+)
+
+const (
+	defaultConcurrency = 4   // 同時に処理するバッチ数
+	defaultRatePerSec  = 4.0 // 1 秒あたりの最大リクエスト数
+)
+
+// QuoteProvider is a source of market snapshots. main depends only on this
+// interface, so the data source can be swapped without touching the scan loop.
+//
+// Implementations return one Quote per requested stock, in input order.
+// Stocks that could not be fetched are returned as Quote{Valid: false}.
+type QuoteProvider interface {
+	Name() string
+	FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error)
+}
+
+// ReportingProvider is a QuoteProvider that can also explain a fetch:
+// which batches failed, why each symbol is missing and how long it took.
+type ReportingProvider interface {
+	QuoteProvider
+	FetchQuotesWithReport(ctx context.Context, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error)
+}
+
+// Fetch fetches quotes from p together with a scan report. For providers
+// that do not implement ReportingProvider the report is derived from the
+// returned quotes alone.
+func Fetch(ctx context.Context, p QuoteProvider, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error) {
+	if rp, ok := p.(ReportingProvider); ok {
+		return rp.FetchQuotesWithReport(ctx, stocks)
+	}
+	start := time.Now()
+	quotes, err := p.FetchQuotes(ctx, stocks)
+	if err != nil {
+		return nil, nil, err
+	}
+	report := &model.ScanReport{Provider: p.Name(), StartedAt: start, Latency: time.Since(start)}
+	report.Batches = []model.BatchReport{{Symbols: len(stocks), Attempts: 1, Latency: report.Latency}}
+	summarize(report, quotes, nil)
+	return quotes, report, nil
+}
+
+// HTTPDoer is the interface satisfied by *http.Client, enabling test injection.
+type HTTPDoer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Option configures how an HTTP-backed provider batches its requests.
+type Option func(*batcher)
+
+// WithConcurrency sets how many batches are fetched in parallel (minimum 1).
+func WithConcurrency(n int) Option {
+	return func(b *batcher) {
+		if n < 1 {
+			n = 1
+		}
+		b.concurrency = n
+	}
+}
+
+// WithRateLimit caps outgoing requests per second. A value <= 0 disables the limit.
+func WithRateLimit(perSec float64) Option {
+	return func(b *batcher) {
+		b.limiter = newRateLimiter(perSec)
+	}
+}
+
+// batcher runs per-batch fetches concurrently under a rate limit, retrying
+// transient failures and backing off entirely while the breaker is open.
+type batcher struct {
+	concurrency int
+	limiter     *rateLimiter
+
+	attempts    int
+	backoffBase time.Duration
+	backoffMax  time.Duration
+	breaker     *breaker
+}
+
+func newBatcher(opts []Option) batcher {
+	b := batcher{
+		concurrency: defaultConcurrency,
+		limiter:     newRateLimiter(defaultRatePerSec),
+		attempts:    defaultAttempts,
+		backoffBase: defaultBackoffBase,
+		backoffMax:  defaultBackoffMax,
+		breaker:     newBreaker(defaultBreakerThreshold, defaultBreakerCooldown),
+	}
+	for _, opt := range opts {
+		opt(&b)
+	}
+	return b
+}
+
+// fetchAll splits stocks into batches of size, fetches them concurrently and
+// returns the quotes in input order with a report of every batch. Each batch
+// is retried on transient errors; a batch that still fails is filled with
+// invalid quotes. While the circuit breaker is open nothing is sent and
+// ErrCircuitOpen is returned.
+func (b *batcher) fetchAll(ctx context.Context, stocks []model.Stock, size int,
+	fetch func(ctx context.Context, batch []model.Stock) ([]model.Quote, error)) ([]model.Quote, *model.ScanReport, error) {
+
+	start := time.Now()
+	if len(stocks) > 0 && b.breaker.open(start) {
+		return nil, nil, ErrCircuitOpen
+	}
+
+	var batches [][]model.Stock
+	for i := 0; i < len(stocks); i += size {
+		end := i + size
+		if end > len(stocks) {
+			end = len(stocks)
+		}
+		batches = append(batches, stocks[i:end])
+	}
+
+	perBatch := make([][]model.Quote, len(batches))
+	reports := make([]model.BatchReport, len(batches))
+	sem := make(chan struct{}, b.concurrency)
+	var wg sync.WaitGroup
+	for i, batch := range batches {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, batch []model.Stock) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			began := time.Now()
+			quotes, attempts, err := b.runBatch(ctx, batch, fetch)
+			reports[i] = model.BatchReport{Symbols: len(batch), Attempts: attempts, Latency: time.Since(began)}
+			if err != nil {
+				// Partial failure: fill batch as invalid and continue
+				quotes = invalidQuotes(batch, time.Time{})
+				reports[i].Err = err.Error()
+			}
+			perBatch[i] = quotes
+		}(i, batch)
+	}
+	wg.Wait()
+
+	results := make([]model.Quote, 0, len(stocks))
+	reasons := make(map[string]string)
+	for i, quotes := range perBatch {
+		results = append(results, quotes...)
+		if reports[i].Err != "" {
+			for _, s := range batches[i] {
+				reasons[s.Symbol] = reports[i].Err
+			}
+		}
+	}
+	report := &model.ScanReport{StartedAt: start, Latency: time.Since(start), Batches: reports}
+	summarize(report, results, reasons)
+	return results, report, nil
+}
+
+// runBatch fetches one batch through the breaker, rate limiter and retry
+// policy, returning the number of requests made.
+func (b *batcher) runBatch(ctx context.Context, batch []model.Stock,
+	fetch func(ctx context.Context, batch []model.Stock) ([]model.Quote, error)) ([]model.Quote, int, error) {
+
+	if !b.breaker.allow(time.Now()) {
+		return nil, 0, ErrCircuitOpen
+	}
+	var quotes []model.Quote
+	attempts := 0
+	err := b.retry(ctx, func() error {
+		if err := b.limiter.wait(ctx); err != nil {
+			return err
+		}
+		attempts++
+		var err error
+		quotes, err = fetch(ctx, batch)
+		return err
+	})
+	b.breaker.record(err, time.Now())
+	return quotes, attempts, err
+}
+
+// reasonNoData is the failure reason for a symbol the server did not return.
+const reasonNoData = "データなし（上場廃止・銘柄コード誤りの可能性）"
+
+// summarize fills the coverage, staleness and per-symbol failures of report
+// from quotes. reasons holds known causes by symbol (e.g. the batch error);
+// other invalid quotes are reported as missing from the response.
+func summarize(report *model.ScanReport, quotes []model.Quote, reasons map[string]string) {
+	now := report.StartedAt.Add(report.Latency)
+	report.Requested = len(quotes)
+	report.Valid = 0
+	report.Staleness = 0
+	report.Failures = nil
+	for _, q := range quotes {
+		if !q.Valid {
+			reason, ok := reasons[q.Symbol]
+			if !ok {
+				reason = reasonNoData
+			}
+			report.Failures = append(report.Failures, model.SymbolFailure{Symbol: q.Symbol, Reason: reason})
+			continue
+		}
+		report.Valid++
+		if !q.FetchedAt.IsZero() {
+			report.Staleness = max(report.Staleness, now.Sub(q.FetchedAt))
+		}
+	}
+}
+
+// stockLookup builds a symbol→Stock map for merging names and sectors.
+func stockLookup(stocks []model.Stock) map[string]model.Stock {
+	lookup := make(map[string]model.Stock, len(stocks))
+	for _, s := range stocks {
+		lookup[s.Symbol] = s
+	}
+	return lookup
+}
+
+// invalidQuotes returns a Valid=false placeholder for every stock in batch.
+func invalidQuotes(batch []model.Stock, at time.Time) []model.Quote {
+	quotes := make([]model.Quote, len(batch))
+	for i, s := range batch {
+		quotes[i] = invalidQuote(s, at)
+	}
+	return quotes
+}
+
+func invalidQuote(s model.Stock, at time.Time) model.Quote {
+	return model.Quote{
+		Symbol: s.Symbol, Name: s.Name, Sector: s.Sector,
+		FetchedAt: at, Valid: false,
+	}
+}
+
+// orderQuotes returns fetched quotes in batch order, filling missing symbols as invalid.
+func orderQuotes(batch []model.Stock, fetched map[string]model.Quote, at time.Time) []model.Quote {
+	quotes := make([]model.Quote, 0, len(batch))
+	for _, s := range batch {
+		if q, ok := fetched[s.Symbol]; ok {
+			quotes = append(quotes, q)
+		} else {
+			quotes = append(quotes, invalidQuote(s, at))
+		}
+	}
+	return quotes
+}
@@ -0,0 +1,446 @@
+package fetcher_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tse-scanner/fetcher"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+// fixtureRoundTripper serves testdata files keyed by URL path suffix and
+// records every request it receives.
+type fixtureRoundTripper struct {
+	t        *testing.T
+	fixtures map[string]string // path suffix → testdata file
+	mu       sync.Mutex
+	requests []*http.Request
+}
+
+func (rt *fixtureRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.requests = append(rt.requests, req)
+	rt.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	for suffix, file := range rt.fixtures {
+		if strings.HasSuffix(req.URL.Path, suffix) {
+			b, err := os.ReadFile(filepath.Join("testdata", file))
+			if err != nil {
+				rt.t.Errorf("fixture %s: %v", file, err)
+			}
+			rec.Write(b)
+			return rec.Result(), nil
+		}
+	}
+	rec.WriteHeader(http.StatusNotFound)
+	return rec.Result(), nil
+}
+
+func fixtureClient(t *testing.T, fixtures map[string]string) (*http.Client, *fixtureRoundTripper) {
+	rt := &fixtureRoundTripper{t: t, fixtures: fixtures}
+	return &http.Client{Transport: rt}, rt
+}
+
+// ---- Stooq ----
+
+func TestStooq_ParsesFixture(t *testing.T) {
+	hc, rt := fixtureClient(t, map[string]string{"/q/l/": "stooq_quote.csv"})
+	p := fetcher.NewStooqWithHTTP(hc, fetcher.WithRateLimit(0))
+
+	quotes, err := p.FetchQuotes(context.Background(), makeStocks("7203.T", "6758.T", "9984.T"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := rt.requests[0].URL.Query().Get("s"); got != "7203.jp 6758.jp 9984.jp" {
+		t.Errorf("symbols param: got %q", got)
+	}
+	if len(quotes) != 3 {
+		t.Fatalf("want 3 quotes, got %d", len(quotes))
+	}
+	q := quotes[0]
+	if !q.Valid || q.Symbol != "7203.T" || q.Name != "テスト7203.T" {
+		t.Fatalf("unexpected first quote: %+v", q)
+	}
+	if q.Price != 3200 || q.PrevClose != 3050 || q.Change != 150 || q.Volume != 8000000 || q.DayHigh != 3250 {
+		t.Errorf("unexpected values: %+v", q)
+	}
+	if quotes[1].Valid {
+		t.Error("N/D row should be invalid")
+	}
+	if quotes[2].Valid || quotes[2].Symbol != "9984.T" {
+		t.Errorf("missing row should be invalid 9984.T, got %+v", quotes[2])
+	}
+}
+
+func TestStooq_HTTPError_AllMarkedInvalid(t *testing.T) {
+	hc, _ := fixtureClient(t, nil)
+	p := fetcher.NewStooqWithHTTP(hc)
+
+	quotes, err := p.FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil {
+		t.Fatalf("unexpected top-level error: %v", err)
+	}
+	if len(quotes) != 1 || quotes[0].Valid {
+		t.Error("want 1 invalid quote after HTTP error")
+	}
+}
+
+// ---- J-Quants ----
+
+func TestJQuants_ParsesFixture(t *testing.T) {
+	hc, rt := fixtureClient(t, map[string]string{
+		"/token/auth_refresh":  "jquants_token.json",
+		"/prices/daily_quotes": "jquants_daily_quotes.json",
+	})
+	p := fetcher.NewJQuantsWithHTTP(hc, "refresh", fetcher.WithRateLimit(0))
+
+	quotes, err := p.FetchQuotes(context.Background(), makeStocks("7203.T", "6758.T"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != 2 {
+		t.Fatalf("want 2 quotes, got %d", len(quotes))
+	}
+	q := quotes[0]
+	if !q.Valid || q.Price != 3200 || q.PrevClose != 3050 || q.Open != 3150 {
+		t.Errorf("unexpected quote: %+v", q)
+	}
+	// 当日を除く 3 営業日（null 行は除外）の平均出来高
+	if q.AvgVolume3M != 5000000 {
+		t.Errorf("avg volume: got %d, want 5000000", q.AvgVolume3M)
+	}
+	if q.WeekHigh52 != 3250 || q.WeekLow52 != 2900 {
+		t.Errorf("52w range: got %f–%f, want 2900–3250", q.WeekLow52, q.WeekHigh52)
+	}
+
+	var auth, quoteReqs int
+	for _, req := range rt.requests {
+		switch {
+		case strings.HasSuffix(req.URL.Path, "/token/auth_refresh"):
+			auth++
+			if req.URL.Query().Get("refreshtoken") != "refresh" {
+				t.Errorf("refresh token not sent: %s", req.URL)
+			}
+		case strings.HasSuffix(req.URL.Path, "/prices/daily_quotes"):
+			quoteReqs++
+			if got := req.Header.Get("Authorization"); got != "Bearer test-id-token" {
+				t.Errorf("Authorization: got %q", got)
+			}
+			if code := req.URL.Query().Get("code"); code != "72030" && code != "67580" {
+				t.Errorf("unexpected code %q", code)
+			}
+		}
+	}
+	if auth != 1 || quoteReqs != 2 {
+		t.Errorf("requests: auth=%d quotes=%d, want 1/2", auth, quoteReqs)
+	}
+}
+
+func TestJQuants_MissingTokenIsError(t *testing.T) {
+	hc, _ := fixtureClient(t, nil)
+	p := fetcher.NewJQuantsWithHTTP(hc, "")
+	if _, err := p.FetchQuotes(context.Background(), makeStocks("7203.T")); err == nil {
+		t.Error("want error without refresh token")
+	}
+}
+
+func TestJQuants_AuthFailureIsError(t *testing.T) {
+	hc, _ := fixtureClient(t, nil) // auth_refresh → 404
+	p := fetcher.NewJQuantsWithHTTP(hc, "refresh")
+	if _, err := p.FetchQuotes(context.Background(), makeStocks("7203.T")); err == nil {
+		t.Error("want error when authentication fails")
+	}
+}
+
+// revokingRoundTripper issues ID tokens tok-1, tok-2, ... and answers 401
+// to quote requests made with tok-1, as if it had been revoked early.
+type revokingRoundTripper struct {
+	t      *testing.T
+	issued atomic.Int32
+}
+
+func (rt *revokingRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	switch {
+	case strings.HasSuffix(req.URL.Path, "/token/auth_refresh"):
+		fmt.Fprintf(rec, `{"idToken":"tok-%d"}`, rt.issued.Add(1))
+	case req.Header.Get("Authorization") == "Bearer tok-1":
+		rec.WriteHeader(http.StatusUnauthorized)
+	default:
+		b, err := os.ReadFile(filepath.Join("testdata", "jquants_daily_quotes.json"))
+		if err != nil {
+			rt.t.Fatal(err)
+		}
+		rec.Write(b)
+	}
+	return rec.Result(), nil
+}
+
+func TestJQuants_ReauthenticatesOnUnauthorized(t *testing.T) {
+	rt := &revokingRoundTripper{t: t}
+	p := fetcher.NewJQuantsWithHTTP(&http.Client{Transport: rt}, "refresh", fetcher.WithRateLimit(0))
+
+	quotes, err := p.FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != 1 || !quotes[0].Valid {
+		t.Errorf("want a valid quote after re-authentication, got %+v", quotes)
+	}
+	if n := rt.issued.Load(); n != 2 {
+		t.Errorf("token exchanges: got %d, want 2", n)
+	}
+}
+
+// ---- Replay / Recorder ----
+
+func TestReplay_ReturnsSnapshotsInOrderThenHoldsLast(t *testing.T) {
+	p, err := fetcher.NewReplay(filepath.Join("testdata", "replay.ndjson"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if p.Len() != 2 {
+		t.Fatalf("want 2 snapshots, got %d", p.Len())
+	}
+	stocks := makeStocks("7203.T", "6758.T")
+	for i, want := range []float64{3100, 3200, 3200} {
+		quotes, err := p.FetchQuotes(context.Background(), stocks)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if quotes[0].Price != want || !quotes[0].Valid {
+			t.Errorf("call %d: got %+v, want price %v", i, quotes[0], want)
+		}
+		if quotes[1].Valid || quotes[1].Symbol != "6758.T" {
+			t.Errorf("call %d: unrecorded symbol should be invalid, got %+v", i, quotes[1])
+		}
+	}
+}
+
+func TestRecorder_RoundTripsThroughReplay(t *testing.T) {
+	results := []map[string]interface{}{{"symbol": "7203.T", "regularMarketPrice": 3200.0}}
+	var buf bytes.Buffer
+	rec := fetcher.NewRecorder(newClient(http.StatusOK, buildYahooJSON(results)), &buf)
+	if rec.Name() != "yahoo" {
+		t.Errorf("Name: got %q, want yahoo", rec.Name())
+	}
+
+	stocks := []model.Stock{{Symbol: "7203.T", Name: "トヨタ自動車"}}
+	for i := 0; i < 2; i++ {
+		if _, err := rec.FetchQuotes(context.Background(), stocks); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := rec.Err(); err != nil {
+		t.Fatalf("unexpected record error: %v", err)
+	}
+
+	replay, err := fetcher.ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("ReadReplay: %v", err)
+	}
+	if replay.Len() != 2 {
+		t.Fatalf("want 2 snapshots, got %d", replay.Len())
+	}
+	quotes, _ := replay.FetchQuotes(context.Background(), stocks)
+	if quotes[0].Price != 3200 || quotes[0].Name != "トヨタ自動車" {
+		t.Errorf("replayed quote: %+v", quotes[0])
+	}
+}
+
+func TestRecorder_RecordsOnlyGivenStocks(t *testing.T) {
+	results := []map[string]interface{}{
+		{"symbol": "7203.T", "regularMarketPrice": 3200.0},
+		{"symbol": "1306.T", "regularMarketPrice": 2800.0},
+	}
+	var buf bytes.Buffer
+	watch := model.Stock{Symbol: "7203.T", Name: "トヨタ自動車"}
+	rec := fetcher.NewRecorder(newClient(http.StatusOK, buildYahooJSON(results)), &buf, watch)
+	quotes, err := rec.FetchQuotes(context.Background(), []model.Stock{watch, {Symbol: "1306.T"}})
+	if err != nil || len(quotes) != 2 {
+		t.Fatalf("FetchQuotes: %v, %v", quotes, err)
+	}
+
+	replay, err := fetcher.ReadReplay(&buf)
+	if err != nil {
+		t.Fatalf("ReadReplay: %v", err)
+	}
+	if snap := replay.Snapshots()[0]; len(snap.Quotes) != 1 || snap.Quotes[0].Symbol != "7203.T" {
+		t.Errorf("want only 7203.T recorded, got %+v", snap.Quotes)
+	}
+}
+
+func TestReadReplay_RejectsEmptyAndMalformed(t *testing.T) {
+	if _, err := fetcher.ReadReplay(strings.NewReader("\n")); err == nil {
+		t.Error("want error for empty replay")
+	}
+	if _, err := fetcher.ReadReplay(strings.NewReader("{bad\n")); err == nil {
+		t.Error("want error for malformed line")
+	}
+}
+
+// ---- scan report ----
+
+func TestFetch_ReportsBatchErrorsAndMissingSymbols(t *testing.T) {
+	symbols := make([]string, 51) // 50 + 1 で 2 バッチになる
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("%d.T", 1000+i)
+	}
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		requested := strings.Split(r.URL.Query().Get("symbols"), ",")
+		if len(requested) == 1 {
+			w.WriteHeader(http.StatusBadRequest) // リトライされない失敗
+			return
+		}
+		var results []map[string]interface{}
+		for _, sym := range requested {
+			if sym != "1001.T" {
+				results = append(results, map[string]interface{}{"symbol": sym, "regularMarketPrice": 100.0})
+			}
+		}
+		w.Write([]byte(buildYahooJSON(results))) //nolint:errcheck
+	})
+
+	quotes, report, err := fetcher.Fetch(context.Background(), fetcher.NewWithHTTP(hc, fastRetry), makeStocks(symbols...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(quotes) != 51 || report.Provider != "yahoo" {
+		t.Fatalf("got %d quotes from %q", len(quotes), report.Provider)
+	}
+	if report.Requested != 51 || report.Valid != 49 || len(report.Batches) != 2 || report.FailedBatches() != 1 {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	if got := report.Coverage(); got < 0.96 || got > 0.961 {
+		t.Errorf("coverage: got %v, want 49/51", got)
+	}
+	reasons := make(map[string]string)
+	for _, f := range report.Failures {
+		reasons[f.Symbol] = f.Reason
+	}
+	if len(reasons) != 2 || !strings.Contains(reasons["1001.T"], "データなし") || !strings.Contains(reasons["1050.T"], "400") {
+		t.Errorf("unexpected failures: %+v", report.Failures)
+	}
+}
+
+func TestFetch_RecorderPassesReportThrough(t *testing.T) {
+	var calls atomic.Int32
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		quoteJSON(w, r)
+	})
+	var buf bytes.Buffer
+	rec := fetcher.NewRecorder(fetcher.NewWithHTTP(hc, fastRetry), &buf)
+	_, report, err := fetcher.Fetch(context.Background(), rec, makeStocks("7203.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Batches) != 1 || report.Batches[0].Attempts != 2 || report.Batches[0].Err != "" {
+		t.Errorf("want one batch succeeding on the 2nd attempt, got %+v", report.Batches)
+	}
+	if report.Coverage() != 1 || buf.Len() == 0 {
+		t.Errorf("coverage %v, recorded %d bytes", report.Coverage(), buf.Len())
+	}
+}
+
+func TestFetch_DerivesReportForPlainProviders(t *testing.T) {
+	fetchedAt := time.Now().Add(-2 * time.Hour)
+	p, err := fetcher.ReadReplay(strings.NewReader(fmt.Sprintf(
+		`{"recordedAt":%q,"quotes":[{"Symbol":"7203.T","Price":3100,"FetchedAt":%[1]q,"Valid":true}]}`,
+		fetchedAt.Format(time.RFC3339))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, report, err := fetcher.Fetch(context.Background(), p, makeStocks("7203.T", "6758.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Provider != "replay" || report.Valid != 1 || len(report.Failures) != 1 || report.Failures[0].Symbol != "6758.T" {
+		t.Errorf("unexpected report: %+v", report)
+	}
+	// 鮮度は取得時刻ではなく気配値の FetchedAt から測る
+	if report.Staleness < 2*time.Hour || report.Staleness > 3*time.Hour {
+		t.Errorf("staleness: got %v, want the age of the recording", report.Staleness)
+	}
+}
+
+// ---- Yahoo chart ----
+
+func TestFetchBars_ParsesFixture(t *testing.T) {
+	hc, rt := fixtureClient(t, map[string]string{"/v8/finance/chart/7203.T": "yahoo_chart.json"})
+	client := fetcher.NewWithHTTP(hc)
+
+	bars, err := client.FetchBars(context.Background(), "7203.T", fetcher.IntervalDaily, fetcher.RangeDaily)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if q := rt.requests[0].URL.Query(); q.Get("interval") != "1d" || q.Get("range") != "6mo" {
+		t.Errorf("query: %v", q)
+	}
+	if len(bars) != 2 {
+		t.Fatalf("want 2 bars (null candle skipped), got %d", len(bars))
+	}
+	b := bars[1]
+	if b.Close != 3100 || b.High != 3120 || b.Volume != 6000000 || b.Time.Unix() != 1718323200 {
+		t.Errorf("unexpected bar: %+v", b)
+	}
+}
+
+func TestFetchBarsAll_OmitsFailures(t *testing.T) {
+	hc, _ := fixtureClient(t, map[string]string{"/v8/finance/chart/7203.T": "yahoo_chart.json"})
+	client := fetcher.NewWithHTTP(hc, fetcher.WithRateLimit(0))
+
+	got := client.FetchBarsAll(context.Background(), []string{"7203.T", "6758.T"}, fetcher.IntervalDaily, fetcher.RangeDaily)
+	if len(got) != 1 || len(got["7203.T"]) != 2 {
+		t.Errorf("want bars for 7203.T only, got %v", got)
+	}
+}
+
+// failOnce fails its first write.
+type failOnce struct {
+	bytes.Buffer
+	failed bool
+}
+
+func (w *failOnce) Write(p []byte) (int, error) {
+	if !w.failed {
+		w.failed = true
+		return 0, errors.New("disk full")
+	}
+	return w.Buffer.Write(p)
+}
+
+func TestRecorder_ErrReportsEachFailureOnce(t *testing.T) {
+	results := []map[string]interface{}{{"symbol": "7203.T", "regularMarketPrice": 3200.0}}
+	rec := fetcher.NewRecorder(newClient(http.StatusOK, buildYahooJSON(results)), &failOnce{})
+	stocks := []model.Stock{{Symbol: "7203.T"}}
+
+	rec.FetchQuotes(context.Background(), stocks) //nolint:errcheck
+	if err := rec.Err(); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("Err: got %v, want write error", err)
+	}
+	if err := rec.Err(); err != nil {
+		t.Errorf("Err after report: got %v, want nil", err)
+	}
+	rec.FetchQuotes(context.Background(), stocks) //nolint:errcheck
+	if err := rec.Err(); err != nil {
+		t.Errorf("Err after successful write: got %v, want nil", err)
+	}
+}
@@ -0,0 +1,172 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"tse-scanner/model"
+)
+
+// Snapshot is one recorded scan: every quote returned at RecordedAt.
+// Replay files are NDJSON, one Snapshot per line.
+type Snapshot struct {
+	RecordedAt time.Time     `json:"recordedAt"`
+	Quotes     []model.Quote `json:"quotes"`
+}
+
+// Replay serves previously recorded snapshots, one per FetchQuotes call.
+// After the last snapshot it keeps returning the last one, so a replayed
+// session can be inspected at leisure. It makes no network requests.
+type Replay struct {
+	mu        sync.Mutex
+	snapshots []Snapshot
+	next      int
+}
+
+var _ QuoteProvider = (*Replay)(nil)
+
+// NewReplay loads snapshots from an NDJSON file written by Recorder.
+func NewReplay(path string) (*Replay, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("リプレイファイルを開けません: %w", err)
+	}
+	defer f.Close()
+	r, err := ReadReplay(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return r, nil
+}
+
+// ReadReplay reads NDJSON snapshots from r. Blank lines are ignored.
+func ReadReplay(r io.Reader) (*Replay, error) {
+	var snaps []Snapshot
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024) // 全銘柄の 1 スナップショットは数 MB になる
+	for line := 1; sc.Scan(); line++ {
+		if len(sc.Bytes()) == 0 {
+			continue
+		}
+		var s Snapshot
+		if err := json.Unmarshal(sc.Bytes(), &s); err != nil {
+			return nil, fmt.Errorf("%d 行目: JSONパースエラー: %w", line, err)
+		}
+		snaps = append(snaps, s)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("読み込みエラー: %w", err)
+	}
+	if len(snaps) == 0 {
+		return nil, errors.New("スナップショットがありません")
+	}
+	return &Replay{snapshots: snaps}, nil
+}
+
+// Name implements QuoteProvider.
+func (r *Replay) Name() string { return "replay" }
+
+// Len returns the number of recorded snapshots.
+func (r *Replay) Len() int { return len(r.snapshots) }
+
+// Snapshots returns every recorded snapshot in file order.
+func (r *Replay) Snapshots() []Snapshot { return r.snapshots }
+
+// FetchQuotes returns the next snapshot, restricted to and ordered by stocks.
+// Symbols absent from the snapshot are returned as invalid.
+func (r *Replay) FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	snap := r.snapshots[r.next]
+	if r.next < len(r.snapshots)-1 {
+		r.next++
+	}
+	r.mu.Unlock()
+
+	fetched := make(map[string]model.Quote, len(snap.Quotes))
+	for _, q := range snap.Quotes {
+		fetched[q.Symbol] = q
+	}
+	return orderQuotes(stocks, fetched, snap.RecordedAt), nil
+}
+
+// Recorder wraps a QuoteProvider and appends every successful scan to an
+// NDJSON file that NewReplay can read back.
+type Recorder struct {
+	QuoteProvider
+	mu   sync.Mutex
+	out  io.Writer
+	only map[string]bool // nil なら全銘柄を記録する
+	err  error
+}
+
+// NewRecorder returns a Recorder writing snapshots to out. If only is given,
+// just those stocks are recorded, so symbols fetched alongside them
+// (benchmark indices, held-only portfolio stocks) are not replayed later as
+// scan candidates.
+func NewRecorder(p QuoteProvider, out io.Writer, only ...model.Stock) *Recorder {
+	r := &Recorder{QuoteProvider: p, out: out}
+	if len(only) > 0 {
+		r.only = make(map[string]bool, len(only))
+		for _, st := range only {
+			r.only[st.Symbol] = true
+		}
+	}
+	return r
+}
+
+// FetchQuotes fetches from the wrapped provider and records the result.
+// Write failures do not fail the scan; they are reported by Err.
+func (r *Recorder) FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error) {
+	quotes, _, err := r.FetchQuotesWithReport(ctx, stocks)
+	return quotes, err
+}
+
+// FetchQuotesWithReport implements ReportingProvider, passing through the
+// wrapped provider's report.
+func (r *Recorder) FetchQuotesWithReport(ctx context.Context, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error) {
+	quotes, report, err := Fetch(ctx, r.QuoteProvider, stocks)
+	if err != nil {
+		return quotes, nil, err
+	}
+	recorded := quotes
+	if r.only != nil {
+		recorded = make([]model.Quote, 0, len(r.only))
+		for _, q := range quotes {
+			if r.only[q.Symbol] {
+				recorded = append(recorded, q)
+			}
+		}
+	}
+	b, err := json.Marshal(Snapshot{RecordedAt: time.Now(), Quotes: recorded})
+	if err == nil {
+		r.mu.Lock()
+		_, err = r.out.Write(append(b, '\n'))
+		r.mu.Unlock()
+	}
+	if err != nil {
+		r.mu.Lock()
+		r.err = fmt.Errorf("記録エラー: %w", err)
+		r.mu.Unlock()
+	}
+	return quotes, report, nil
+}
+
+// Err returns the most recent write error since the previous call, if any,
+// and clears it, so each failure is reported once.
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	err := r.err
+	r.err = nil
+	return err
+}
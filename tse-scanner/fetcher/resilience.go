@@ -0,0 +1,209 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultAttempts    = 3                      // 1 バッチあたりの最大試行回数
+	defaultBackoffBase = 250 * time.Millisecond // 初回リトライまでの待ち時間（以降倍々）
+	defaultBackoffMax  = 5 * time.Second
+	maxRetryAfter      = time.Minute // これより長い Retry-After はリトライせず失敗とする
+
+	defaultBreakerThreshold = 5 // 連続失敗でブレーカーを開く回数
+	defaultBreakerCooldown  = 30 * time.Second
+)
+
+// ErrCircuitOpen is returned by FetchQuotes while the circuit breaker is
+// open after repeated failures. The scan loop should skip the scan and try
+// again later rather than hammer a struggling (or blocking) server.
+var ErrCircuitOpen = errors.New("連続エラーのため取得を一時停止中です（サーキットブレーカー）")
+
+// StatusError is a non-200 HTTP response.
+type StatusError struct {
+	Code       int
+	RetryAfter time.Duration // Retry-After ヘッダーの値（なければ 0）
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("HTTPステータス %d", e.Code)
+}
+
+// newStatusError builds a StatusError from resp, parsing Retry-After.
+func newStatusError(resp *http.Response) *StatusError {
+	return &StatusError{
+		Code:       resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter accepts both forms of Retry-After: delay seconds and an
+// HTTP date. Invalid or past values yield 0.
+func parseRetryAfter(v string, now time.Time) time.Duration {
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(v); err == nil {
+		return max(time.Duration(secs)*time.Second, 0)
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		return max(t.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryable reports whether err is worth another attempt: throttling (429),
+// server errors (5xx), an expired session and transport errors. Parse errors
+// and other 4xx responses fail the same way every time.
+func retryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusTooManyRequests || se.Code >= 500
+	}
+	if errors.Is(err, errStaleSession) {
+		return true
+	}
+	var te *transportError
+	return errors.As(err, &te)
+}
+
+// transportError marks a failed round trip (connection reset, timeout, ...).
+type transportError struct{ err error }
+
+func (e *transportError) Error() string { return "HTTPリクエストエラー: " + e.err.Error() }
+func (e *transportError) Unwrap() error { return e.err }
+
+// WithRetry sets how many times a failed batch is attempted (minimum 1, i.e.
+// no retry) and the exponential backoff between attempts, starting at base
+// and capped at maxDelay. Each delay is jittered to 50–100% of its value.
+func WithRetry(attempts int, base, maxDelay time.Duration) Option {
+	return func(b *batcher) {
+		b.attempts = max(attempts, 1)
+		b.backoffBase, b.backoffMax = base, max(maxDelay, base)
+	}
+}
+
+// WithCircuitBreaker opens the breaker after threshold consecutive failed
+// batches and keeps it open for cooldown, after which one probe request is
+// let through. A threshold <= 0 disables the breaker.
+func WithCircuitBreaker(threshold int, cooldown time.Duration) Option {
+	return func(b *batcher) {
+		b.breaker = newBreaker(threshold, cooldown)
+	}
+}
+
+// retry calls fn until it succeeds, fails permanently or attempts run out.
+// If ctx is cancelled while waiting to retry, the returned error wraps
+// ctx.Err() so callers see the cancellation rather than the stale failure.
+func (b *batcher) retry(ctx context.Context, fn func() error) error {
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if attempt >= b.attempts || !retryable(err) {
+			return err
+		}
+		delay := b.backoff(attempt)
+		var se *StatusError
+		if errors.As(err, &se) && se.RetryAfter > 0 {
+			if se.RetryAfter > maxRetryAfter {
+				return err
+			}
+			delay = se.RetryAfter
+		}
+		t := time.NewTimer(delay)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return fmt.Errorf("%w（直前のエラー: %v）", ctx.Err(), err)
+		}
+	}
+}
+
+// backoff returns the jittered delay before retry number attempt (1-based).
+func (b *batcher) backoff(attempt int) time.Duration {
+	d := b.backoffBase << (attempt - 1)
+	if d <= 0 || d > b.backoffMax {
+		d = b.backoffMax
+	}
+	half := int64(d / 2)
+	return time.Duration(half + rand.Int63n(half+1))
+}
+
+// breaker is a consecutive-failure circuit breaker. A nil *breaker always allows.
+type breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool // 半開状態で試行中のリクエストがある
+}
+
+func newBreaker(threshold int, cooldown time.Duration) *breaker {
+	if threshold <= 0 {
+		return nil
+	}
+	return &breaker{threshold: threshold, cooldown: cooldown}
+}
+
+// allow reports whether a request may be sent now. Once the cool-down has
+// passed a single probe is allowed; its result closes or re-opens the breaker.
+func (br *breaker) allow(now time.Time) bool {
+	if br == nil {
+		return true
+	}
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	if br.failures < br.threshold {
+		return true
+	}
+	if now.Before(br.openUntil) || br.probing {
+		return false
+	}
+	br.probing = true
+	return true
+}
+
+// record updates the breaker with the outcome of an allowed request. Only
+// failures worth retrying (throttling, 5xx, network) count towards opening it.
+func (br *breaker) record(err error, now time.Time) {
+	if br == nil {
+		return
+	}
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	br.probing = false
+	switch {
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		// 中断はサーバーの状態を示さないので数えない
+	case !retryable(err):
+		// 成功、またはサーバーは応答した（パースエラー・404 など銘柄固有の失敗）
+		br.failures = 0
+	default:
+		br.failures++
+		if br.failures >= br.threshold {
+			br.openUntil = now.Add(br.cooldown)
+		}
+	}
+}
+
+// open reports whether requests are currently being refused.
+func (br *breaker) open(now time.Time) bool {
+	if br == nil {
+		return false
+	}
+	br.mu.Lock()
+	defer br.mu.Unlock()
+	return br.failures >= br.threshold && now.Before(br.openUntil)
+}
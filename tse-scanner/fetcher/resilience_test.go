@@ -0,0 +1,271 @@
+package fetcher_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"tse-scanner/fetcher"
+)
+
+// ---- helpers ----
+
+// hostRewriter sends every request to srv, keeping the original host in
+// req.Host so one handler can play fc.yahoo.com, consent.yahoo.com and
+// query1.finance.yahoo.com.
+type hostRewriter struct{ srv *httptest.Server }
+
+func (rt hostRewriter) RoundTrip(req *http.Request) (*http.Response, error) {
+	target, _ := url.Parse(rt.srv.URL)
+	r := req.Clone(req.Context())
+	r.Host = req.URL.Host
+	r.URL.Scheme, r.URL.Host = target.Scheme, target.Host
+	resp, err := rt.srv.Client().Transport.RoundTrip(r)
+	if err == nil {
+		resp.Request = req // リダイレクト先の判定・クッキーは元の URL で扱う
+	}
+	return resp, err
+}
+
+func fakeYahoo(t *testing.T, h http.HandlerFunc) *http.Client {
+	t.Helper()
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return &http.Client{Transport: hostRewriter{srv}}
+}
+
+var fastRetry = fetcher.WithRetry(3, time.Millisecond, 5*time.Millisecond)
+
+func quoteJSON(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte(buildYahooJSON([]map[string]interface{}{ //nolint:errcheck
+		{"symbol": "7203.T", "regularMarketPrice": 3200.0},
+	})))
+}
+
+// ---- retry ----
+
+func TestRetry_ServerErrorsThenSuccess(t *testing.T) {
+	var calls atomic.Int32
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		quoteJSON(w, r)
+	})
+	quotes, err := fetcher.NewWithHTTP(hc, fastRetry).FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quotes[0].Valid || calls.Load() != 3 {
+		t.Errorf("want valid quote after 3 attempts, got valid=%v after %d", quotes[0].Valid, calls.Load())
+	}
+}
+
+func TestRetry_GivesUpAfterAttempts(t *testing.T) {
+	var calls atomic.Int32
+	hc := fakeYahoo(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	})
+	quotes, err := fetcher.NewWithHTTP(hc, fastRetry).FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if quotes[0].Valid || calls.Load() != 3 {
+		t.Errorf("want invalid quote after 3 attempts, got valid=%v after %d", quotes[0].Valid, calls.Load())
+	}
+}
+
+func TestRetry_NotOnClientError(t *testing.T) {
+	var calls atomic.Int32
+	hc := fakeYahoo(t, func(w http.ResponseWriter, _ *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusNotFound)
+	})
+	fetcher.NewWithHTTP(hc, fastRetry).FetchQuotes(context.Background(), makeStocks("7203.T")) //nolint:errcheck
+	if calls.Load() != 1 {
+		t.Errorf("404 should not be retried, got %d requests", calls.Load())
+	}
+}
+
+func TestRetry_CancelledDuringBackoffReportsCancellation(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	hc := fakeYahoo(t, func(w http.ResponseWriter, _ *http.Request) {
+		cancel() // バックオフ待ちの間にキャンセルされる
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+	c := fetcher.NewWithHTTP(hc, fetcher.WithRetry(3, time.Minute, time.Minute))
+	_, report, err := c.FetchQuotesWithReport(ctx, makeStocks("7203.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Batches) != 1 || !strings.Contains(report.Batches[0].Err, context.Canceled.Error()) {
+		t.Errorf("want the batch error to report the cancellation, got %+v", report.Batches)
+	}
+}
+
+func TestRetry_RespectsRetryAfter(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		times []time.Time
+	)
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		times = append(times, time.Now())
+		n := len(times)
+		mu.Unlock()
+		if n == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		quoteJSON(w, r)
+	})
+	quotes, err := fetcher.NewWithHTTP(hc, fastRetry).FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !quotes[0].Valid || len(times) != 2 {
+		t.Fatalf("want success on 2nd attempt, got valid=%v after %d", quotes[0].Valid, len(times))
+	}
+	if gap := times[1].Sub(times[0]); gap < time.Second {
+		t.Errorf("Retry-After: 1 not respected, retried after %v", gap)
+	}
+}
+
+// ---- circuit breaker ----
+
+func TestCircuitBreaker_OpensAndRecovers(t *testing.T) {
+	var (
+		calls   atomic.Int32
+		healthy atomic.Bool
+	)
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		quoteJSON(w, r)
+	})
+	client := fetcher.NewWithHTTP(hc,
+		fetcher.WithRetry(1, time.Millisecond, time.Millisecond),
+		fetcher.WithCircuitBreaker(2, 50*time.Millisecond))
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := client.FetchQuotes(ctx, makeStocks("7203.T")); err != nil {
+			t.Fatalf("scan %d: %v", i, err)
+		}
+	}
+	before := calls.Load()
+	if _, err := client.FetchQuotes(ctx, makeStocks("7203.T")); !errors.Is(err, fetcher.ErrCircuitOpen) {
+		t.Fatalf("want ErrCircuitOpen, got %v", err)
+	}
+	if calls.Load() != before {
+		t.Error("no request should be sent while the breaker is open")
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	quotes, err := client.FetchQuotes(ctx, makeStocks("7203.T"))
+	if err != nil || !quotes[0].Valid {
+		t.Fatalf("probe after cool-down should succeed: %v", err)
+	}
+	if _, err := client.FetchQuotes(ctx, makeStocks("7203.T")); err != nil {
+		t.Errorf("breaker should be closed again: %v", err)
+	}
+}
+
+// ---- crumb / cookie session ----
+
+func TestSession_CrumbHandshakeOnUnauthorized(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		path []string
+	)
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path = append(path, r.Host+r.URL.Path)
+		mu.Unlock()
+		cookie, _ := r.Cookie("A3")
+		switch {
+		case r.Host == "fc.yahoo.com":
+			http.SetCookie(w, &http.Cookie{Name: "A3", Value: "sess", Domain: ".yahoo.com", Path: "/"})
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1/test/getcrumb":
+			if cookie == nil || cookie.Value != "sess" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte("Ab1.cD2/eF3")) //nolint:errcheck
+		case r.URL.Query().Get("crumb") != "Ab1.cD2/eF3" || cookie == nil:
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"finance":{"error":{"code":"Unauthorized","description":"Invalid Crumb"}}}`)) //nolint:errcheck
+		default:
+			quoteJSON(w, r)
+		}
+	})
+	client := fetcher.NewWithHTTP(hc, fastRetry)
+
+	quotes, err := client.FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil || !quotes[0].Valid {
+		t.Fatalf("want valid quote after handshake, got %+v, %v (requests %v)", quotes, err, path)
+	}
+	want := []string{"query1.finance.yahoo.com/v7/finance/quote", "fc.yahoo.com/", "query1.finance.yahoo.com/v1/test/getcrumb", "query1.finance.yahoo.com/v7/finance/quote"}
+	if len(path) != len(want) {
+		t.Fatalf("requests = %v, want %v", path, want)
+	}
+	for i := range want {
+		if path[i] != want[i] {
+			t.Errorf("request %d = %s, want %s", i, path[i], want[i])
+		}
+	}
+
+	path = nil
+	if _, err := client.FetchQuotes(context.Background(), makeStocks("7203.T")); err != nil {
+		t.Fatal(err)
+	}
+	if len(path) != 1 {
+		t.Errorf("crumb should be reused, got requests %v", path)
+	}
+}
+
+func TestSession_AcceptsConsentForm(t *testing.T) {
+	var posted url.Values
+	hc := fakeYahoo(t, func(w http.ResponseWriter, r *http.Request) {
+		cookie, _ := r.Cookie("A3")
+		switch {
+		case r.Host == "fc.yahoo.com":
+			http.Redirect(w, r, "https://consent.yahoo.com/v2/collectConsent?sessionId=s1", http.StatusFound)
+		case r.Host == "consent.yahoo.com" && r.Method == http.MethodGet:
+			w.Write([]byte(`<form method="post"><input type="hidden" name="csrfToken" value="tok"><input type="hidden" name="sessionId" value="s1"><button name="agree" value="agree">OK</button></form>`)) //nolint:errcheck
+		case r.Host == "consent.yahoo.com":
+			r.ParseForm() //nolint:errcheck
+			posted = r.PostForm
+			http.SetCookie(w, &http.Cookie{Name: "A3", Value: "eu", Domain: ".yahoo.com", Path: "/"})
+		case r.URL.Path == "/v1/test/getcrumb" && cookie != nil:
+			w.Write([]byte("euCrumb")) //nolint:errcheck
+		case r.URL.Query().Get("crumb") == "euCrumb":
+			quoteJSON(w, r)
+		default:
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	quotes, err := fetcher.NewWithHTTP(hc, fastRetry).FetchQuotes(context.Background(), makeStocks("7203.T"))
+	if err != nil || !quotes[0].Valid {
+		t.Fatalf("want valid quote after consent, got %+v, %v", quotes, err)
+	}
+	if posted.Get("csrfToken") != "tok" || posted.Get("sessionId") != "s1" || posted.Get("agree") != "agree" {
+		t.Errorf("consent form = %v", posted)
+	}
+}
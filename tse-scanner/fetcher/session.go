@@ -0,0 +1,198 @@
+package fetcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Yahoo Finance's v7 quote endpoint rejects requests without a session
+// cookie and a matching "crumb" token. The handshake is:
+//
+//  1. GET fc.yahoo.com, which sets the A3 session cookie. In the EU it
+//     redirects to a consent page whose form must be posted first.
+//  2. GET /v1/test/getcrumb with that cookie, which returns the crumb.
+//
+// The crumb is then sent as a query parameter on every quote request.
+var (
+	cookieURL = "https://fc.yahoo.com/"
+	crumbURL  = "https://query1.finance.yahoo.com/v1/test/getcrumb"
+)
+
+// errStaleSession means Yahoo rejected the crumb; the session has been
+// refreshed and the request should be retried.
+var errStaleSession = errors.New("Yahoo Finance のセッション（crumb）が無効です")
+
+// crumbPattern matches a plausible crumb (Yahoo returns e.g. "Ab1.cD2/eF3").
+var crumbPattern = regexp.MustCompile(`^[A-Za-z0-9./\\_-]{1,64}$`)
+
+// hiddenInput matches the hidden fields of the consent form.
+var hiddenInput = regexp.MustCompile(`<input[^>]*type="hidden"[^>]*name="([^"]+)"[^>]*value="([^"]*)"`)
+
+// yahooSession holds the cookie and crumb shared by a Client's requests.
+// The handshake is only performed once Yahoo rejects a request, so
+// endpoints that do not need a crumb never pay for it.
+type yahooSession struct {
+	http HTTPDoer
+	jar  http.CookieJar // HTTPDoer がクッキーを扱わない場合に自前で保持する
+
+	mu       sync.Mutex
+	crumb    string
+	inflight *refreshCall // 実行中のハンドシェイク（なければ nil）
+}
+
+// refreshCall is one handshake shared by every request that saw the same
+// stale crumb.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+func newYahooSession(h HTTPDoer) *yahooSession {
+	s := &yahooSession{http: h}
+	if c, ok := h.(*http.Client); !ok || c.Jar == nil {
+		s.jar, _ = cookiejar.New(nil)
+	}
+	return s
+}
+
+// current returns the crumb to send, or "" before the first handshake.
+func (s *yahooSession) current() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.crumb
+}
+
+// do sends req with the session cookies and the browser User-Agent.
+func (s *yahooSession) do(req *http.Request) (*http.Response, error) {
+	req.Header.Set("User-Agent", userAgent)
+	if s.jar != nil {
+		for _, c := range s.jar.Cookies(req.URL) {
+			req.AddCookie(c)
+		}
+	}
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if s.jar != nil {
+		u := req.URL
+		if resp.Request != nil {
+			u = resp.Request.URL
+		}
+		s.jar.SetCookies(u, resp.Cookies())
+	}
+	return resp, nil
+}
+
+// refresh performs the cookie/crumb handshake unless another request
+// already replaced stale, the crumb that was rejected. The handshake runs
+// without holding s.mu; concurrent callers wait for the one in flight.
+func (s *yahooSession) refresh(ctx context.Context, stale string) error {
+	s.mu.Lock()
+	if s.crumb != stale {
+		s.mu.Unlock()
+		return nil
+	}
+	if c := s.inflight; c != nil {
+		s.mu.Unlock()
+		select {
+		case <-c.done:
+			return c.err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	c := &refreshCall{done: make(chan struct{})}
+	s.inflight = c
+	s.mu.Unlock()
+
+	crumb, err := s.handshake(ctx)
+
+	s.mu.Lock()
+	if err == nil {
+		s.crumb = crumb
+	}
+	s.inflight = nil
+	s.mu.Unlock()
+	c.err = err
+	close(c.done)
+	return err
+}
+
+// handshake obtains the session cookie and returns a fresh crumb.
+func (s *yahooSession) handshake(ctx context.Context) (string, error) {
+	resp, err := s.get(ctx, cookieURL)
+	if err != nil {
+		return "", err
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.Request != nil && isConsentHost(resp.Request.URL.Host) {
+		if err := s.consent(ctx, resp.Request.URL, string(body)); err != nil {
+			return "", err
+		}
+	}
+
+	resp, err = s.get(ctx, crumbURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("crumb の取得に失敗しました: %w", newStatusError(resp))
+	}
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("レスポンス読み込みエラー: %w", err)
+	}
+	crumb := strings.TrimSpace(string(b))
+	if !crumbPattern.MatchString(crumb) {
+		return "", fmt.Errorf("crumb の形式が不正です: %.40q", crumb)
+	}
+	return crumb, nil
+}
+
+// consent accepts the EU consent form served at page.
+func (s *yahooSession) consent(ctx context.Context, page *url.URL, html string) error {
+	form := url.Values{}
+	for _, m := range hiddenInput.FindAllStringSubmatch(html, -1) {
+		form.Set(m[1], m[2])
+	}
+	form.Set("agree", "agree")
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, page.String(), strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := s.do(req)
+	if err != nil {
+		return &transportError{err}
+	}
+	io.Copy(io.Discard, resp.Body) //nolint:errcheck
+	resp.Body.Close()
+	return nil
+}
+
+func (s *yahooSession) get(ctx context.Context, u string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	resp, err := s.do(req)
+	if err != nil {
+		return nil, &transportError{err}
+	}
+	return resp, nil
+}
+
+func isConsentHost(host string) bool {
+	return strings.HasPrefix(host, "consent.") || strings.HasPrefix(host, "guce.")
+}
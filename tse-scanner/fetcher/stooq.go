@@ -0,0 +1,159 @@
+package fetcher
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"tse-scanner/model"
+)
+
+const (
+	stooqURL       = "https://stooq.com/q/l/"
+	stooqBatchSize = 20 // Stooq truncates long symbol lists
+)
+
+// Stooq fetches delayed quotes from the Stooq CSV endpoint. It needs no API
+// key but does not provide average volume or 52-week range, so those fields
+// are left zero (analyzer signals that depend on them simply do not fire).
+type Stooq struct {
+	http HTTPDoer
+	batcher
+}
+
+var _ ReportingProvider = (*Stooq)(nil)
+
+// NewStooq returns a Stooq provider with a production HTTP client (10s timeout).
+func NewStooq(opts ...Option) *Stooq {
+	return NewStooqWithHTTP(&http.Client{Timeout: 10 * time.Second}, opts...)
+}
+
+// NewStooqWithHTTP returns a Stooq provider using the provided HTTPDoer (for testing).
+func NewStooqWithHTTP(h HTTPDoer, opts ...Option) *Stooq {
+	return &Stooq{http: h, batcher: newBatcher(opts)}
+}
+
+// Name implements QuoteProvider.
+func (s *Stooq) Name() string { return "stooq" }
+
+// FetchQuotes implements QuoteProvider.
+func (s *Stooq) FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error) {
+	quotes, _, err := s.FetchQuotesWithReport(ctx, stocks)
+	return quotes, err
+}
+
+// FetchQuotesWithReport implements ReportingProvider.
+func (s *Stooq) FetchQuotesWithReport(ctx context.Context, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error) {
+	quotes, report, err := s.fetchAll(ctx, stocks, stooqBatchSize, s.fetchBatch)
+	if report != nil {
+		report.Provider = s.Name()
+	}
+	return quotes, report, err
+}
+
+func (s *Stooq) fetchBatch(ctx context.Context, batch []model.Stock) ([]model.Quote, error) {
+	symbols := make([]string, len(batch))
+	for i, st := range batch {
+		symbols[i] = toStooqSymbol(st.Symbol)
+	}
+	// f: symbol, date, time, open, high, low, close, volume, previous close
+	url := fmt.Sprintf("%s?s=%s&f=sd2t2ohlcvp&h&e=csv", stooqURL, strings.Join(symbols, "+"))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	resp, err := s.http.Do(req)
+	if err != nil {
+		return nil, &transportError{err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newStatusError(resp)
+	}
+	return parseStooqCSV(resp.Body, batch)
+}
+
+// toStooqSymbol maps "7203.T" to Stooq's "7203.jp".
+func toStooqSymbol(symbol string) string {
+	return strings.ToLower(strings.TrimSuffix(symbol, ".T")) + ".jp"
+}
+
+// fromStooqSymbol maps "7203.JP" back to "7203.T".
+func fromStooqSymbol(symbol string) string {
+	base := strings.TrimSuffix(strings.ToUpper(strings.TrimSpace(symbol)), ".JP")
+	return base + ".T"
+}
+
+// parseStooqCSV parses the quote CSV. Columns are located by header name;
+// rows with "N/D" (no data) are returned as invalid.
+func parseStooqCSV(r io.Reader, batch []model.Stock) ([]model.Quote, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV パースエラー: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("Stooq: 空のレスポンス")
+	}
+	col := make(map[string]int, len(records[0]))
+	for i, h := range records[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	if _, ok := col["symbol"]; !ok {
+		return nil, fmt.Errorf("Stooq: 見出し行に Symbol がありません")
+	}
+	get := func(rec []string, name string) string {
+		i, ok := col[name]
+		if !ok || i >= len(rec) {
+			return ""
+		}
+		return strings.TrimSpace(rec[i])
+	}
+	num := func(rec []string, name string) (float64, bool) {
+		f, err := strconv.ParseFloat(get(rec, name), 64)
+		return f, err == nil
+	}
+
+	lookup := stockLookup(batch)
+	fetched := make(map[string]model.Quote, len(records)-1)
+	now := time.Now()
+	for _, rec := range records[1:] {
+		sym := fromStooqSymbol(get(rec, "symbol"))
+		price, ok := num(rec, "close")
+		if !ok {
+			continue // N/D
+		}
+		st := lookup[sym]
+		q := model.Quote{
+			Symbol:    sym,
+			Name:      st.Name,
+			Sector:    st.Sector,
+			Price:     price,
+			FetchedAt: now,
+			Valid:     true,
+		}
+		q.Open, _ = num(rec, "open")
+		q.DayHigh, _ = num(rec, "high")
+		q.DayLow, _ = num(rec, "low")
+		if v, ok := num(rec, "volume"); ok {
+			q.Volume = int64(v)
+		}
+		if prev, ok := num(rec, "prev"); ok && prev > 0 {
+			q.PrevClose = prev
+			q.Change = price - prev
+			q.ChangePercent = q.Change / prev * 100
+		}
+		fetched[sym] = q
+	}
+	return orderQuotes(batch, fetched, now), nil
+}
@@ -1,5 +1,8 @@
-// Package fetcher retrieves real-time stock quotes from Yahoo Finance.
-// Yahoo Finance provides public JSON endpoints for Japanese stocks (*.T suffix).
+// Package fetcher retrieves real-time stock quotes.
+//
+// Every data source implements QuoteProvider. Client (Yahoo Finance) is the
+// default; Stooq, J-Quants and a local replay file are alternatives selected
+// with the -provider flag.
 package fetcher
 
 import (
@@ -8,6 +11,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/cookiejar"
+	"net/url"
 	"strings"
 	"time"
 
@@ -20,60 +25,50 @@ const (
 	userAgent = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
 )
 
-// HTTPDoer is the interface satisfied by *http.Client, enabling test injection.
-type HTTPDoer interface {
-	Do(req *http.Request) (*http.Response, error)
-}
-
 // Client wraps an HTTP client and fetches Yahoo Finance quotes.
 type Client struct {
-	http HTTPDoer
+	session *yahooSession
+	batcher
 }
 
-// New returns a Client with a production HTTP client (10s timeout).
-func New() *Client {
-	return &Client{
-		http: &http.Client{Timeout: 10 * time.Second},
-	}
+var _ ReportingProvider = (*Client)(nil)
+
+// New returns a Client with a production HTTP client (10s timeout and a
+// cookie jar for the Yahoo session).
+func New(opts ...Option) *Client {
+	jar, _ := cookiejar.New(nil)
+	return NewWithHTTP(&http.Client{Timeout: 10 * time.Second, Jar: jar}, opts...)
 }
 
 // NewWithHTTP returns a Client using the provided HTTPDoer (for testing).
-func NewWithHTTP(h HTTPDoer) *Client {
-	return &Client{http: h}
+func NewWithHTTP(h HTTPDoer, opts ...Option) *Client {
+	return &Client{session: newYahooSession(h), batcher: newBatcher(opts)}
 }
 
+// Name implements QuoteProvider.
+func (c *Client) Name() string { return "yahoo" }
+
 // FetchQuotes fetches quotes for all stocks in the watchlist.
-// Requests are batched to avoid hitting rate limits.
-// Stocks that fail to fetch are returned as Quote{Valid: false}.
+// Requests are batched and fetched concurrently under the client's rate limit,
+// so whole-market universes (~4,000 issues) complete in one scan.
+// Results preserve the input order.
+// Stocks that fail to fetch are returned as Quote{Valid: false}; transient
+// failures (429, 5xx, network) are retried with backoff first.
 func (c *Client) FetchQuotes(ctx context.Context, stocks []model.Stock) ([]model.Quote, error) {
-	// Build symbol→Stock lookup for fast merging
-	lookup := make(map[string]model.Stock, len(stocks))
-	for _, s := range stocks {
-		lookup[s.Symbol] = s
-	}
+	quotes, _, err := c.FetchQuotesWithReport(ctx, stocks)
+	return quotes, err
+}
 
-	results := make([]model.Quote, 0, len(stocks))
-	for i := 0; i < len(stocks); i += batchSize {
-		end := i + batchSize
-		if end > len(stocks) {
-			end = len(stocks)
-		}
-		batch := stocks[i:end]
-
-		quotes, err := c.fetchBatch(ctx, batch, lookup)
-		if err != nil {
-			// Partial failure: fill batch as invalid and continue
-			for _, s := range batch {
-				results = append(results, model.Quote{
-					Symbol: s.Symbol, Name: s.Name, Sector: s.Sector,
-					Valid: false,
-				})
-			}
-			continue
-		}
-		results = append(results, quotes...)
+// FetchQuotesWithReport implements ReportingProvider.
+func (c *Client) FetchQuotesWithReport(ctx context.Context, stocks []model.Stock) ([]model.Quote, *model.ScanReport, error) {
+	lookup := stockLookup(stocks)
+	quotes, report, err := c.fetchAll(ctx, stocks, batchSize, func(ctx context.Context, batch []model.Stock) ([]model.Quote, error) {
+		return c.fetchBatch(ctx, batch, lookup)
+	})
+	if report != nil {
+		report.Provider = c.Name()
 	}
-	return results, nil
+	return quotes, report, err
 }
 
 // fetchBatch fetches one batch of up to batchSize symbols.
@@ -83,24 +78,34 @@ func (c *Client) fetchBatch(ctx context.Context, batch []model.Stock, lookup map
 		symbols[i] = s.Symbol
 	}
 
-	url := fmt.Sprintf("%s?symbols=%s&fields=shortName,regularMarketPrice,regularMarketChange,regularMarketChangePercent,regularMarketVolume,averageDailyVolume3Month,regularMarketDayHigh,regularMarketDayLow,regularMarketOpen,regularMarketPreviousClose,fiftyTwoWeekHigh,fiftyTwoWeekLow",
+	u := fmt.Sprintf("%s?symbols=%s&fields=shortName,regularMarketPrice,regularMarketChange,regularMarketChangePercent,regularMarketVolume,averageDailyVolume3Month,regularMarketDayHigh,regularMarketDayLow,regularMarketOpen,regularMarketPreviousClose,fiftyTwoWeekHigh,fiftyTwoWeekLow",
 		baseURL, strings.Join(symbols, ","))
+	crumb := c.session.current()
+	if crumb != "" {
+		u += "&crumb=" + url.QueryEscape(crumb)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
 	if err != nil {
 		return nil, fmt.Errorf("リクエスト作成エラー: %w", err)
 	}
-	req.Header.Set("User-Agent", userAgent)
 	req.Header.Set("Accept", "application/json")
 
-	resp, err := c.http.Do(req)
+	resp, err := c.session.do(req)
 	if err != nil {
-		return nil, fmt.Errorf("HTTPリクエストエラー: %w", err)
+		return nil, &transportError{err}
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden {
+		// crumb が未取得または失効: セッションを張り直してリトライさせる
+		if err := c.session.refresh(ctx, crumb); err != nil {
+			return nil, err
+		}
+		return nil, errStaleSession
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("HTTPステータス %d", resp.StatusCode)
+		return nil, newStatusError(resp)
 	}
 
 	body, err := io.ReadAll(resp.Body)
@@ -112,6 +117,7 @@ func (c *Client) fetchBatch(ctx context.Context, batch []model.Stock, lookup map
 }
 
 // ---- Yahoo Finance JSON response types ----
+// Yahoo Finance provides public JSON endpoints for Japanese stocks (*.T suffix).
 
 type yahooResponse struct {
 	QuoteResponse struct {
@@ -178,16 +184,5 @@ func parseResponse(body []byte, lookup map[string]model.Stock, batch []model.Sto
 	}
 
 	// Preserve order and fill missing symbols as invalid
-	quotes := make([]model.Quote, 0, len(batch))
-	for _, s := range batch {
-		if q, ok := fetched[s.Symbol]; ok {
-			quotes = append(quotes, q)
-		} else {
-			quotes = append(quotes, model.Quote{
-				Symbol: s.Symbol, Name: s.Name, Sector: s.Sector,
-				FetchedAt: now, Valid: false,
-			})
-		}
-	}
-	return quotes, nil
+	return orderQuotes(batch, fetched, now), nil
 }
@@ -3,9 +3,11 @@ package fetcher_test
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -318,3 +320,84 @@ func TestFetchQuotes_FetchedAtIsPopulated(t *testing.T) {
 		t.Errorf("FetchedAt %v outside expected range [%v, %v]", quotes[0].FetchedAt, before, after)
 	}
 }
+
+// echoRoundTripper answers every request with a quote for each requested
+// symbol (price = request sequence number) and records request start times.
+type echoRoundTripper struct {
+	mu       sync.Mutex
+	starts   []time.Time
+	inFlight int
+	maxSeen  int
+}
+
+func (rt *echoRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.mu.Lock()
+	rt.starts = append(rt.starts, time.Now())
+	rt.inFlight++
+	if rt.inFlight > rt.maxSeen {
+		rt.maxSeen = rt.inFlight
+	}
+	rt.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+	var results []map[string]interface{}
+	for _, sym := range strings.Split(req.URL.Query().Get("symbols"), ",") {
+		results = append(results, map[string]interface{}{"symbol": sym, "regularMarketPrice": 100.0})
+	}
+
+	rt.mu.Lock()
+	rt.inFlight--
+	rt.mu.Unlock()
+
+	rec := httptest.NewRecorder()
+	rec.WriteString(buildYahooJSON(results))
+	return rec.Result(), nil
+}
+
+func TestFetchQuotes_ConcurrentBatchesPreserveOrder(t *testing.T) {
+	rt := &echoRoundTripper{}
+	client := fetcher.NewWithHTTP(&http.Client{Transport: rt},
+		fetcher.WithConcurrency(4), fetcher.WithRateLimit(0))
+
+	symbols := make([]string, 420) // 9 batches
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("%04d.T", 1000+i)
+	}
+	quotes, err := client.FetchQuotes(context.Background(), makeStocks(symbols...))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(quotes) != len(symbols) {
+		t.Fatalf("want %d quotes, got %d", len(symbols), len(quotes))
+	}
+	for i, q := range quotes {
+		if q.Symbol != symbols[i] || !q.Valid {
+			t.Fatalf("quote %d: got %s (valid=%v), want %s", i, q.Symbol, q.Valid, symbols[i])
+		}
+	}
+	if rt.maxSeen > 4 {
+		t.Errorf("concurrency exceeded: %d in flight", rt.maxSeen)
+	}
+	if len(rt.starts) != 9 {
+		t.Errorf("want 9 requests, got %d", len(rt.starts))
+	}
+}
+
+func TestFetchQuotes_RateLimitSpacesRequests(t *testing.T) {
+	rt := &echoRoundTripper{}
+	client := fetcher.NewWithHTTP(&http.Client{Transport: rt},
+		fetcher.WithConcurrency(8), fetcher.WithRateLimit(50)) // 20ms 間隔
+
+	symbols := make([]string, 200) // 4 batches
+	for i := range symbols {
+		symbols[i] = fmt.Sprintf("%04d.T", 2000+i)
+	}
+	start := time.Now()
+	if _, err := client.FetchQuotes(context.Background(), makeStocks(symbols...)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// 4 requests at 50 req/s need at least 3 intervals of 20ms
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("rate limit not applied: 4 requests finished in %v", elapsed)
+	}
+}
@@ -0,0 +1,332 @@
+// Package history persists quote snapshots so the scanner can look back in
+// time (e.g. "how did this stock move over the last 30 minutes?").
+//
+// Snapshots are stored in an embedded bbolt database: one bucket per symbol,
+// keyed by FetchedAt (big-endian Unix nanoseconds) so range queries are a
+// single ordered cursor scan. Points older than the retention period are
+// pruned automatically.
+package history
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"tse-scanner/model"
+)
+
+const (
+	// DefaultRetention is how long points are kept when Options.Retention is zero.
+	DefaultRetention = 30 * 24 * time.Hour
+
+	pruneEvery  = time.Hour // Append 時に古いデータを削除する間隔
+	openTimeout = time.Second
+)
+
+// ErrLocked is returned by Open when another process holds the database.
+var ErrLocked = errors.New("履歴データベースは他のプロセスが使用中です")
+
+// Point is one stored observation of a stock.
+type Point struct {
+	At            time.Time `json:"-"`
+	Price         float64   `json:"p"`
+	Open          float64   `json:"o,omitempty"`
+	High          float64   `json:"h,omitempty"`
+	Low           float64   `json:"l,omitempty"`
+	Volume        int64     `json:"v,omitempty"`
+	ChangePercent float64   `json:"c,omitempty"`
+	PrevClose     float64   `json:"pc,omitempty"`
+	AvgVolume3M   int64     `json:"av,omitempty"`
+	WeekHigh52    float64   `json:"wh,omitempty"`
+	WeekLow52     float64   `json:"wl,omitempty"`
+}
+
+// Quote rebuilds the snapshot as a model.Quote (name and sector are not stored).
+func (p Point) Quote(symbol string) model.Quote {
+	q := model.Quote{
+		Symbol:        symbol,
+		Price:         p.Price,
+		ChangePercent: p.ChangePercent,
+		Volume:        p.Volume,
+		AvgVolume3M:   p.AvgVolume3M,
+		DayHigh:       p.High,
+		DayLow:        p.Low,
+		Open:          p.Open,
+		PrevClose:     p.PrevClose,
+		WeekHigh52:    p.WeekHigh52,
+		WeekLow52:     p.WeekLow52,
+		FetchedAt:     p.At,
+		Valid:         true,
+	}
+	if p.PrevClose > 0 {
+		q.Change = p.Price - p.PrevClose
+	}
+	return q
+}
+
+// Reader is the query side of Store, used by the analyzer and display.
+type Reader interface {
+	// Range returns the points for symbol with from <= At <= to, oldest first.
+	Range(symbol string, from, to time.Time) ([]Point, error)
+	// Latest returns the newest point for symbol at or before t.
+	Latest(symbol string, t time.Time) (Point, bool, error)
+}
+
+// Options configures a Store.
+type Options struct {
+	// Retention is how long points are kept. Zero means DefaultRetention;
+	// a negative value keeps everything.
+	Retention time.Duration
+
+	// ReadOnly opens an existing database with a shared lock for
+	// inspection; Append and Prune fail. A scanner writing to the same
+	// file still holds an exclusive lock, so Open returns ErrLocked.
+	ReadOnly bool
+}
+
+// Store is a bbolt-backed time series of quotes.
+type Store struct {
+	db        *bolt.DB
+	retention time.Duration
+
+	mu        sync.Mutex
+	lastPrune time.Time
+}
+
+var _ Reader = (*Store)(nil)
+
+// DefaultPath returns the per-user history database path
+// (e.g. ~/.cache/tse-scanner/history.db on Linux).
+func DefaultPath() string {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return filepath.Join(".", "history.db")
+	}
+	return filepath.Join(base, "tse-scanner", "history.db")
+}
+
+// Open opens (creating if needed) the history database at path. It waits
+// briefly for another process's lock and then returns ErrLocked.
+func Open(path string, opts Options) (*Store, error) {
+	if !opts.ReadOnly {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return nil, fmt.Errorf("ディレクトリ作成エラー: %w", err)
+		}
+	}
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: openTimeout, ReadOnly: opts.ReadOnly})
+	if errors.Is(err, bolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", ErrLocked, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("履歴データベースを開けません: %w", err)
+	}
+	retention := opts.Retention
+	if retention == 0 {
+		retention = DefaultRetention
+	}
+	return &Store{db: db, retention: retention}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// Append stores every valid quote under its symbol and FetchedAt.
+// Invalid quotes and quotes without a timestamp are skipped.
+func (s *Store) Append(quotes []model.Quote) error {
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		for _, q := range quotes {
+			if !q.Valid || q.FetchedAt.IsZero() {
+				continue
+			}
+			b, err := tx.CreateBucketIfNotExists([]byte(q.Symbol))
+			if err != nil {
+				return err
+			}
+			v, err := json.Marshal(Point{
+				Price: q.Price, Open: q.Open, High: q.DayHigh, Low: q.DayLow,
+				Volume: q.Volume, ChangePercent: q.ChangePercent, PrevClose: q.PrevClose,
+				AvgVolume3M: q.AvgVolume3M, WeekHigh52: q.WeekHigh52, WeekLow52: q.WeekLow52,
+			})
+			if err != nil {
+				return err
+			}
+			if err := b.Put(key(q.FetchedAt), v); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("履歴書き込みエラー: %w", err)
+	}
+
+	s.mu.Lock()
+	due := s.retention > 0 && time.Since(s.lastPrune) >= pruneEvery
+	if due {
+		s.lastPrune = time.Now()
+	}
+	s.mu.Unlock()
+	if due {
+		if _, err := s.Prune(time.Now().Add(-s.retention)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Range implements Reader.
+func (s *Store) Range(symbol string, from, to time.Time) ([]Point, error) {
+	var points []Point
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(symbol))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		end := key(to)
+		for k, v := c.Seek(key(from)); k != nil && string(k) <= string(end); k, v = c.Next() {
+			p, err := decode(k, v)
+			if err != nil {
+				return err
+			}
+			points = append(points, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("履歴読み込みエラー: %w", err)
+	}
+	return points, nil
+}
+
+// Latest implements Reader.
+func (s *Store) Latest(symbol string, t time.Time) (Point, bool, error) {
+	var (
+		p     Point
+		found bool
+	)
+	err := s.db.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte(symbol))
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		k, v := c.Seek(key(t.Add(1)))
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+		if k == nil {
+			return nil
+		}
+		var err error
+		p, err = decode(k, v)
+		found = err == nil
+		return err
+	})
+	if err != nil {
+		return Point{}, false, fmt.Errorf("履歴読み込みエラー: %w", err)
+	}
+	return p, found, nil
+}
+
+// Each calls fn for every stored point with from <= At <= to, symbol by
+// symbol (each symbol's points oldest first). Returning an error from fn
+// stops the iteration.
+func (s *Store) Each(from, to time.Time, fn func(symbol string, p Point) error) error {
+	return s.db.View(func(tx *bolt.Tx) error {
+		start, end := key(from), string(key(to))
+		return tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			symbol := string(name)
+			c := b.Cursor()
+			for k, v := c.Seek(start); k != nil && string(k) <= end; k, v = c.Next() {
+				p, err := decode(k, v)
+				if err != nil {
+					return err
+				}
+				if err := fn(symbol, p); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	})
+}
+
+// Symbols returns every symbol with stored points, sorted.
+func (s *Store) Symbols() ([]string, error) {
+	var symbols []string
+	err := s.db.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			symbols = append(symbols, string(name))
+			return nil
+		})
+	})
+	return symbols, err
+}
+
+// Prune deletes points older than before and drops emptied symbols.
+// It returns the number of points removed.
+func (s *Store) Prune(before time.Time) (int, error) {
+	removed := 0
+	err := s.db.Update(func(tx *bolt.Tx) error {
+		var empty [][]byte
+		err := tx.ForEach(func(name []byte, b *bolt.Bucket) error {
+			// Collect first: deleting while iterating a cursor can skip keys.
+			var old [][]byte
+			c := b.Cursor()
+			cutoff := string(key(before))
+			for k, _ := c.First(); k != nil && string(k) < cutoff; k, _ = c.Next() {
+				old = append(old, append([]byte(nil), k...))
+			}
+			for _, k := range old {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			removed += len(old)
+			if k, _ := c.First(); k == nil {
+				empty = append(empty, append([]byte(nil), name...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+		for _, name := range empty {
+			if err := tx.DeleteBucket(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("履歴削除エラー: %w", err)
+	}
+	return removed, nil
+}
+
+func key(t time.Time) []byte {
+	k := make([]byte, 8)
+	binary.BigEndian.PutUint64(k, uint64(t.UnixNano()))
+	return k
+}
+
+func decode(k, v []byte) (Point, error) {
+	var p Point
+	if err := json.Unmarshal(v, &p); err != nil {
+		return Point{}, err
+	}
+	p.At = time.Unix(0, int64(binary.BigEndian.Uint64(k)))
+	return p, nil
+}
@@ -0,0 +1,191 @@
+package history_test
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"tse-scanner/history"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var base = time.Date(2024, 6, 14, 9, 0, 0, 0, time.FixedZone("JST", 9*3600))
+
+func openStore(t *testing.T, opts history.Options) *history.Store {
+	t.Helper()
+	s, err := history.Open(filepath.Join(t.TempDir(), "history.db"), opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func quote(symbol string, price float64, at time.Time) model.Quote {
+	return model.Quote{Symbol: symbol, Price: price, Volume: 1000, FetchedAt: at, Valid: true}
+}
+
+// ---- tests ----
+
+func TestAppendAndRange(t *testing.T) {
+	s := openStore(t, history.Options{Retention: -1})
+	for i := 0; i < 5; i++ {
+		at := base.Add(time.Duration(i) * time.Minute)
+		if err := s.Append([]model.Quote{quote("7203.T", 3000+float64(i), at), quote("6758.T", 100, at)}); err != nil {
+			t.Fatalf("Append: %v", err)
+		}
+	}
+
+	points, err := s.Range("7203.T", base.Add(time.Minute), base.Add(3*time.Minute))
+	if err != nil {
+		t.Fatalf("Range: %v", err)
+	}
+	if len(points) != 3 {
+		t.Fatalf("want 3 points (inclusive range), got %d", len(points))
+	}
+	for i, p := range points {
+		if want := 3001 + float64(i); p.Price != want {
+			t.Errorf("point %d: price %v, want %v", i, p.Price, want)
+		}
+		if !p.At.Equal(base.Add(time.Duration(i+1) * time.Minute)) {
+			t.Errorf("point %d: At %v", i, p.At)
+		}
+	}
+	if p, _ := s.Range("9999.T", base, base.Add(time.Hour)); len(p) != 0 {
+		t.Errorf("unknown symbol should have no points, got %d", len(p))
+	}
+}
+
+func TestAppend_SkipsInvalidAndUntimestamped(t *testing.T) {
+	s := openStore(t, history.Options{Retention: -1})
+	invalid := quote("7203.T", 1, base)
+	invalid.Valid = false
+	if err := s.Append([]model.Quote{invalid, quote("6758.T", 1, time.Time{})}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	symbols, _ := s.Symbols()
+	if len(symbols) != 0 {
+		t.Errorf("want no symbols, got %v", symbols)
+	}
+}
+
+func TestLatest(t *testing.T) {
+	s := openStore(t, history.Options{Retention: -1})
+	s.Append([]model.Quote{quote("7203.T", 1, base), quote("7203.T", 2, base.Add(time.Minute))}) //nolint:errcheck
+
+	cases := []struct {
+		at    time.Time
+		price float64
+		found bool
+	}{
+		{base.Add(-time.Second), 0, false},
+		{base, 1, true},
+		{base.Add(30 * time.Second), 1, true},
+		{base.Add(time.Hour), 2, true},
+	}
+	for _, c := range cases {
+		p, ok, err := s.Latest("7203.T", c.at)
+		if err != nil {
+			t.Fatalf("Latest: %v", err)
+		}
+		if ok != c.found || p.Price != c.price {
+			t.Errorf("Latest(%v): got %v/%v, want %v/%v", c.at, p.Price, ok, c.price, c.found)
+		}
+	}
+}
+
+func TestPrune_RemovesOldPointsAndEmptySymbols(t *testing.T) {
+	s := openStore(t, history.Options{Retention: -1})
+	s.Append([]model.Quote{ //nolint:errcheck
+		quote("7203.T", 1, base),
+		quote("7203.T", 2, base.Add(2*time.Hour)),
+		quote("6758.T", 1, base),
+	})
+
+	removed, err := s.Prune(base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("Prune: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed: got %d, want 2", removed)
+	}
+	symbols, _ := s.Symbols()
+	if len(symbols) != 1 || symbols[0] != "7203.T" {
+		t.Errorf("symbols after prune: %v", symbols)
+	}
+}
+
+func TestAppend_AppliesRetention(t *testing.T) {
+	s := openStore(t, history.Options{Retention: time.Hour})
+	now := time.Now()
+	err := s.Append([]model.Quote{quote("7203.T", 1, now.Add(-2*time.Hour)), quote("7203.T", 2, now)})
+	if err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	points, _ := s.Range("7203.T", now.Add(-24*time.Hour), now)
+	if len(points) != 1 || points[0].Price != 2 {
+		t.Errorf("retention not applied: %+v", points)
+	}
+}
+
+func TestOpen_PersistsAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := history.Open(path, history.Options{Retention: -1})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	s.Append([]model.Quote{quote("7203.T", 3200, base)}) //nolint:errcheck
+	s.Close()
+
+	s, err = history.Open(path, history.Options{Retention: -1})
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer s.Close()
+	p, ok, _ := s.Latest("7203.T", base)
+	if !ok || p.Price != 3200 {
+		t.Errorf("point not persisted: %+v ok=%v", p, ok)
+	}
+}
+
+func TestOpen_LockedDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := history.Open(path, history.Options{})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer s.Close()
+	if _, err := history.Open(path, history.Options{}); !errors.Is(err, history.ErrLocked) {
+		t.Errorf("second Open: got %v, want ErrLocked", err)
+	}
+}
+
+func TestOpen_ReadOnly(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.db")
+	s, err := history.Open(path, history.Options{Retention: -1})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := history.Open(path, history.Options{ReadOnly: true}); !errors.Is(err, history.ErrLocked) {
+		t.Errorf("read-only Open while writer is open: got %v, want ErrLocked", err)
+	}
+	if err := s.Append([]model.Quote{quote("7203.T", 2500, base)}); err != nil {
+		t.Fatalf("Append: %v", err)
+	}
+	s.Close()
+
+	r, err := history.Open(path, history.Options{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("read-only Open: %v", err)
+	}
+	defer r.Close()
+	if syms, err := r.Symbols(); err != nil || len(syms) != 1 {
+		t.Errorf("Symbols = %v, %v; want [7203.T]", syms, err)
+	}
+	if err := r.Append([]model.Quote{quote("7203.T", 2510, base.Add(time.Minute))}); err == nil {
+		t.Error("Append on read-only store: want error")
+	}
+}
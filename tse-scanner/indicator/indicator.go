@@ -0,0 +1,277 @@
+// Package indicator computes technical indicators from OHLCV series.
+//
+// Every function returns a slice aligned with its input: element i is the
+// indicator value at bar i, or NaN while the look-back window is still
+// filling (warm-up). Use Last to read the most recent value.
+//
+// Smoothing follows the conventions used by most Japanese charting tools:
+// RSI and ATR use Wilder's smoothing, MACD uses EMAs seeded with an SMA.
+package indicator
+
+import (
+	"math"
+
+	"tse-scanner/calendar"
+	"tse-scanner/model"
+)
+
+// Default periods.
+const (
+	RSIPeriod       = 14
+	MACDFast        = 12
+	MACDSlow        = 26
+	MACDSignal      = 9
+	BollingerPeriod = 20
+	BollingerK      = 2.0
+	ATRPeriod       = 14
+	SMAShort        = 5  // 短期移動平均（日足 5 日）
+	SMALong         = 25 // 長期移動平均（日足 25 日）
+	BetaPeriod      = 60 // ベータの推定に使う日次リターン数（約 3 ヶ月）
+)
+
+// Closes extracts closing prices from bars.
+func Closes(bars []model.Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		out[i] = b.Close
+	}
+	return out
+}
+
+// Last returns the final value of v and whether it is defined (not NaN).
+func Last(v []float64) (float64, bool) {
+	if len(v) == 0 || math.IsNaN(v[len(v)-1]) {
+		return 0, false
+	}
+	return v[len(v)-1], true
+}
+
+func nans(n int) []float64 {
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = math.NaN()
+	}
+	return out
+}
+
+// SMA is the simple moving average over n values.
+func SMA(values []float64, n int) []float64 {
+	out := nans(len(values))
+	if n <= 0 {
+		return out
+	}
+	sum := 0.0
+	for i, v := range values {
+		sum += v
+		if i >= n {
+			sum -= values[i-n]
+		}
+		if i >= n-1 {
+			out[i] = sum / float64(n)
+		}
+	}
+	return out
+}
+
+// EMA is the exponential moving average over n values, seeded with the SMA
+// of the first n values. NaN inputs (e.g. a warm-up prefix) are skipped.
+func EMA(values []float64, n int) []float64 {
+	out := nans(len(values))
+	if n <= 0 {
+		return out
+	}
+	k := 2.0 / float64(n+1)
+	start := 0
+	for start < len(values) && math.IsNaN(values[start]) {
+		start++
+	}
+	if len(values)-start < n {
+		return out
+	}
+	sum := 0.0
+	for _, v := range values[start : start+n] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	out[start+n-1] = prev
+	for i := start + n; i < len(values); i++ {
+		prev = values[i]*k + prev*(1-k)
+		out[i] = prev
+	}
+	return out
+}
+
+// RSI is Wilder's relative strength index over n periods (0–100).
+func RSI(closes []float64, n int) []float64 {
+	out := nans(len(closes))
+	if n <= 0 || len(closes) <= n {
+		return out
+	}
+	var gain, loss float64
+	for i := 1; i <= n; i++ {
+		d := closes[i] - closes[i-1]
+		if d > 0 {
+			gain += d
+		} else {
+			loss -= d
+		}
+	}
+	gain /= float64(n)
+	loss /= float64(n)
+	out[n] = rsi(gain, loss)
+	for i := n + 1; i < len(closes); i++ {
+		d := closes[i] - closes[i-1]
+		g, l := math.Max(d, 0), math.Max(-d, 0)
+		gain = (gain*float64(n-1) + g) / float64(n)
+		loss = (loss*float64(n-1) + l) / float64(n)
+		out[i] = rsi(gain, loss)
+	}
+	return out
+}
+
+func rsi(gain, loss float64) float64 {
+	if loss == 0 {
+		if gain == 0 {
+			return 50
+		}
+		return 100
+	}
+	return 100 - 100/(1+gain/loss)
+}
+
+// MACD returns the MACD line (EMA fast − EMA slow), its signal line and the
+// histogram (MACD − signal).
+func MACD(closes []float64, fast, slow, signal int) (macd, sig, hist []float64) {
+	ef, es := EMA(closes, fast), EMA(closes, slow)
+	macd = nans(len(closes))
+	for i := range closes {
+		macd[i] = ef[i] - es[i] // NaN propagates through warm-up
+	}
+	sig = EMA(macd, signal)
+	hist = nans(len(closes))
+	for i := range closes {
+		hist[i] = macd[i] - sig[i]
+	}
+	return macd, sig, hist
+}
+
+// Bollinger returns the n-period middle band (SMA) and the bands k
+// population standard deviations above and below it.
+func Bollinger(closes []float64, n int, k float64) (mid, upper, lower []float64) {
+	mid = SMA(closes, n)
+	upper, lower = nans(len(closes)), nans(len(closes))
+	for i := n - 1; i < len(closes) && n > 0; i++ {
+		var ss float64
+		for _, v := range closes[i-n+1 : i+1] {
+			ss += (v - mid[i]) * (v - mid[i])
+		}
+		sd := math.Sqrt(ss / float64(n))
+		upper[i], lower[i] = mid[i]+k*sd, mid[i]-k*sd
+	}
+	return mid, upper, lower
+}
+
+// TrueRange returns the true range of each bar (the first bar uses High−Low).
+func TrueRange(bars []model.Bar) []float64 {
+	out := make([]float64, len(bars))
+	for i, b := range bars {
+		tr := b.High - b.Low
+		if i > 0 {
+			pc := bars[i-1].Close
+			tr = math.Max(tr, math.Max(math.Abs(b.High-pc), math.Abs(b.Low-pc)))
+		}
+		out[i] = tr
+	}
+	return out
+}
+
+// ATR is Wilder's average true range over n bars.
+func ATR(bars []model.Bar, n int) []float64 {
+	out := nans(len(bars))
+	if n <= 0 || len(bars) < n {
+		return out
+	}
+	tr := TrueRange(bars)
+	sum := 0.0
+	for _, v := range tr[:n] {
+		sum += v
+	}
+	prev := sum / float64(n)
+	out[n-1] = prev
+	for i := n; i < len(bars); i++ {
+		prev = (prev*float64(n-1) + tr[i]) / float64(n)
+		out[i] = prev
+	}
+	return out
+}
+
+// VWAP is the volume-weighted average of the typical price (H+L+C)/3,
+// accumulated from the first bar of each trading day (JST).
+func VWAP(bars []model.Bar) []float64 {
+	out := nans(len(bars))
+	var pv, vol float64
+	var day string
+	for i, b := range bars {
+		if d := b.Time.In(calendar.JST).Format("2006-01-02"); d != day {
+			day, pv, vol = d, 0, 0
+		}
+		pv += (b.High + b.Low + b.Close) / 3 * float64(b.Volume)
+		vol += float64(b.Volume)
+		if vol > 0 {
+			out[i] = pv / vol
+		}
+	}
+	return out
+}
+
+// Beta is the rolling beta of closes against market over n daily returns:
+// cov(r, m) / var(m). Both series must be aligned bar by bar (same dates);
+// element i uses the returns ending at bar i.
+func Beta(closes, market []float64, n int) []float64 {
+	size := min(len(closes), len(market))
+	out := nans(len(closes))
+	if n < 2 {
+		return out
+	}
+	for i := n; i < size; i++ {
+		var sr, sm, srm, smm, k float64
+		for j := i - n + 1; j <= i; j++ {
+			if closes[j-1] <= 0 || market[j-1] <= 0 {
+				continue // 欠損値は除外する
+			}
+			r := closes[j]/closes[j-1] - 1
+			m := market[j]/market[j-1] - 1
+			sr, sm, srm, smm, k = sr+r, sm+m, srm+r*m, smm+m*m, k+1
+		}
+		if k < 2 {
+			continue
+		}
+		if v := smm/k - (sm/k)*(sm/k); v > 0 {
+			out[i] = (srm/k - (sr/k)*(sm/k)) / v
+		}
+	}
+	return out
+}
+
+// CrossedAbove reports whether a moved from at-or-below b to above b within
+// the last `within` bars (within >= 1; 1 means on the latest bar).
+func CrossedAbove(a, b []float64, within int) bool {
+	n := min(len(a), len(b))
+	for i := n - 1; i >= 1 && i >= n-within; i-- {
+		if a[i] > b[i] && a[i-1] <= b[i-1] {
+			return true
+		}
+	}
+	return false
+}
+
+// CrossedBelow is CrossedAbove with the direction reversed.
+func CrossedBelow(a, b []float64, within int) bool {
+	n := min(len(a), len(b))
+	for i := n - 1; i >= 1 && i >= n-within; i-- {
+		if a[i] < b[i] && a[i-1] >= b[i-1] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,158 @@
+package indicator_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tse-scanner/indicator"
+	"tse-scanner/model"
+)
+
+// ---- helpers ----
+
+var jst = time.FixedZone("JST", 9*60*60)
+
+func approx(t *testing.T, name string, got, want float64) {
+	t.Helper()
+	if math.Abs(got-want) > 1e-6 {
+		t.Errorf("%s: got %.6f, want %.6f", name, got, want)
+	}
+}
+
+func last(t *testing.T, v []float64) float64 {
+	t.Helper()
+	x, ok := indicator.Last(v)
+	if !ok {
+		t.Fatalf("last value undefined: %v", v)
+	}
+	return x
+}
+
+func bar(o, h, l, c float64, vol int64, at time.Time) model.Bar {
+	return model.Bar{Time: at, Open: o, High: h, Low: l, Close: c, Volume: vol}
+}
+
+// ---- tests ----
+
+func TestSMA(t *testing.T) {
+	v := indicator.SMA([]float64{1, 2, 3, 4, 5}, 3)
+	if !math.IsNaN(v[0]) || !math.IsNaN(v[1]) {
+		t.Errorf("warm-up should be NaN: %v", v)
+	}
+	approx(t, "SMA[2]", v[2], 2)
+	approx(t, "SMA[4]", v[4], 4)
+}
+
+func TestEMA_SeededWithSMA(t *testing.T) {
+	v := indicator.EMA([]float64{2, 4, 6, 8}, 3)
+	approx(t, "EMA[2]", v[2], 4) // SMA(2,4,6)
+	approx(t, "EMA[3]", v[3], 6) // 8×0.5 + 4×0.5
+}
+
+func TestRSI_Extremes(t *testing.T) {
+	up := []float64{1, 2, 3, 4, 5, 6}
+	approx(t, "RSI rising", last(t, indicator.RSI(up, 3)), 100)
+	flat := []float64{5, 5, 5, 5, 5}
+	approx(t, "RSI flat", last(t, indicator.RSI(flat, 3)), 50)
+	if _, ok := indicator.Last(indicator.RSI([]float64{1, 2}, 3)); ok {
+		t.Error("RSI should be undefined for short series")
+	}
+}
+
+func TestRSI_WilderSmoothing(t *testing.T) {
+	// 変化: +1, −1, +2 → 平均上昇 1, 平均下落 1/3 → RSI 75
+	closes := []float64{10, 11, 10, 12}
+	approx(t, "RSI", last(t, indicator.RSI(closes, 3)), 75)
+	// 次の変化 −3: gain = (1×2+0)/3, loss = (1/3×2+3)/3
+	closes = append(closes, 9)
+	g, l := 2.0/3, (2.0/3+3)/3
+	approx(t, "RSI next", last(t, indicator.RSI(closes, 3)), 100-100/(1+g/l))
+}
+
+func TestMACD_ConstantSeriesIsZero(t *testing.T) {
+	closes := make([]float64, 40)
+	for i := range closes {
+		closes[i] = 100
+	}
+	macd, sig, hist := indicator.MACD(closes, 12, 26, 9)
+	approx(t, "MACD", last(t, macd), 0)
+	approx(t, "signal", last(t, sig), 0)
+	approx(t, "hist", last(t, hist), 0)
+	if !math.IsNaN(sig[32]) || math.IsNaN(sig[33]) {
+		t.Error("signal line should start at index slow+signal−2")
+	}
+}
+
+func TestBollinger(t *testing.T) {
+	mid, upper, lower := indicator.Bollinger([]float64{2, 4, 4, 4, 5, 5, 7, 9}, 8, 2)
+	approx(t, "mid", last(t, mid), 5)
+	approx(t, "upper", last(t, upper), 9) // σ = 2
+	approx(t, "lower", last(t, lower), 1)
+}
+
+func TestATR(t *testing.T) {
+	day := time.Date(2024, 6, 10, 15, 0, 0, 0, jst)
+	bars := []model.Bar{
+		bar(10, 12, 9, 11, 0, day),
+		bar(11, 13, 10, 12, 0, day.AddDate(0, 0, 1)), // TR 3
+		bar(15, 16, 14, 15, 0, day.AddDate(0, 0, 2)), // ギャップ: TR = 16−12 = 4
+	}
+	tr := indicator.TrueRange(bars)
+	approx(t, "TR[2]", tr[2], 4)
+	approx(t, "ATR", last(t, indicator.ATR(bars, 3)), (3.0+3+4)/3)
+}
+
+func TestVWAP_ResetsEachDay(t *testing.T) {
+	d1 := time.Date(2024, 6, 13, 14, 0, 0, 0, jst)
+	d2 := time.Date(2024, 6, 14, 9, 0, 0, 0, jst)
+	bars := []model.Bar{
+		bar(0, 30, 30, 30, 100, d1),
+		bar(0, 10, 10, 10, 100, d2),
+		bar(0, 20, 20, 20, 300, d2.Add(5*time.Minute)),
+	}
+	v := indicator.VWAP(bars)
+	approx(t, "VWAP day1", v[0], 30)
+	approx(t, "VWAP day2", v[2], (10.0*100+20*300)/400)
+}
+
+func TestCrossedAbove(t *testing.T) {
+	a := []float64{1, 2, 3, 4}
+	b := []float64{3, 3, 3, 3}
+	if !indicator.CrossedAbove(a, b, 1) {
+		t.Error("a crosses above b on the last bar")
+	}
+	a = []float64{1, 4, 5, 6}
+	if indicator.CrossedAbove(a, b, 1) {
+		t.Error("cross two bars ago should not count with within=1")
+	}
+	if !indicator.CrossedAbove(a, b, 3) {
+		t.Error("cross within 3 bars should count")
+	}
+	if indicator.CrossedBelow(a, b, 3) {
+		t.Error("no downward cross")
+	}
+	nan := []float64{math.NaN(), 4, 4, 4}
+	if indicator.CrossedAbove(nan, b, 3) {
+		t.Error("NaN warm-up should never count as a cross")
+	}
+}
+
+func TestBeta(t *testing.T) {
+	market := []float64{100}
+	stock := []float64{50}
+	for i, m := range []float64{0.01, -0.02, 0.015, 0.005, -0.01, 0.02} {
+		market = append(market, market[i]*(1+m))
+		stock = append(stock, stock[i]*(1+2*m)) // 指数の 2 倍動く
+	}
+	beta := indicator.Beta(stock, market, 5)
+	if !math.IsNaN(beta[4]) {
+		t.Errorf("warm-up: got %v, want NaN", beta[4])
+	}
+	approx(t, "beta", last(t, beta), 2)
+
+	flat := []float64{100, 100, 100, 100}
+	if _, ok := indicator.Last(indicator.Beta(flat, flat, 3)); ok {
+		t.Error("beta against a flat market is undefined")
+	}
+}
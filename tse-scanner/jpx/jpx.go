@@ -0,0 +1,275 @@
+// Package jpx imports the JPX listed-company spreadsheet (東証上場銘柄一覧).
+//
+// JPX publishes the full list of listed issues as data_j.xls. Each row carries
+// the code, Japanese name, market/product category (市場・商品区分) and the
+// 33-industry classification:
+//
+//	日付, コード, 銘柄名, 市場・商品区分, 33業種コード, 33業種区分, 17業種コード, ...
+//
+// Only ordinary shares on the Prime, Standard and Growth segments are
+// imported; ETFs, REITs, PRO Market and other products are skipped.
+// The file is read locally; this package never downloads it.
+package jpx
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/extrame/xls"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/transform"
+
+	"tse-scanner/model"
+)
+
+// maxRows bounds how many spreadsheet rows are read (the list has ~4,400).
+const maxRows = 20000
+
+// Filter restricts which issues are imported. Empty fields match everything.
+type Filter struct {
+	Segments []model.Segment
+	// Sectors matches either the 33-industry code ("3650") or its name ("電気機器").
+	Sectors []string
+}
+
+func (f Filter) match(s model.Stock) bool {
+	if len(f.Segments) > 0 {
+		ok := false
+		for _, seg := range f.Segments {
+			ok = ok || s.Segment == seg
+		}
+		if !ok {
+			return false
+		}
+	}
+	if len(f.Sectors) > 0 {
+		ok := false
+		for _, sec := range f.Sectors {
+			ok = ok || sec == s.SectorCode || sec == s.Sector
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseSegments parses a comma-separated segment list such as
+// "prime,growth" or "プライム,グロース".
+func ParseSegments(s string) ([]model.Segment, error) {
+	var segs []model.Segment
+	for _, v := range strings.Split(s, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "":
+			continue
+		case "prime", string(model.SegmentPrime):
+			segs = append(segs, model.SegmentPrime)
+		case "standard", string(model.SegmentStandard):
+			segs = append(segs, model.SegmentStandard)
+		case "growth", string(model.SegmentGrowth):
+			segs = append(segs, model.SegmentGrowth)
+		default:
+			return nil, fmt.Errorf("未知の市場区分です: %q（prime / standard / growth）", v)
+		}
+	}
+	return segs, nil
+}
+
+// Load reads a JPX listed-company file (.xls or .csv) and returns the issues
+// matching f, in file order.
+func Load(path string, f Filter) ([]model.Stock, error) {
+	var (
+		records [][]string
+		err     error
+	)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xls":
+		records, err = readXLS(path)
+	case ".csv":
+		var file *os.File
+		file, err = os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("上場銘柄一覧を開けません: %w", err)
+		}
+		defer file.Close()
+		records, err = ReadCSV(file)
+	default:
+		return nil, fmt.Errorf("未対応のファイル形式です: %s（.xls / .csv）", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return Parse(records, f)
+}
+
+// ReadCSV reads a CSV export of the list. Shift_JIS (as saved by Excel) and
+// UTF-8 (with or without BOM) are both accepted.
+func ReadCSV(r io.Reader) ([][]string, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("読み込みエラー: %w", err)
+	}
+	b = bytes.TrimPrefix(b, []byte("\xef\xbb\xbf"))
+	if !utf8.Valid(b) {
+		b, _, err = transform.Bytes(japanese.ShiftJIS.NewDecoder(), b)
+		if err != nil {
+			return nil, fmt.Errorf("Shift_JIS デコードエラー: %w", err)
+		}
+	}
+	cr := csv.NewReader(bytes.NewReader(b))
+	cr.FieldsPerRecord = -1
+	records, err := cr.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("CSV パースエラー: %w", err)
+	}
+	return records, nil
+}
+
+// readXLS reads every row of the first sheet of a BIFF (.xls) workbook.
+func readXLS(path string) (records [][]string, err error) {
+	// The xls reader panics on some malformed inputs; surface those as errors.
+	defer func() {
+		if r := recover(); r != nil {
+			records, err = nil, fmt.Errorf("XLS パースエラー: %v", r)
+		}
+	}()
+	wb, err := xls.Open(path, "utf-8")
+	if err != nil {
+		return nil, fmt.Errorf("XLS を開けません: %w", err)
+	}
+	if wb == nil || wb.NumSheets() == 0 {
+		return nil, errors.New("XLS にワークシートがありません")
+	}
+	// ReadAllCells は全シートを連結するため、先頭シートだけを読む
+	ws := wb.GetSheet(0)
+	for i := 0; i <= int(ws.MaxRow) && i < maxRows; i++ {
+		row := sheetRow(ws, i)
+		if row == nil {
+			records = append(records, nil)
+			continue
+		}
+		cells := make([]string, row.LastCol()) // LastCol は最終列の次
+		for c := range cells {
+			cells[c] = row.Col(c)
+		}
+		records = append(records, cells)
+	}
+	return records, nil
+}
+
+// sheetRow returns row i of ws, or nil if the sheet has no such row
+// (WorkSheet.Row panics on a missing row).
+func sheetRow(ws *xls.WorkSheet, i int) (row *xls.Row) {
+	defer func() {
+		if recover() != nil {
+			row = nil
+		}
+	}()
+	return ws.Row(i)
+}
+
+// columns holds the index of each field we use, located by header name.
+type columns struct {
+	code, name, market, sectorCode, sector int
+}
+
+func findColumns(header []string) (columns, error) {
+	c := columns{code: -1, name: -1, market: -1, sectorCode: -1, sector: -1}
+	for i, h := range header {
+		switch strings.TrimSpace(h) {
+		case "コード":
+			c.code = i
+		case "銘柄名":
+			c.name = i
+		case "市場・商品区分":
+			c.market = i
+		case "33業種コード":
+			c.sectorCode = i
+		case "33業種区分":
+			c.sector = i
+		}
+	}
+	if c.code < 0 || c.name < 0 || c.market < 0 {
+		return c, errors.New("見出し行に コード / 銘柄名 / 市場・商品区分 が見つかりません")
+	}
+	return c, nil
+}
+
+// Parse converts spreadsheet rows (header first) into stocks matching f.
+// Blank rows are ignored; non-equity products are skipped.
+func Parse(records [][]string, f Filter) ([]model.Stock, error) {
+	start := -1
+	var cols columns
+	for i, rec := range records {
+		if c, err := findColumns(rec); err == nil {
+			start, cols = i+1, c
+			break
+		}
+	}
+	if start < 0 {
+		return nil, errors.New("見出し行に コード / 銘柄名 / 市場・商品区分 が見つかりません")
+	}
+
+	stocks := make([]model.Stock, 0, len(records)-start)
+	for _, rec := range records[start:] {
+		code := normalizeCode(field(rec, cols.code))
+		if code == "" {
+			continue
+		}
+		seg, ok := segmentOf(field(rec, cols.market))
+		if !ok {
+			continue
+		}
+		s := model.Stock{
+			Symbol:     code + ".T",
+			Name:       strings.TrimSpace(field(rec, cols.name)),
+			Sector:     strings.TrimSpace(field(rec, cols.sector)),
+			Segment:    seg,
+			SectorCode: normalizeCode(field(rec, cols.sectorCode)),
+		}
+		if f.match(s) {
+			stocks = append(stocks, s)
+		}
+	}
+	return stocks, nil
+}
+
+func field(rec []string, i int) string {
+	if i < 0 || i >= len(rec) {
+		return ""
+	}
+	return rec[i]
+}
+
+// segmentOf maps 市場・商品区分 such as "プライム（内国株式）" to a Segment.
+// It reports false for ETFs, REITs, PRO Market and other non-equity products.
+func segmentOf(market string) (model.Segment, bool) {
+	market = strings.TrimSpace(market)
+	for _, seg := range []model.Segment{model.SegmentPrime, model.SegmentStandard, model.SegmentGrowth} {
+		if strings.HasPrefix(market, string(seg)) {
+			return seg, true
+		}
+	}
+	return "", false
+}
+
+// normalizeCode turns spreadsheet numbers such as "7203.0" into "7203".
+// Alphanumeric codes ("130A") and "-" placeholders are returned trimmed.
+func normalizeCode(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "-" {
+		return ""
+	}
+	if f, err := strconv.ParseFloat(v, 64); err == nil && f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return strings.ToUpper(v)
+}
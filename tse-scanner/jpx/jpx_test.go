@@ -0,0 +1,127 @@
+package jpx_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/text/encoding/japanese"
+
+	"tse-scanner/jpx"
+	"tse-scanner/model"
+)
+
+// sampleCSV mirrors the column layout of JPX data_j.xls.
+const sampleCSV = `日付,コード,銘柄名,市場・商品区分,33業種コード,33業種区分,17業種コード,17業種区分,規模コード,規模区分
+20260930,1305,ｉＦｒｅｅＥＴＦ　ＴＯＰＩＸ（年１回決算型）,ETF・ETN,-,-,-,-,-,-
+20260930,1332,ニッスイ,プライム（内国株式）,50,水産・農林業,1,食品,6,TOPIX Small 1
+20260930,7203.0,トヨタ自動車,プライム（内国株式）,3700,輸送用機器,6,自動車・輸送機,1,TOPIX Core30
+20260930,130A,Veritas In Silico,グロース（内国株式）,3250,医薬品,5,医薬品,-,-
+20260930,8955,日本プライムリアルティ投資法人,REIT・ベンチャーファンド・カントリーファンド・インフラファンド,-,-,-,-,-,-
+20260930,3399,丸千代山岡家,スタンダード（内国株式）,6100,小売業,14,小売,-,-
+,,,,,,,,,
+20260930,9999,テストPRO,PRO Market,9050,サービス業,10,情報通信・サービスその他,-,-
+`
+
+func writeCSV(t *testing.T, body []byte) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "data_j.csv")
+	if err := os.WriteFile(p, body, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func symbols(stocks []model.Stock) string {
+	s := make([]string, len(stocks))
+	for i, st := range stocks {
+		s[i] = st.Symbol
+	}
+	return strings.Join(s, ",")
+}
+
+func TestLoad_CSV_SkipsNonEquityProducts(t *testing.T) {
+	stocks, err := jpx.Load(writeCSV(t, []byte(sampleCSV)), jpx.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got, want := symbols(stocks), "1332.T,7203.T,130A.T,3399.T"; got != want {
+		t.Errorf("symbols: got %s, want %s", got, want)
+	}
+	toyota := stocks[1]
+	if toyota.Name != "トヨタ自動車" || toyota.Segment != model.SegmentPrime ||
+		toyota.SectorCode != "3700" || toyota.Sector != "輸送用機器" {
+		t.Errorf("unexpected stock: %+v", toyota)
+	}
+}
+
+func TestLoad_CSV_ShiftJIS(t *testing.T) {
+	sjis, err := japanese.ShiftJIS.NewEncoder().String(sampleCSV)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stocks, err := jpx.Load(writeCSV(t, []byte(sjis)), jpx.Filter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(stocks) != 4 || stocks[0].Name != "ニッスイ" {
+		t.Errorf("Shift_JIS not decoded: %+v", stocks)
+	}
+}
+
+func TestLoad_FilterBySegmentAndSector(t *testing.T) {
+	path := writeCSV(t, []byte(sampleCSV))
+
+	prime, _ := jpx.Load(path, jpx.Filter{Segments: []model.Segment{model.SegmentPrime}})
+	if got := symbols(prime); got != "1332.T,7203.T" {
+		t.Errorf("prime: got %s", got)
+	}
+
+	byCode, _ := jpx.Load(path, jpx.Filter{Sectors: []string{"3250", "小売業"}})
+	if got := symbols(byCode); got != "130A.T,3399.T" {
+		t.Errorf("sector code/name: got %s", got)
+	}
+
+	both, _ := jpx.Load(path, jpx.Filter{
+		Segments: []model.Segment{model.SegmentGrowth},
+		Sectors:  []string{"3700"},
+	})
+	if len(both) != 0 {
+		t.Errorf("want no match, got %s", symbols(both))
+	}
+}
+
+func TestParse_MissingHeader(t *testing.T) {
+	_, err := jpx.Parse([][]string{{"a", "b"}, {"1", "2"}}, jpx.Filter{})
+	if err == nil {
+		t.Error("want error without JPX header row")
+	}
+}
+
+func TestLoad_UnsupportedAndBrokenFiles(t *testing.T) {
+	dir := t.TempDir()
+	txt := filepath.Join(dir, "data_j.txt")
+	os.WriteFile(txt, []byte(sampleCSV), 0o644)
+	if _, err := jpx.Load(txt, jpx.Filter{}); err == nil {
+		t.Error("want error for .txt")
+	}
+	broken := filepath.Join(dir, "data_j.xls")
+	os.WriteFile(broken, []byte("not an excel file"), 0o644)
+	if _, err := jpx.Load(broken, jpx.Filter{}); err == nil {
+		t.Error("want error for corrupt .xls")
+	}
+}
+
+func TestParseSegments(t *testing.T) {
+	segs, err := jpx.ParseSegments("prime, グロース")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(segs) != 2 || segs[0] != model.SegmentPrime || segs[1] != model.SegmentGrowth {
+		t.Errorf("unexpected segments: %v", segs)
+	}
+	if _, err := jpx.ParseSegments("mothers"); err == nil {
+		t.Error("want error for unknown segment")
+	}
+}
@@ -5,15 +5,29 @@ import (
 	"flag"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"tse-scanner/alert"
 	"tse-scanner/analyzer"
+	"tse-scanner/calendar"
+	"tse-scanner/dashboard"
 	"tse-scanner/display"
+	"tse-scanner/events"
 	"tse-scanner/fetcher"
+	"tse-scanner/history"
+	"tse-scanner/jpx"
 	"tse-scanner/model"
+	"tse-scanner/news"
+	"tse-scanner/paper"
+	"tse-scanner/portfolio"
+	"tse-scanner/sector"
+	"tse-scanner/tui"
+	"tse-scanner/watchlist"
 )
 
 // バージョン情報は make build 時に -ldflags で注入される
@@ -24,11 +38,66 @@ var (
 )
 
 func main() {
+	if len(os.Args) > 1 {
+		var run func([]string) error
+		switch os.Args[1] {
+		case "watchlist":
+			run = runWatchlist
+		case "history":
+			run = runHistory
+		case "backtest":
+			run = runBacktest
+		case "paper":
+			run = runPaper
+		}
+		if run != nil {
+			if err := run(os.Args[2:]); err != nil {
+				log.Fatal(err)
+			}
+			return
+		}
+	}
+
 	var (
 		interval     = flag.Duration("interval", 60*time.Second, "更新間隔（例: 30s, 1m, 5m）")
 		minScore     = flag.Float64("min-score", 20.0, "表示する最小急騰スコア（0–100）")
 		topN         = flag.Int("top", 20, "最大表示件数")
 		showVersion  = flag.Bool("version", false, "バージョン情報を表示")
+		watchlistArg = flag.String("watchlist", watchlist.DefaultName, "ウォッチリスト名またはファイル（CSV/YAML/JSON、カンマ区切りで複数可）")
+		watchlistDir = flag.String("watchlist-dir", watchlist.DefaultDir(), "名前付きウォッチリストの保存ディレクトリ")
+		universe     = flag.String("universe", "", "JPX 上場銘柄一覧ファイル（data_j.xls / CSV）。指定時は -watchlist の代わりに全銘柄を対象にする")
+		segments     = flag.String("segment", "", "-universe の市場区分フィルタ（prime,standard,growth）")
+		sectors      = flag.String("sector", "", "-universe の 33 業種フィルタ（コードまたは業種名、カンマ区切り）")
+		concurrency  = flag.Int("concurrency", 4, "同時に取得するバッチ数")
+		ratePerSec   = flag.Float64("rate", 4, "1 秒あたりの最大リクエスト数（0 で無制限）")
+		providerName = flag.String("provider", "yahoo", "データ取得元（yahoo / stooq / jquants / replay）")
+		replayFile   = flag.String("replay-file", "", "-provider=replay で再生する記録ファイル（NDJSON）")
+		recordFile   = flag.String("record", "", "取得したスナップショットを NDJSON で追記するファイル")
+		historyPath  = flag.String("history", "", "スナップショットを保存する履歴データベース（例: "+history.DefaultPath()+"。実行中は排他ロックされる）")
+		indicators   = flag.Bool("indicators", false, "テクニカル指標（RSI / MACD / ボリンジャー / VWAP / 移動平均 / ATR）をスコアに加える")
+		indicatorTop = flag.Int("indicator-top", 50, "-indicators で足データを取得する上位銘柄数")
+		alertsPath   = flag.String("alerts", "", "アラートルールと通知チャネルの設定ファイル（YAML）")
+		outputFormat = flag.String("output", string(display.FormatTerminal), "出力形式（terminal / table / json / ndjson / csv）")
+		outputFile   = flag.String("output-file", "", "結果を書き出すファイル（既定は標準出力、既存ファイルには追記）")
+		once         = flag.Bool("once", false, "1 回だけスキャンして終了する（cron やパイプライン向け）")
+		minCoverage  = flag.Float64("min-coverage", 90, "-once で終了コード 2 とする取得率の下限（%、0 で無効）")
+		calendarPath = flag.String("calendar", "", "追加の休業日・半日立会ファイル（YAML）。組み込みの JPX 休業日に追加される")
+		marketHours  = flag.Bool("market-hours", true, "取引時間外（夜間・休場日・昼休み）はスキャンを休止する")
+		tuiMode      = flag.Bool("tui", false, "対話型のフルスクリーン表示（並べ替え・業種絞り込み・詳細表示）")
+		serveAddr    = flag.String("serve", "", "Web ダッシュボードを起動するアドレス（例: :8080）。指定時は端末表示の代わりに HTTP で配信する")
+		retention    = flag.Duration("retention", history.DefaultRetention, "履歴の保持期間（例: 72h, 720h。負の値で無期限）")
+		profilePath  = flag.String("profile", "", "スコアリングプロファイル（YAML / JSON）。未指定時は標準プロファイル")
+		momentumWin  = flag.Duration("momentum-window", analyzer.DefaultMomentumWindow, "短期モメンタムを測る期間（-history 有効時）")
+		modeArg      = flag.String("mode", string(analyzer.ModeSurge), "スキャンの方向（surge: 急騰 / plunge: 急落 / both: 両方）")
+		indexArg     = flag.String("index", "1306.T", "比較対象の指数（カンマ区切り、先頭をスコアのベンチマークに使う。空文字で無効。^N225 などは yahoo のみ）")
+		sectorWin    = flag.Duration("sector-window", sector.DefaultWindow, "業種の資金流入（強さの変化）を測る期間")
+		eventsArg    = flag.String("events", "", "決算発表・適時開示・権利落ち・株式分割のイベントファイル（CSV、カンマ区切りで複数可）")
+		newsArg      = flag.String("news", "", "ニュースフィード（name=URL またはファイル、カンマ区切り。例: kabutan=https://example.com/rss.xml）")
+		newsInterval = flag.Duration("news-interval", news.DefaultInterval, "ニュースフィードの取得間隔")
+		portfolioArg = flag.String("portfolio", "", "保有銘柄ファイル（CSV / YAML）。評価損益を表示し、利確・損切ライン到達を通知する")
+		paperMode    = flag.Bool("paper", false, "スキャン結果で仮想売買する（ペーパートレード、結果は tse-scanner paper で確認）")
+		paperAccount = flag.String("paper-account", paper.DefaultPath(), "ペーパートレードの仮想口座ファイル（JSON）")
+		paperRules   = flag.String("paper-rules", "", "ペーパートレードの売買ルール（YAML）。未指定時は標準ルール")
 	)
 	flag.Parse()
 
@@ -37,54 +106,494 @@ func main() {
 		return
 	}
 
-	if *interval < 10*time.Second {
+	format, err := display.ParseFormat(*outputFormat)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *once && *serveAddr != "" {
+		log.Fatal("-once と -serve は同時に指定できません")
+	}
+	if *tuiMode && (*once || *serveAddr != "" || *outputFile != "" || format != display.FormatTerminal) {
+		log.Fatal("-tui は -once / -serve / -output / -output-file と同時に指定できません")
+	}
+	if *interval < 10*time.Second && *providerName != "replay" && !*once {
 		log.Fatal("interval は 10 秒以上に設定してください（レート制限回避のため）")
 	}
 
-	watchlist := model.DefaultWatchlist()
-	client := fetcher.New()
+	if *calendarPath != "" {
+		cal, err := calendar.Load(*calendarPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		calendar.SetDefault(cal)
+	}
+
+	out, closeOut, err := openOutput(format, *outputFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer closeOut()
+
+	stocks, err := loadStocks(*universe, *segments, *sectors, *watchlistDir, *watchlistArg)
+	if err != nil {
+		log.Fatalf("ウォッチリスト読み込みエラー: %v", err)
+	}
+	mode, err := analyzer.ParseMode(*modeArg)
+	if err != nil {
+		log.Fatal(err)
+	}
+	profile := analyzer.DefaultProfile()
+	if *profilePath != "" {
+		if profile, err = analyzer.LoadProfile(*profilePath); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	provider, err := newProvider(*providerName, *replayFile,
+		fetcher.WithConcurrency(*concurrency), fetcher.WithRateLimit(*ratePerSec))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *recordFile != "" {
+		f, err := os.OpenFile(*recordFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+		if err != nil {
+			log.Fatalf("記録ファイルを開けません: %v", err)
+		}
+		defer f.Close()
+		provider = fetcher.NewRecorder(provider, f, stocks...) // 指数・保有のみの銘柄は記録しない
+	}
+
+	var hist *history.Store
+	if *historyPath != "" {
+		hist, err = history.Open(*historyPath, history.Options{Retention: *retention})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer hist.Close()
+	}
+
+	var book *events.Book
+	if *eventsArg != "" {
+		if book, err = events.Load(splitList(*eventsArg)...); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var desk *news.Desk
+	if *newsArg != "" {
+		feeds, err := news.ParseFeeds(*newsArg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		desk = news.NewDesk(feeds, stocks, news.WithInterval(*newsInterval))
+	}
+
+	var held *portfolio.Portfolio
+	if *portfolioArg != "" {
+		p, err := portfolio.Load(*portfolioArg)
+		if err != nil {
+			log.Fatal(err)
+		}
+		held = &p
+	}
+
+	var trader *paper.Trader
+	if *paperMode {
+		cfg := paper.DefaultConfig()
+		if *paperRules != "" {
+			if cfg, err = paper.LoadConfig(*paperRules); err != nil {
+				log.Fatal(err)
+			}
+		}
+		acct, err := paper.LoadAccount(*paperAccount, cfg.InitialCash)
+		if err != nil {
+			log.Fatal(err)
+		}
+		trader = paper.New(cfg, acct)
+	}
+
+	var alerts *alert.Engine
+	if *alertsPath != "" {
+		cfg, err := alert.LoadConfig(*alertsPath)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if alerts, err = alert.NewFromConfig(cfg); err != nil {
+			log.Fatal(err)
+		}
+	} else if held != nil {
+		// 通知チャネルがなくても利確・損切の到達はログに残す（クールダウンは共通）
+		alerts, _ = alert.New(alert.Config{}, nil)
+	}
+
+	var bars *barSource
+	if *indicators {
+		// 足データは -provider に関わらず Yahoo Finance のチャート API から取得する
+		bars = newBarSource(fetcher.New(fetcher.WithConcurrency(*concurrency), fetcher.WithRateLimit(*ratePerSec)))
+	}
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	var dash *dashboard.Server
+	if *serveAddr != "" {
+		var reader history.Reader
+		if hist != nil {
+			reader = hist
+		}
+		dash = dashboard.New(reader)
+		srv := &http.Server{Addr: *serveAddr, Handler: dash.Handler()}
+		go func() {
+			if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Printf("ダッシュボードエラー: %v", err)
+				cancel()
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			shutdownCtx, done := context.WithTimeout(context.Background(), 5*time.Second)
+			defer done()
+			srv.Shutdown(shutdownCtx) //nolint:errcheck
+		}()
+		log.Printf("ダッシュボード: http://%s/", displayAddr(*serveAddr))
+	}
+
+	var ui *tui.Program
+	if *tuiMode {
+		var reader history.Reader
+		if hist != nil {
+			reader = hist
+		}
+		ui = tui.NewProgram(ctx, tui.New(reader))
+		// ログは画面を崩さないよう状態行に表示する
+		log.SetOutput(ui)
+		defer log.SetOutput(os.Stderr)
+	}
+
 	// Graceful shutdown on SIGINT / SIGTERM
 	sig := make(chan os.Signal, 1)
 	signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
 	go func() {
 		<-sig
-		fmt.Println("\n終了中...")
+		fmt.Fprintln(os.Stderr, "\n終了中...")
 		cancel()
 	}()
 
-	scan := func() {
-		quotes, err := client.FetchQuotes(ctx, watchlist)
+	rotation := sector.NewTracker(*sectorWin)
+	indices := indexStocks(*indexArg)
+	var heldOnly []model.Stock // ウォッチリスト外の保有銘柄（評価額のためだけに取得する）
+	if held != nil {
+		heldOnly = missingStocks(held.Stocks(), stocks)
+	}
+	fetchList := append(append(append([]model.Stock{}, stocks...), heldOnly...), indices...)
+
+	// scan runs one scan and returns its fetch report (nil if the fetch failed).
+	scan := func() *model.ScanReport {
+		quotes, report, err := fetcher.Fetch(ctx, provider, fetchList)
 		if err != nil {
 			if ctx.Err() != nil {
-				return
+				return nil
+			}
+			log.Printf("データ取得エラー（%s）: %v", provider.Name(), err)
+			return nil
+		}
+		if rec, ok := provider.(*fetcher.Recorder); ok {
+			if err := rec.Err(); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+		// 保有銘柄と指数は fetchList の末尾に付けて取得し、ここで銘柄と分ける
+		var summary *portfolio.Summary
+		if held != nil {
+			s := held.Valuate(quotes[:len(stocks)+len(heldOnly)])
+			summary = &s
+		}
+		quotes, indexQuotes := quotes[:len(stocks)], quotes[len(stocks)+len(heldOnly):]
+		report.Restrict(quotes) // 取得率はウォッチリストの銘柄だけで判定する（保有のみの銘柄・指数は除く）
+		if hist != nil {
+			// 指数・保有のみの銘柄を保存すると backtest で急騰候補として再生されるため除く
+			if err := hist.Append(quotes); err != nil {
+				log.Printf("%v", err)
 			}
-			log.Printf("データ取得エラー: %v", err)
-			return
 		}
 
-		candidates := analyzer.Analyze(quotes, *minScore)
+		in := analyzer.Inputs{Window: *momentumWin, Mode: mode, Events: book}
+		in.Sectors = rotation.Update(time.Now(), sector.Aggregate(quotes))
+		if len(indexQuotes) > 0 {
+			in.Benchmark = indexQuotes[0]
+		}
+		if hist != nil {
+			in.History = hist
+		}
+		// アラートは -min-score 未満の銘柄も対象にするため、全銘柄をスコアリングする
+		scored := profile.AnalyzeWith(quotes, 0, in)
+		if bars != nil {
+			// 足データの取得は重いため、指標なしのスコア上位銘柄に絞る
+			symbols := make([]string, 0, *indicatorTop)
+			for i := 0; i < len(scored) && i < *indicatorTop; i++ {
+				symbols = append(symbols, scored[i].Symbol)
+			}
+			if in.Benchmark.Valid {
+				symbols = append(symbols, in.Benchmark.Symbol) // ベータの推定用
+			}
+			in.Daily, in.Intraday = bars.load(ctx, symbols)
+			scored = profile.AnalyzeWith(quotes, 0, in)
+		}
+		if held != nil {
+			held.MarkHeld(scored)
+		}
+		if alerts != nil {
+			fired := alerts.Evaluate(time.Now(), scored)
+			if summary != nil {
+				for _, a := range alerts.EvaluatePositions(time.Now(), summary.Hits()) {
+					if len(a.Channels) == 0 {
+						log.Print(a.Message)
+						continue
+					}
+					fired = append(fired, a)
+				}
+			}
+			if len(fired) > 0 {
+				dispatch := func() {
+					if err := alerts.Dispatch(ctx, fired); err != nil {
+						log.Printf("アラート通知エラー: %v", err)
+					}
+				}
+				// -once では終了前に通知を送り切る
+				if *once {
+					dispatch()
+				} else {
+					go dispatch()
+				}
+			}
+		}
+
+		if trader != nil {
+			// 仮想売買は表示件数に関わらず全候補から選ぶ
+			for _, f := range trader.Step(time.Now(), quotes, scored) {
+				log.Print(f)
+			}
+			if err := trader.Account().Save(*paperAccount); err != nil {
+				log.Printf("%v", err)
+			}
+		}
+
+		candidates := aboveScore(scored, *minScore)
 		if len(candidates) > *topN {
 			candidates = candidates[:*topN]
 		}
-		display.Render(candidates, time.Now(), *interval, len(watchlist))
+		if desk != nil {
+			if err := desk.Refresh(ctx, time.Now()); err != nil {
+				log.Printf("%v", err)
+			}
+			desk.Attach(candidates)
+		}
+		if dash != nil {
+			ds := dashboard.NewScan(time.Now(), len(stocks), scored, candidates)
+			ds.Report, ds.Portfolio = report, summary
+			if err := dash.Publish(ds); err != nil {
+				log.Printf("%v", err)
+			}
+			log.Printf("スキャン完了: 候補 %d 件 / %d 銘柄（取得率 %.1f%%）",
+				len(candidates), len(stocks), report.Coverage()*100)
+			return report
+		}
+		if ui != nil {
+			ui.Publish(tui.Scan{At: time.Now(), Universe: len(stocks), Candidates: candidates, Report: report, Portfolio: summary})
+			return report
+		}
+		next := *interval
+		if *once {
+			next = 0
+		}
+		s := display.Scan{At: time.Now(), Universe: len(stocks), Candidates: candidates, Report: report,
+			Sectors: in.Sectors, Indices: indexQuotes, Mode: string(mode), Portfolio: summary}
+		if err := out.Write(s, next); err != nil {
+			log.Printf("出力エラー: %v", err)
+		}
+		return report
 	}
 
-	scan() // 初回即時実行
+	if *once {
+		// 取得に失敗した銘柄が多い結果を正常終了として扱わない（cron での検知用）
+		report := scan()
+		code := 0
+		switch {
+		case report == nil:
+			code = 1
+		case report.Coverage()*100 < *minCoverage:
+			log.Printf("取得率 %.1f%% が下限 %.1f%% を下回りました（失敗 %d 銘柄）",
+				report.Coverage()*100, *minCoverage, len(report.Failures))
+			code = 2
+		}
+		if code != 0 {
+			// os.Exit は defer を実行しないため、出力と履歴を先に閉じる
+			closeOut()
+			if hist != nil {
+				hist.Close()
+			}
+			os.Exit(code)
+		}
+		return
+	}
 
-	ticker := time.NewTicker(*interval)
-	defer ticker.Stop()
+	// 再生データは取引時間と無関係なので休止しない
+	sleepOffHours := *marketHours && *providerName != "replay"
 
-	for {
-		select {
-		case <-ticker.C:
+	loop := func() {
+		for {
+			start := time.Now()
 			scan()
-		case <-ctx.Done():
-			fmt.Println("終了しました。")
-			return
+
+			wait := max(*interval-time.Since(start), 0)
+			if now := time.Now(); sleepOffHours {
+				// 時間外のスキャンは引け値の取得を兼ねて 1 回だけ行い、次の立会まで休止する
+				if next := calendar.Default().NextTrading(now); next.After(now) {
+					wait = next.Sub(now)
+					log.Printf("取引時間外のため %s まで休止します", next.In(calendar.JST).Format("01/02 15:04"))
+				}
+			}
+
+			timer := time.NewTimer(wait)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			}
+		}
+	}
+
+	if ui != nil {
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			loop()
+		}()
+		err := ui.Run()
+		cancel()
+		<-done
+		if err != nil {
+			log.SetOutput(os.Stderr)
+			log.Fatalf("TUI エラー: %v", err)
+		}
+		return
+	}
+
+	loop()
+	fmt.Fprintln(os.Stderr, "終了しました。")
+}
+
+// aboveScore returns the candidates scoring at least minScore. scored must be
+// sorted by SurgeScore descending, as returned by the analyzer.
+func aboveScore(scored []model.Candidate, minScore float64) []model.Candidate {
+	for i, c := range scored {
+		if c.SurgeScore < minScore {
+			return scored[:i]
+		}
+	}
+	return scored
+}
+
+// openOutput returns the result writer for -output / -output-file and a func
+// closing the file. Appending csv to a non-empty file omits the header.
+func openOutput(format display.Format, path string) (*display.Writer, func(), error) {
+	if path == "" {
+		return display.NewWriter(os.Stdout, format), func() {}, nil
+	}
+	if format == display.FormatTerminal {
+		return nil, nil, fmt.Errorf("-output-file には -output=table / json / ndjson / csv を指定してください")
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, nil, fmt.Errorf("出力ファイルを開けません: %w", err)
+	}
+	w := display.NewWriter(f, format)
+	if st, err := f.Stat(); err == nil && st.Size() > 0 {
+		w.OmitHeader()
+	}
+	return w, func() { f.Close() }, nil
+}
+
+// displayAddr turns a listen address such as ":8080" into a browsable host:port.
+func displayAddr(addr string) string {
+	if strings.HasPrefix(addr, ":") {
+		return "localhost" + addr
+	}
+	return addr
+}
+
+// newProvider builds the QuoteProvider selected by -provider.
+func newProvider(name, replayFile string, opts ...fetcher.Option) (fetcher.QuoteProvider, error) {
+	switch name {
+	case "yahoo":
+		return fetcher.New(opts...), nil
+	case "stooq":
+		return fetcher.NewStooq(opts...), nil
+	case "jquants":
+		token := os.Getenv(fetcher.JQuantsTokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("-provider=jquants には環境変数 %s が必要です", fetcher.JQuantsTokenEnv)
 		}
+		return fetcher.NewJQuants(token, opts...), nil
+	case "replay":
+		if replayFile == "" {
+			return nil, fmt.Errorf("-provider=replay には -replay-file が必要です")
+		}
+		return fetcher.NewReplay(replayFile)
+	default:
+		return nil, fmt.Errorf("未知のデータ取得元です: %s（yahoo / stooq / jquants / replay）", name)
+	}
+}
+
+// indexNames labels the usual benchmark symbols; others show their quote name.
+var indexNames = map[string]string{
+	"^N225":  "日経平均",
+	"1306.T": "TOPIX（1306）",
+	"1321.T": "日経225（1321）",
+	"^TOPX":  "TOPIX",
+}
+
+// indexStocks parses -index into stocks fetched alongside the watchlist.
+func indexStocks(spec string) []model.Stock {
+	var stocks []model.Stock
+	for _, sym := range splitList(spec) {
+		stocks = append(stocks, model.Stock{Symbol: sym, Name: indexNames[sym]})
+	}
+	return stocks
+}
+
+// missingStocks returns the stocks of extra that are not in stocks.
+func missingStocks(extra, stocks []model.Stock) []model.Stock {
+	in := make(map[string]bool, len(stocks))
+	for _, s := range stocks {
+		in[s.Symbol] = true
+	}
+	var out []model.Stock
+	for _, s := range extra {
+		if !in[s.Symbol] {
+			out = append(out, s)
+		}
+	}
+	return out
+}
+
+// loadStocks returns the scan universe: the filtered JPX listing when
+// universePath is set, otherwise the resolved watchlist spec.
+func loadStocks(universePath, segments, sectors, watchlistDir, watchlistSpec string) ([]model.Stock, error) {
+	if universePath == "" {
+		return watchlist.NewStore(watchlistDir).Resolve(watchlistSpec)
+	}
+	segs, err := jpx.ParseSegments(segments)
+	if err != nil {
+		return nil, err
+	}
+	stocks, err := jpx.Load(universePath, jpx.Filter{Segments: segs, Sectors: splitList(sectors)})
+	if err != nil {
+		return nil, err
+	}
+	if len(stocks) == 0 {
+		return nil, fmt.Errorf("%s に条件に合う銘柄がありません", universePath)
 	}
+	return stocks, nil
 }
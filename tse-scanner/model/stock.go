@@ -3,11 +3,23 @@ package model
 
 import "time"
 
+// Segment is a TSE market segment (市場区分).
+type Segment string
+
+const (
+	SegmentPrime    Segment = "プライム"
+	SegmentStandard Segment = "スタンダード"
+	SegmentGrowth   Segment = "グロース"
+)
+
 // Stock represents a watchlist entry.
 type Stock struct {
-	Symbol string // e.g. "7203.T"
-	Name   string // 日本語銘柄名
-	Sector string // 業種
+	Symbol     string   // e.g. "7203.T"
+	Name       string   // 日本語銘柄名
+	Sector     string   // 業種
+	Tags       []string // 任意の分類タグ（例: "高配当", "AI"）
+	Segment    Segment  // 市場区分（JPX 上場銘柄一覧から取り込んだ場合のみ）
+	SectorCode string   // 東証33業種コード（例: "3650"）
 }
 
 // Quote holds a real-time market snapshot for one stock.
@@ -19,7 +31,7 @@ type Quote struct {
 	Change        float64
 	ChangePercent float64 // %
 	Volume        int64
-	AvgVolume3M   int64   // 3ヶ月平均出来高
+	AvgVolume3M   int64 // 3ヶ月平均出来高
 	DayHigh       float64
 	DayLow        float64
 	Open          float64
@@ -39,78 +51,139 @@ type Signal struct {
 // Candidate is a Quote enriched with surge analysis results.
 type Candidate struct {
 	Quote
-	VolumeRatio float64  // 出来高 / 3ヶ月平均出来高
-	SurgeScore  float64  // 0–100 の急騰スコア
-	Signals     []Signal // 発動したシグナル一覧
-}
-
-// DefaultWatchlist returns the built-in stock watchlist (東証プライム中心).
-func DefaultWatchlist() []Stock {
-	return []Stock{
-		// 自動車
-		{Symbol: "7203.T", Name: "トヨタ自動車", Sector: "自動車"},
-		{Symbol: "7267.T", Name: "本田技研工業", Sector: "自動車"},
-		{Symbol: "7201.T", Name: "日産自動車", Sector: "自動車"},
-		{Symbol: "7270.T", Name: "SUBARU", Sector: "自動車"},
-		// 電機・電子
-		{Symbol: "6758.T", Name: "ソニーグループ", Sector: "電機"},
-		{Symbol: "6752.T", Name: "パナソニックHD", Sector: "電機"},
-		{Symbol: "6501.T", Name: "日立製作所", Sector: "電機"},
-		{Symbol: "6503.T", Name: "三菱電機", Sector: "電機"},
-		{Symbol: "6701.T", Name: "NEC", Sector: "電機"},
-		// 半導体・精密
-		{Symbol: "8035.T", Name: "東京エレクトロン", Sector: "半導体"},
-		{Symbol: "6857.T", Name: "アドバンテスト", Sector: "半導体"},
-		{Symbol: "4063.T", Name: "信越化学工業", Sector: "化学"},
-		{Symbol: "6723.T", Name: "ルネサスエレクトロニクス", Sector: "半導体"},
-		{Symbol: "6146.T", Name: "ディスコ", Sector: "半導体"},
-		// IT・通信
-		{Symbol: "9984.T", Name: "ソフトバンクグループ", Sector: "IT"},
-		{Symbol: "9432.T", Name: "日本電信電話", Sector: "通信"},
-		{Symbol: "9433.T", Name: "KDDI", Sector: "通信"},
-		{Symbol: "9434.T", Name: "ソフトバンク", Sector: "通信"},
-		{Symbol: "4689.T", Name: "LINEヤフー", Sector: "IT"},
-		{Symbol: "3659.T", Name: "ネクソン", Sector: "ゲーム"},
-		// 金融
-		{Symbol: "8306.T", Name: "三菱UFJフィナンシャル", Sector: "銀行"},
-		{Symbol: "8411.T", Name: "みずほフィナンシャル", Sector: "銀行"},
-		{Symbol: "8316.T", Name: "三井住友フィナンシャル", Sector: "銀行"},
-		{Symbol: "8604.T", Name: "野村ホールディングス", Sector: "証券"},
-		{Symbol: "8591.T", Name: "オリックス", Sector: "金融"},
-		// 小売
-		{Symbol: "3382.T", Name: "セブン＆アイHD", Sector: "小売"},
-		{Symbol: "8267.T", Name: "イオン", Sector: "小売"},
-		{Symbol: "9843.T", Name: "ニトリHD", Sector: "小売"},
-		{Symbol: "4452.T", Name: "花王", Sector: "日用品"},
-		// ゲーム・エンタメ
-		{Symbol: "7974.T", Name: "任天堂", Sector: "ゲーム"},
-		{Symbol: "9766.T", Name: "コナミグループ", Sector: "ゲーム"},
-		{Symbol: "7832.T", Name: "バンダイナムコHD", Sector: "ゲーム"},
-		{Symbol: "9684.T", Name: "スクウェア・エニックス", Sector: "ゲーム"},
-		// 医薬品
-		{Symbol: "4568.T", Name: "第一三共", Sector: "医薬品"},
-		{Symbol: "4519.T", Name: "中外製薬", Sector: "医薬品"},
-		{Symbol: "4502.T", Name: "武田薬品工業", Sector: "医薬品"},
-		{Symbol: "4578.T", Name: "大塚ホールディングス", Sector: "医薬品"},
-		// 重工・機械
-		{Symbol: "7011.T", Name: "三菱重工業", Sector: "重工"},
-		{Symbol: "6326.T", Name: "クボタ", Sector: "機械"},
-		{Symbol: "6301.T", Name: "小松製作所", Sector: "機械"},
-		// 素材
-		{Symbol: "3407.T", Name: "旭化成", Sector: "化学"},
-		{Symbol: "4005.T", Name: "住友化学", Sector: "化学"},
-		{Symbol: "5401.T", Name: "日本製鉄", Sector: "鉄鋼"},
-		// エネルギー
-		{Symbol: "5020.T", Name: "ENEOSホールディングス", Sector: "エネルギー"},
-		// 食品
-		{Symbol: "2914.T", Name: "日本たばこ産業", Sector: "食品"},
-		{Symbol: "2802.T", Name: "味の素", Sector: "食品"},
-		// 海運・交通
-		{Symbol: "9101.T", Name: "日本郵船", Sector: "海運"},
-		{Symbol: "9107.T", Name: "川崎汽船", Sector: "海運"},
-		{Symbol: "9020.T", Name: "東日本旅客鉄道", Sector: "交通"},
-		// 不動産
-		{Symbol: "8801.T", Name: "三井不動産", Sector: "不動産"},
-		{Symbol: "8802.T", Name: "三菱地所", Sector: "不動産"},
+	VolumeRatio float64    // 出来高 / 3ヶ月平均出来高
+	SurgeScore  float64    // 0–100 の急騰スコア（Plunge なら急落スコア）
+	Plunge      bool       // 急落モードで評価した候補
+	Held        bool       // ポートフォリオの保有銘柄
+	StopHigh    float64    // ストップ高の値段（前日終値なしなら 0）
+	StopLow     float64    // ストップ安の値段（前日終値なしなら 0）
+	Signals     []Signal   // 発動したシグナル一覧
+	Momentum    Momentum   // 履歴から算出した短期モメンタム（履歴なしならゼロ値）
+	Indicators  Indicators // テクニカル指標（足データなしならゼロ値）
+	Relative    Relative   // 指数との比較（ベンチマークなしならゼロ値）
+	Events      []Event    // 当日に効力が生じる決算・権利落ちなどのイベント
+	News        []Headline // 銘柄に関する最新のニュース見出し（新しい順）
+}
+
+// Headline is one news headline matched to a stock.
+type Headline struct {
+	Source    string // 配信元（例: "株探"）
+	Title     string
+	Link      string
+	Published time.Time // 配信日時（不明ならゼロ値）
+}
+
+// Event is a corporate event (決算発表・権利落ち・株式分割・適時開示) that
+// takes effect on the scan's trading day.
+type Event struct {
+	Kind      string    // 種別（例: "決算発表"）
+	At        time.Time // 発表日時・権利落ち日
+	Title     string    // 表題（適時開示の件名など）
+	Scheduled bool      // 予定されたイベント（値動きが想定内）
+	NextDay   bool      // 前営業日の大引け後（または休日）の発表で、当日が初めての反応日
+}
+
+// Momentum holds intraday rate-of-change measures derived from snapshot history.
+type Momentum struct {
+	Window          time.Duration // 上昇率を測る期間
+	Velocity        float64       // 直近 Window の上昇率（%）
+	Acceleration    float64       // Velocity − 前の Window の上昇率（%pt）
+	VolumePace      float64       // 出来高 / 立会経過時間で按分した平均出来高（0 = 算出不可）
+	HasVelocity     bool
+	HasAcceleration bool
+}
+
+// Relative compares a stock's move with the benchmark index.
+type Relative struct {
+	Benchmark    string  // 比較対象の指数（例: "1306.T"、空ならベンチマークなし）
+	MarketChange float64 // 指数の騰落率（%）
+	Strength     float64 // 騰落率 − 指数騰落率（%pt）
+	Beta         float64 // 日足から推定したベータ（推定できなければ 1）
+	Excess       float64 // 騰落率 − Beta × 指数騰落率（%pt）
+	HasBeta      bool    // Beta を日足から推定済み
+}
+
+// Bar is one OHLCV candle (日足 or 分足).
+type Bar struct {
+	Time   time.Time
+	Open   float64
+	High   float64
+	Low    float64
+	Close  float64
+	Volume int64
+}
+
+// Indicators holds technical indicator values at the latest bar.
+// Fields are zero when the series was too short to compute them.
+type Indicators struct {
+	RSI            float64 // RSI(14)
+	MACD           float64 // MACD(12,26)
+	MACDSignal     float64 // シグナル(9)
+	BollingerUpper float64 // ボリンジャーバンド +2σ(20)
+	BollingerLower float64 // ボリンジャーバンド −2σ(20)
+	SMAShort       float64 // 短期移動平均（5 日）
+	SMALong        float64 // 長期移動平均（25 日）
+	ATR            float64 // ATR(14)
+	VWAP           float64 // 当日 VWAP（分足から算出）
+	HasDaily       bool    // 日足指標を算出済み
+	HasIntraday    bool    // VWAP を算出済み
+}
+
+// ScanReport describes how one fetch of the watchlist went, so partial
+// failures are visible instead of silently dropping candidates.
+type ScanReport struct {
+	Provider  string
+	StartedAt time.Time
+	Latency   time.Duration // 取得全体の所要時間
+	Requested int           // 要求銘柄数
+	Valid     int           // 有効な気配値が得られた銘柄数
+	Staleness time.Duration // 最も古い有効な気配値の経過時間（FetchedAt 基準）
+	Batches   []BatchReport
+	Failures  []SymbolFailure
+}
+
+// BatchReport is the outcome of one batched request.
+type BatchReport struct {
+	Symbols  int
+	Attempts int // リトライを含む試行回数
+	Latency  time.Duration
+	Err      string
+}
+
+// SymbolFailure explains why one stock has no valid quote.
+type SymbolFailure struct {
+	Symbol string
+	Reason string
+}
+
+// Coverage returns the fraction of requested stocks with a valid quote
+// (1 when nothing was requested).
+func (r ScanReport) Coverage() float64 {
+	if r.Requested == 0 {
+		return 1
+	}
+	return float64(r.Valid) / float64(r.Requested)
+}
+
+// Restrict limits Requested and Valid to quotes, the stocks the scan is
+// judged on, so benchmark indices and held-only stocks fetched alongside do
+// not count toward Coverage. Failures still list every symbol.
+func (r *ScanReport) Restrict(quotes []Quote) {
+	r.Requested, r.Valid = len(quotes), 0
+	for _, q := range quotes {
+		if q.Valid {
+			r.Valid++
+		}
+	}
+}
+
+// FailedBatches returns the number of batches that failed after retries.
+func (r ScanReport) FailedBatches() int {
+	n := 0
+	for _, b := range r.Batches {
+		if b.Err != "" {
+			n++
+		}
 	}
+	return n
 }
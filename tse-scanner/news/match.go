@@ -0,0 +1,89 @@
+package news
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"tse-scanner/model"
+)
+
+// codePattern matches a code written in a headline: "<7203>", "[7203]",
+// "（7203）", "【7203】" and the like, including 5-digit TDnet codes.
+var codePattern = regexp.MustCompile(`[<＜\[［(（【]\s*([0-9][0-9A-Z]{3})0?\s*[>＞\]］)）】]`)
+
+// nameSuffixes are legal-form and holding-company suffixes dropped from
+// company names, so headlines using the short form still match.
+var nameSuffixes = []string{"株式会社", "（株）", "(株)", "ホールディングス", "ＨＤ", "HD"}
+
+// minNameRunes is the shortest company name matched (e.g. "花王").
+const minNameRunes = 2
+
+// Matcher finds the stocks a headline is about, by code or company name.
+type Matcher struct {
+	codes map[string]string // 4 桁コード → 銘柄コード
+	names []nameEntry       // 長い名前から順に照合する
+}
+
+type nameEntry struct {
+	name   string
+	symbol string
+}
+
+// NewMatcher indexes stocks by code and name.
+func NewMatcher(stocks []model.Stock) *Matcher {
+	m := &Matcher{codes: make(map[string]string, len(stocks))}
+	for _, s := range stocks {
+		m.codes[strings.TrimSuffix(s.Symbol, ".T")] = s.Symbol
+		for _, name := range nameForms(s.Name) {
+			m.names = append(m.names, nameEntry{name, s.Symbol})
+		}
+	}
+	sort.SliceStable(m.names, func(i, j int) bool { return len(m.names[i].name) > len(m.names[j].name) })
+	return m
+}
+
+// nameForms returns the name and its short form without a legal-form suffix.
+func nameForms(name string) []string {
+	name = strings.TrimSpace(name)
+	if utf8.RuneCountInString(name) < minNameRunes {
+		return nil
+	}
+	forms := []string{name}
+	short := name
+	for _, suf := range nameSuffixes {
+		short = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(short, suf), suf))
+	}
+	if short != name && utf8.RuneCountInString(short) >= minNameRunes {
+		forms = append(forms, short)
+	}
+	return forms
+}
+
+// Match returns the symbols a headline mentions (each once, codes first).
+func (m *Matcher) Match(title string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	add := func(sym string) {
+		if !seen[sym] {
+			seen[sym] = true
+			out = append(out, sym)
+		}
+	}
+	for _, sub := range codePattern.FindAllStringSubmatch(title, -1) {
+		if sym, ok := m.codes[sub[1]]; ok {
+			add(sym)
+		}
+	}
+	// 長い名前から照合し、一致した部分は短い名前の照合対象から外す
+	// （「トヨタ自動車」に「トヨタ」を二重に当てない）
+	rest := title
+	for _, e := range m.names {
+		if strings.Contains(rest, e.name) {
+			add(e.symbol)
+			rest = strings.ReplaceAll(rest, e.name, "\x00")
+		}
+	}
+	return out
+}
@@ -0,0 +1,198 @@
+// Package news collects recent headlines from news feeds and matches them
+// to stocks, so the first question about a surging candidate — "why?" — can
+// be answered next to the table.
+//
+// A Provider returns headlines from one source. RSS reads RSS 2.0 and RSS
+// 1.0 (RDF) feeds, which covers the usual sources: 株探 (Kabutan) and TDnet
+// mirrors put the code in the title ("<7203>", "[7203]"), 日経 headlines only
+// name the company. Feed URLs are configured by the user with -news
+// ("kabutan=https://…,tdnet=./tdnet.xml"); a path instead of a URL reads a
+// local file, which is also how the providers are tested.
+//
+// A Desk polls every provider at most once per interval, matches each
+// headline to symbols by code and company name, and attaches the latest
+// headlines of each candidate.
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"tse-scanner/model"
+)
+
+const (
+	// DefaultInterval is how often a Desk polls its providers.
+	DefaultInterval = 5 * time.Minute
+	// DefaultMaxAge is how long a headline stays attached to a stock.
+	DefaultMaxAge = 24 * time.Hour
+	// DefaultPerSymbol is how many headlines Attach keeps per candidate.
+	DefaultPerSymbol = 3
+)
+
+// Item is one headline as returned by a Provider.
+type Item struct {
+	Source    string
+	Title     string
+	Link      string
+	Published time.Time // 配信日時（不明ならゼロ値）
+}
+
+// Provider fetches the current headlines of one source.
+type Provider interface {
+	Name() string
+	Fetch(ctx context.Context) ([]Item, error)
+}
+
+// sourceNames labels the sources the package documents; other names are
+// shown as given.
+var sourceNames = map[string]string{
+	"kabutan": "株探",
+	"nikkei":  "日経",
+	"tdnet":   "TDnet",
+}
+
+// ParseFeeds parses -news: comma-separated name=url pairs. url may be an
+// http(s) URL or a local file path.
+func ParseFeeds(spec string) ([]Provider, error) {
+	var providers []Provider
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(part, "=")
+		if !ok || strings.TrimSpace(name) == "" || strings.TrimSpace(url) == "" {
+			return nil, fmt.Errorf("ニュースフィードの指定 %q を解釈できません（例: kabutan=https://example.com/rss.xml）", part)
+		}
+		name = strings.TrimSpace(name)
+		if label, ok := sourceNames[strings.ToLower(name)]; ok {
+			name = label
+		}
+		providers = append(providers, NewRSS(name, strings.TrimSpace(url)))
+	}
+	return providers, nil
+}
+
+// Option configures a Desk.
+type Option func(*Desk)
+
+// WithInterval sets how often providers are polled (DefaultInterval).
+func WithInterval(d time.Duration) Option { return func(k *Desk) { k.interval = d } }
+
+// WithMaxAge sets how long headlines are kept (DefaultMaxAge).
+func WithMaxAge(d time.Duration) Option { return func(k *Desk) { k.maxAge = d } }
+
+// Desk polls providers and keeps the matched headlines by symbol. It is
+// safe for concurrent use.
+type Desk struct {
+	providers []Provider
+	matcher   *Matcher
+	interval  time.Duration
+	maxAge    time.Duration
+
+	mu       sync.Mutex
+	polled   time.Time
+	bySymbol map[string][]model.Headline // 銘柄コード → 新しい順
+}
+
+// NewDesk returns a Desk matching headlines against stocks.
+func NewDesk(providers []Provider, stocks []model.Stock, opts ...Option) *Desk {
+	d := &Desk{
+		providers: providers,
+		matcher:   NewMatcher(stocks),
+		interval:  DefaultInterval,
+		maxAge:    DefaultMaxAge,
+		bySymbol:  make(map[string][]model.Headline),
+	}
+	for _, o := range opts {
+		o(d)
+	}
+	return d
+}
+
+// Refresh polls every provider unless the last poll was less than the
+// interval before now. Headlines from providers that fail are kept from the
+// previous poll; the failures are returned joined.
+func (d *Desk) Refresh(ctx context.Context, now time.Time) error {
+	d.mu.Lock()
+	if !d.polled.IsZero() && now.Sub(d.polled) < d.interval {
+		d.mu.Unlock()
+		return nil
+	}
+	d.polled = now
+	d.mu.Unlock()
+
+	var (
+		items  []Item
+		failed = make(map[string]bool)
+		errs   []error
+	)
+	for _, p := range d.providers {
+		got, err := p.Fetch(ctx)
+		if err != nil {
+			failed[p.Name()] = true
+			errs = append(errs, fmt.Errorf("ニュース取得エラー（%s）: %w", p.Name(), err))
+			continue
+		}
+		items = append(items, got...)
+	}
+
+	bySymbol := make(map[string][]model.Headline)
+	seen := make(map[string]bool)
+	for _, it := range items {
+		if !it.Published.IsZero() && now.Sub(it.Published) > d.maxAge {
+			continue
+		}
+		key := it.Link
+		if key == "" {
+			key = it.Source + "\x00" + it.Title
+		}
+		if seen[key] {
+			continue // 同じ記事が複数のフィードに載ることがある
+		}
+		seen[key] = true
+		h := model.Headline{Source: it.Source, Title: it.Title, Link: it.Link, Published: it.Published}
+		for _, sym := range d.matcher.Match(it.Title) {
+			bySymbol[sym] = append(bySymbol[sym], h)
+		}
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	// 失敗したソースは前回の見出しを残す
+	for sym, hs := range d.bySymbol {
+		for _, h := range hs {
+			if failed[h.Source] && (h.Published.IsZero() || now.Sub(h.Published) <= d.maxAge) {
+				bySymbol[sym] = append(bySymbol[sym], h)
+			}
+		}
+	}
+	for _, hs := range bySymbol {
+		sort.SliceStable(hs, func(i, j int) bool { return hs[i].Published.After(hs[j].Published) })
+	}
+	d.bySymbol = bySymbol
+	return errors.Join(errs...)
+}
+
+// Latest returns up to n of symbol's newest headlines.
+func (d *Desk) Latest(symbol string, n int) []model.Headline {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	hs := d.bySymbol[symbol]
+	if len(hs) > n {
+		hs = hs[:n]
+	}
+	return append([]model.Headline(nil), hs...)
+}
+
+// Attach sets News on every candidate to its DefaultPerSymbol newest headlines.
+func (d *Desk) Attach(cands []model.Candidate) {
+	for i := range cands {
+		cands[i].News = d.Latest(cands[i].Symbol, DefaultPerSymbol)
+	}
+}
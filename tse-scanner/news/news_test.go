@@ -0,0 +1,179 @@
+package news_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"tse-scanner/model"
+	"tse-scanner/news"
+)
+
+// ---- helpers ----
+
+var now = time.Date(2024, 6, 14, 10, 30, 0, 0, time.FixedZone("JST", 9*60*60))
+
+var stocks = []model.Stock{
+	{Symbol: "7203.T", Name: "トヨタ自動車"},
+	{Symbol: "6758.T", Name: "ソニーグループ"},
+	{Symbol: "9984.T", Name: "ソフトバンクグループ"},
+	{Symbol: "130A.T", Name: "Veritas In Silico"},
+}
+
+// stubProvider returns fixed items or an error.
+type stubProvider struct {
+	name  string
+	items []news.Item
+	err   error
+	calls int
+}
+
+func (s *stubProvider) Name() string { return s.name }
+
+func (s *stubProvider) Fetch(context.Context) ([]news.Item, error) {
+	s.calls++
+	return s.items, s.err
+}
+
+func titles(hs []model.Headline) []string {
+	out := make([]string, len(hs))
+	for i, h := range hs {
+		out[i] = h.Title
+	}
+	return out
+}
+
+// ---- tests ----
+
+func TestRSS_FixtureFeeds(t *testing.T) {
+	tests := []struct {
+		file      string
+		wantItems int
+		wantFirst string
+	}{
+		{"testdata/kabutan.xml", 4, "トヨタ、今期営業益を上方修正 <7203>"},
+		{"testdata/nikkei.rdf", 2, "ソフトバンクグループ、AI投資を拡大"},
+		{"testdata/tdnet_sjis.xml", 2, "[72030] トヨタ自動車 : 自己株式の取得状況に関するお知らせ"},
+	}
+	for _, tt := range tests {
+		items, err := news.NewRSS("test", tt.file).Fetch(context.Background())
+		if err != nil {
+			t.Fatalf("%s: %v", tt.file, err)
+		}
+		if len(items) != tt.wantItems || items[0].Title != tt.wantFirst {
+			t.Errorf("%s: got %d items, first %q", tt.file, len(items), items[0].Title)
+		}
+		if items[0].Published.IsZero() || items[0].Source != "test" {
+			t.Errorf("%s: %+v", tt.file, items[0])
+		}
+	}
+}
+
+func TestRSS_HTTP(t *testing.T) {
+	body, err := os.ReadFile("testdata/kabutan.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/rss" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(body) //nolint:errcheck
+	}))
+	defer srv.Close()
+
+	if items, err := news.NewRSSWithHTTP(srv.Client(), "株探", srv.URL+"/rss").Fetch(context.Background()); err != nil || len(items) != 4 {
+		t.Errorf("got %d items, %v", len(items), err)
+	}
+	if _, err := news.NewRSSWithHTTP(srv.Client(), "株探", srv.URL+"/missing").Fetch(context.Background()); err == nil {
+		t.Error("want error for 404")
+	}
+}
+
+func TestMatcher(t *testing.T) {
+	m := news.NewMatcher(stocks)
+	cases := map[string][]string{
+		"トヨタ、今期営業益を上方修正 <7203>":   {"7203.T"},
+		"[130A0] 新規上場会社 : 上場承認":   {"130A.T"},
+		"ソニーグループとトヨタ自動車が提携（6758）": {"6758.T", "7203.T"},
+		"ソフトバンクグループ、AI投資を拡大":      {"9984.T"},
+		"日経平均は反発、半導体株が高い":         nil,
+		"未登録の銘柄 <9999> がストップ高":    nil,
+	}
+	for title, want := range cases {
+		got := m.Match(title)
+		if len(got) != len(want) {
+			t.Errorf("Match(%q) = %v, want %v", title, got, want)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("Match(%q) = %v, want %v", title, got, want)
+			}
+		}
+	}
+}
+
+func TestDesk_RefreshAndAttach(t *testing.T) {
+	feeds, err := news.ParseFeeds("kabutan=testdata/kabutan.xml, nikkei=testdata/nikkei.rdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	desk := news.NewDesk(feeds, stocks)
+	if err := desk.Refresh(context.Background(), now); err != nil {
+		t.Fatalf("Refresh: %v", err)
+	}
+
+	cands := []model.Candidate{{Quote: model.Quote{Symbol: "7203.T"}}, {Quote: model.Quote{Symbol: "8306.T"}}}
+	desk.Attach(cands)
+	// 24 時間より古い記事は除き、新しい順に並べる
+	got := titles(cands[0].News)
+	want := []string{"トヨタ、今期営業益を上方修正 <7203>", "トヨタ自動車、業績予想を上方修正"}
+	if len(got) != 2 || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("7203.T news = %v, want %v", got, want)
+	}
+	if cands[0].News[0].Source != "株探" || cands[0].News[1].Source != "日経" {
+		t.Errorf("sources: %+v", cands[0].News)
+	}
+	if len(cands[1].News) != 0 {
+		t.Errorf("8306.T: want no news, got %v", titles(cands[1].News))
+	}
+}
+
+func TestDesk_IntervalAndFailedSourceKeepsHeadlines(t *testing.T) {
+	p := &stubProvider{name: "株探", items: []news.Item{
+		{Source: "株探", Title: "トヨタ、増配 <7203>", Link: "a", Published: now.Add(-time.Hour)},
+		{Source: "株探", Title: "トヨタ、増配 <7203>", Link: "a", Published: now.Add(-time.Hour)}, // 重複
+	}}
+	desk := news.NewDesk([]news.Provider{p}, stocks, news.WithInterval(time.Minute))
+	desk.Refresh(context.Background(), now) //nolint:errcheck
+	if n := len(desk.Latest("7203.T", 5)); n != 1 {
+		t.Fatalf("want 1 headline after dedupe, got %d", n)
+	}
+
+	desk.Refresh(context.Background(), now.Add(30*time.Second)) //nolint:errcheck
+	if p.calls != 1 {
+		t.Errorf("polled within interval: %d calls", p.calls)
+	}
+
+	p.err = errors.New("HTTPステータス 503")
+	if err := desk.Refresh(context.Background(), now.Add(2*time.Minute)); err == nil {
+		t.Error("want error from failed provider")
+	}
+	if n := len(desk.Latest("7203.T", 5)); n != 1 {
+		t.Errorf("failed source: want previous headline kept, got %d", n)
+	}
+}
+
+func TestParseFeeds_Errors(t *testing.T) {
+	for _, spec := range []string{"kabutan", "=https://example.com", "nikkei="} {
+		if _, err := news.ParseFeeds(spec); err == nil {
+			t.Errorf("%q: want error", spec)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package news
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/htmlindex"
+
+	"tse-scanner/fetcher"
+)
+
+// maxFeedBytes bounds how much of a feed is read.
+const maxFeedBytes = 4 << 20
+
+// RSS reads an RSS 2.0 or RSS 1.0 (RDF) feed from a URL or a local file.
+type RSS struct {
+	name string
+	url  string
+	http fetcher.HTTPDoer
+}
+
+var _ Provider = (*RSS)(nil)
+
+// NewRSS returns a feed provider with a production HTTP client (10s timeout).
+func NewRSS(name, url string) *RSS {
+	return NewRSSWithHTTP(&http.Client{Timeout: 10 * time.Second}, name, url)
+}
+
+// NewRSSWithHTTP returns a feed provider using the provided HTTPDoer (for testing).
+func NewRSSWithHTTP(h fetcher.HTTPDoer, name, url string) *RSS {
+	return &RSS{name: name, url: url, http: h}
+}
+
+// Name implements Provider.
+func (r *RSS) Name() string { return r.name }
+
+// Fetch implements Provider.
+func (r *RSS) Fetch(ctx context.Context) ([]Item, error) {
+	body, err := r.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return parseFeed(io.LimitReader(body, maxFeedBytes), r.name)
+}
+
+func (r *RSS) open(ctx context.Context) (io.ReadCloser, error) {
+	if !strings.HasPrefix(r.url, "http://") && !strings.HasPrefix(r.url, "https://") {
+		f, err := os.Open(strings.TrimPrefix(r.url, "file://"))
+		if err != nil {
+			return nil, fmt.Errorf("フィードを開けません: %w", err)
+		}
+		return f, nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("リクエスト作成エラー: %w", err)
+	}
+	resp, err := r.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTPエラー: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("HTTPステータス %d", resp.StatusCode)
+	}
+	return resp.Body, nil
+}
+
+// feed covers both layouts: RSS 2.0 nests items in <channel>, RSS 1.0 puts
+// them directly under <rdf:RDF>.
+type feed struct {
+	Channel struct {
+		Items []feedItem `xml:"item"`
+	} `xml:"channel"`
+	Items []feedItem `xml:"item"`
+}
+
+type feedItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	PubDate string `xml:"pubDate"` // RSS 2.0
+	Date    string `xml:"date"`    // RSS 1.0（dc:date）
+}
+
+// dateLayouts are the publication date formats seen in feeds.
+var dateLayouts = []string{time.RFC1123Z, time.RFC1123, time.RFC3339, "Mon, 2 Jan 2006 15:04:05 -0700"}
+
+func parseFeed(r io.Reader, source string) ([]Item, error) {
+	var f feed
+	dec := xml.NewDecoder(r)
+	dec.CharsetReader = func(charset string, input io.Reader) (io.Reader, error) {
+		enc, err := htmlindex.Get(charset) // Shift_JIS / EUC-JP のフィードもある
+		if err != nil {
+			return nil, fmt.Errorf("未対応の文字コードです: %s", charset)
+		}
+		return enc.NewDecoder().Reader(input), nil
+	}
+	if err := dec.Decode(&f); err != nil {
+		return nil, fmt.Errorf("フィードのパースエラー: %w", err)
+	}
+	items := make([]Item, 0, len(f.Channel.Items)+len(f.Items))
+	for _, it := range append(f.Channel.Items, f.Items...) {
+		title := strings.TrimSpace(it.Title)
+		if title == "" {
+			continue
+		}
+		item := Item{Source: source, Title: title, Link: strings.TrimSpace(it.Link)}
+		date := strings.TrimSpace(it.PubDate)
+		if date == "" {
+			date = strings.TrimSpace(it.Date)
+		}
+		for _, layout := range dateLayouts {
+			if t, err := time.Parse(layout, date); err == nil {
+				item.Published = t
+				break
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
@@ -0,0 +1,164 @@
+package paper
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Position is an open virtual position.
+type Position struct {
+	Symbol     string    `json:"symbol"`
+	Name       string    `json:"name,omitempty"`
+	Shares     int       `json:"shares"`
+	EntryAt    time.Time `json:"entry_at"`
+	EntryPrice float64   `json:"entry_price"` // 約定値（スリッページ込み）
+	Commission float64   `json:"commission"`  // 買い手数料
+	Score      float64   `json:"score"`       // エントリー時の急騰スコア
+	Signals    []string  `json:"signals,omitempty"`
+	Last       float64   `json:"last"` // 直近の時価
+	LastAt     time.Time `json:"last_at"`
+}
+
+// Value is the position's market value at its last price.
+func (p Position) Value() float64 { return p.Last * float64(p.Shares) }
+
+// Trade is one closed round trip.
+type Trade struct {
+	Symbol     string    `json:"symbol"`
+	Name       string    `json:"name,omitempty"`
+	Shares     int       `json:"shares"`
+	EntryAt    time.Time `json:"entry_at"`
+	ExitAt     time.Time `json:"exit_at"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Commission float64   `json:"commission"` // 往復の手数料
+	PnL        float64   `json:"pnl"`        // 手数料控除後の損益（円）
+	Return     float64   `json:"return"`     // 手数料控除後のリターン（%）
+	Reason     string    `json:"reason"`
+	Score      float64   `json:"score"`
+	Signals    []string  `json:"signals,omitempty"`
+}
+
+// Day is the account's result for one trading day.
+type Day struct {
+	Date       string  `json:"date"`       // 2006-01-02（JST）
+	Start      float64 `json:"start"`      // 前日末の評価額
+	Equity     float64 `json:"equity"`     // 当日最後のスキャン時点の評価額
+	Realized   float64 `json:"realized"`   // 当日決済分の損益
+	Commission float64 `json:"commission"` // 当日の手数料
+	Buys       int     `json:"buys"`
+	Sells      int     `json:"sells"`
+}
+
+// PnL is the day's change in equity, realized or not.
+func (d Day) PnL() float64 { return d.Equity - d.Start }
+
+// Account is the persisted state of a paper-trading account.
+type Account struct {
+	Initial   float64    `json:"initial"`
+	Cash      float64    `json:"cash"`
+	Positions []Position `json:"positions"`
+	Trades    []Trade    `json:"trades"`
+	Days      []Day      `json:"days"`
+	Updated   time.Time  `json:"updated"`
+}
+
+// NewAccount returns an empty account funded with cash.
+func NewAccount(cash float64) *Account {
+	return &Account{Initial: cash, Cash: cash, Positions: []Position{}, Trades: []Trade{}, Days: []Day{}}
+}
+
+// Equity is cash plus the market value of the open positions.
+func (a *Account) Equity() float64 {
+	eq := a.Cash
+	for _, p := range a.Positions {
+		eq += p.Value()
+	}
+	return eq
+}
+
+// Holds reports whether symbol has an open position.
+func (a *Account) Holds(symbol string) bool {
+	for _, p := range a.Positions {
+		if p.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// Stats summarises the closed trades.
+type Stats struct {
+	Trades      int
+	Wins        int
+	HitRate     float64 // 勝率（%）
+	Realized    float64 // 確定損益（円）
+	Commission  float64 // 手数料合計（円）
+	MaxDrawdown float64 // 日次評価額の最大ドローダウン（%）
+}
+
+// Stats returns the account's trade and equity statistics.
+func (a *Account) Stats() Stats {
+	s := Stats{Trades: len(a.Trades)}
+	for _, t := range a.Trades {
+		if t.PnL > 0 {
+			s.Wins++
+		}
+		s.Realized += t.PnL
+		s.Commission += t.Commission
+	}
+	for _, p := range a.Positions {
+		s.Commission += p.Commission
+	}
+	if s.Trades > 0 {
+		s.HitRate = float64(s.Wins) / float64(s.Trades) * 100
+	}
+	peak := a.Initial
+	for _, d := range a.Days {
+		peak = math.Max(peak, d.Equity)
+		if peak > 0 {
+			s.MaxDrawdown = math.Max(s.MaxDrawdown, (peak-d.Equity)/peak*100)
+		}
+	}
+	return s
+}
+
+// LoadAccount reads the account at path. A missing file starts a new
+// account funded with initialCash.
+func LoadAccount(path string, initialCash float64) (*Account, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return NewAccount(initialCash), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("仮想口座を開けません: %w", err)
+	}
+	a := NewAccount(0)
+	if err := json.Unmarshal(b, a); err != nil {
+		return nil, fmt.Errorf("%s: 仮想口座のパースエラー: %w", path, err)
+	}
+	return a, nil
+}
+
+// Save writes the account to path.
+func (a *Account) Save(path string) error {
+	b, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ディレクトリ作成エラー: %w", err)
+	}
+	// Write to a temp file and rename so a crash never leaves a half-written account.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(b, '\n'), 0o644); err != nil {
+		return fmt.Errorf("仮想口座の書き込みエラー: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,210 @@
+// Package paper forward-tests the scanner live with a virtual account.
+//
+// A Trader consumes every scan: it marks the open positions to market,
+// closes those that hit an exit rule and buys the best new candidates, the
+// way the backtest package does on recorded data but with the frictions of
+// a real order: 100-share lots, commissions and slippage of a few 呼値
+// (ticks). The account — cash, positions, the trade log and one row of P&L
+// per trading day — is persisted as JSON after each scan, so a forward test
+// survives restarts and can be read back with "tse-scanner paper".
+//
+// Rules are configured in a YAML file (see Config); every field is optional:
+//
+//	initial_cash: 1000000
+//	min_score: 60          # エントリーする最小急騰スコア
+//	max_positions: 5
+//	position_size: 20%     # 評価額に対する割合、または金額（500000）
+//	take_profit: 3         # %（0 で無効）
+//	stop_loss: 2           # %（0 で無効）
+//	time_stop: 1h          # 最大保有時間（0 で無効）
+//	close_at_end: true     # 大引け後のスキャンで全ポジションを手仕舞う
+//	commission: {rate: 0.055, min: 55, max: 1100}
+//	slippage_ticks: 1
+//
+// Only surge candidates are bought; orders fill at the scan price moved
+// against the trader by slippage_ticks, and only while the market is
+// trading (orders placed at a stop-high price are assumed not to fill).
+package paper
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultPath returns the per-user virtual account file
+// (e.g. ~/.config/tse-scanner/paper.json on Linux).
+func DefaultPath() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", "paper.json")
+	}
+	return filepath.Join(base, "tse-scanner", "paper.json")
+}
+
+// Duration is a time.Duration written as "30m" / "1h" in config files.
+type Duration time.Duration
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (d *Duration) UnmarshalYAML(n *yaml.Node) error {
+	v, err := time.ParseDuration(n.Value)
+	if err != nil {
+		return fmt.Errorf("期間 %q を解釈できません（例: 30m, 1h）", n.Value)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Size is the amount invested per position: a fixed amount of yen, or a
+// percentage of the account's equity.
+type Size struct {
+	Yen     float64
+	Percent float64
+}
+
+// ParseSize parses "500000" or "20%".
+func ParseSize(s string) (Size, error) {
+	s = strings.TrimSpace(s)
+	if v, ok := strings.CutSuffix(s, "%"); ok {
+		p, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil || p <= 0 || p > 100 {
+			return Size{}, fmt.Errorf("ポジションサイズ %q を解釈できません（0 より大きく 100%% 以下）", s)
+		}
+		return Size{Percent: p}, nil
+	}
+	y, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil || y <= 0 {
+		return Size{}, fmt.Errorf("ポジションサイズ %q を解釈できません（例: 500000, 20%%）", s)
+	}
+	return Size{Yen: y}, nil
+}
+
+// UnmarshalYAML implements yaml.Unmarshaler.
+func (s *Size) UnmarshalYAML(n *yaml.Node) error {
+	v, err := ParseSize(n.Value)
+	if err != nil {
+		return err
+	}
+	*s = v
+	return nil
+}
+
+// Amount returns the yen invested in one position at equity.
+func (s Size) Amount(equity float64) float64 {
+	if s.Percent > 0 {
+		return equity * s.Percent / 100
+	}
+	return s.Yen
+}
+
+func (s Size) String() string {
+	if s.Percent > 0 {
+		return strconv.FormatFloat(s.Percent, 'f', -1, 64) + "%"
+	}
+	return strconv.FormatFloat(s.Yen, 'f', 0, 64) + "円"
+}
+
+// Commission is a broker's fee schedule: Rate percent of the traded value,
+// bounded by Min and Max (0 = no bound).
+type Commission struct {
+	Rate float64 `yaml:"rate"` // 約定代金に対する割合（%）
+	Min  float64 `yaml:"min"`  // 最低手数料（円）
+	Max  float64 `yaml:"max"`  // 上限（円、0 で上限なし）
+}
+
+// Fee returns the commission on an order of value yen.
+func (c Commission) Fee(value float64) float64 {
+	fee := value * c.Rate / 100
+	if fee < c.Min {
+		fee = c.Min
+	}
+	if c.Max > 0 && fee > c.Max {
+		fee = c.Max
+	}
+	return fee
+}
+
+// Config holds the trading rules of a paper account.
+type Config struct {
+	InitialCash   float64    `yaml:"initial_cash"`   // 初期資金（円）
+	MinScore      float64    `yaml:"min_score"`      // エントリーする最小急騰スコア
+	MaxPositions  int        `yaml:"max_positions"`  // 同時に保有する最大銘柄数
+	PositionSize  Size       `yaml:"position_size"`  // 1 銘柄あたりの投資額
+	Lot           int        `yaml:"lot"`            // 売買単位（株）
+	TakeProfit    float64    `yaml:"take_profit"`    // 利確ライン（%、0 で無効）
+	StopLoss      float64    `yaml:"stop_loss"`      // 損切ライン（%、正の値、0 で無効）
+	TimeStop      Duration   `yaml:"time_stop"`      // 最大保有時間（0 で無効）
+	CloseAtEnd    bool       `yaml:"close_at_end"`   // 大引けで手仕舞う（デイトレード）
+	Commission    Commission `yaml:"commission"`     // 売買手数料（片道）
+	SlippageTicks int        `yaml:"slippage_ticks"` // 約定値を不利な方向にずらす呼値の数
+}
+
+// DefaultConfig returns the rules used when no file is given. Entry and
+// exits match the backtest subcommand's defaults.
+func DefaultConfig() Config {
+	return Config{
+		InitialCash:   1_000_000,
+		MinScore:      60,
+		MaxPositions:  5,
+		PositionSize:  Size{Percent: 20},
+		Lot:           100,
+		TakeProfit:    3,
+		StopLoss:      2,
+		TimeStop:      Duration(time.Hour),
+		CloseAtEnd:    true,
+		SlippageTicks: 1,
+	}
+}
+
+// Validate reports rules that cannot be traded.
+func (c Config) Validate() error {
+	var errs []error
+	if c.InitialCash <= 0 {
+		errs = append(errs, errors.New("initial_cash は正の値にしてください"))
+	}
+	if c.MaxPositions < 1 {
+		errs = append(errs, errors.New("max_positions は 1 以上にしてください"))
+	}
+	if c.PositionSize.Yen <= 0 && c.PositionSize.Percent <= 0 {
+		errs = append(errs, errors.New("position_size を指定してください（例: 500000, 20%）"))
+	}
+	if c.Lot < 1 {
+		errs = append(errs, errors.New("lot は 1 以上にしてください"))
+	}
+	if c.TakeProfit < 0 || c.StopLoss < 0 || c.TimeStop < 0 {
+		errs = append(errs, errors.New("take_profit / stop_loss / time_stop は 0 以上にしてください"))
+	}
+	if c.Commission.Rate < 0 || c.Commission.Min < 0 || c.Commission.Max < 0 {
+		errs = append(errs, errors.New("commission は 0 以上にしてください"))
+	}
+	if c.SlippageTicks < 0 {
+		errs = append(errs, errors.New("slippage_ticks は 0 以上にしてください"))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadConfig reads YAML rules; fields left out keep DefaultConfig's values.
+func LoadConfig(path string) (Config, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("ペーパートレード設定を開けません: %w", err)
+	}
+	cfg := DefaultConfig()
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&cfg); err != nil && !errors.Is(err, io.EOF) {
+		return Config{}, fmt.Errorf("%s: ペーパートレード設定のパースエラー: %w", path, err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return cfg, nil
+}
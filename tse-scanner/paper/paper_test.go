@@ -0,0 +1,240 @@
+package paper_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/model"
+	"tse-scanner/paper"
+)
+
+// ---- helpers ----
+
+// at returns a time on 2024-06-14 (Fri, close 15:00) in JST.
+func at(h, m int) time.Time { return time.Date(2024, 6, 14, h, m, 0, 0, calendar.JST) }
+
+func quote(symbol string, price float64) model.Quote {
+	return model.Quote{Symbol: symbol, Name: symbol, Price: price, PrevClose: 1000, Valid: true}
+}
+
+func cand(symbol string, price, score float64) model.Candidate {
+	return model.Candidate{Quote: quote(symbol, price), SurgeScore: score,
+		Signals: []model.Signal{{Label: "出来高急増", Score: score}, {Label: "📅決算発表日"}}}
+}
+
+func testConfig() paper.Config {
+	cfg := paper.DefaultConfig()
+	cfg.Commission = paper.Commission{Rate: 0.05, Min: 100}
+	cfg.TimeStop = 0
+	return cfg
+}
+
+// ---- tests ----
+
+func TestParseSize(t *testing.T) {
+	if s, err := paper.ParseSize("20%"); err != nil || s.Amount(1_000_000) != 200_000 {
+		t.Errorf("20%%: %+v, %v", s, err)
+	}
+	if s, err := paper.ParseSize("500,000"); err != nil || s.Amount(1_000_000) != 500_000 {
+		t.Errorf("500,000: %+v, %v", s, err)
+	}
+	for _, bad := range []string{"", "0", "-1", "150%", "abc"} {
+		if _, err := paper.ParseSize(bad); err == nil {
+			t.Errorf("%q: want error", bad)
+		}
+	}
+}
+
+func TestCommission_Fee(t *testing.T) {
+	c := paper.Commission{Rate: 0.1, Min: 100, Max: 1000}
+	for value, want := range map[float64]float64{50_000: 100, 500_000: 500, 5_000_000: 1000} {
+		if got := c.Fee(value); got != want {
+			t.Errorf("Fee(%v) = %v, want %v", value, got, want)
+		}
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		p := filepath.Join(dir, name)
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	cfg, err := paper.LoadConfig(write("ok.yaml", "min_score: 70\nposition_size: 300000\ntime_stop: 30m\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.MinScore != 70 || cfg.PositionSize.Yen != 300000 || cfg.TimeStop != paper.Duration(30*time.Minute) {
+		t.Errorf("parsed: %+v", cfg)
+	}
+	if cfg.MaxPositions != 5 || cfg.Lot != 100 || !cfg.CloseAtEnd {
+		t.Errorf("defaults not kept: %+v", cfg)
+	}
+	if _, err := paper.LoadConfig(write("empty.yaml", "")); err != nil {
+		t.Errorf("empty file: %v", err)
+	}
+
+	for name, body := range map[string]string{
+		"unknown.yaml": "min_scor: 70\n",
+		"invalid.yaml": "max_positions: 0\nslippage_ticks: -1\n",
+		"size.yaml":    "position_size: 120%\n",
+	} {
+		if _, err := paper.LoadConfig(write(name, body)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+}
+
+func TestTrader_EntryWithSlippageAndCommission(t *testing.T) {
+	tr := paper.New(testConfig(), paper.NewAccount(1_000_000))
+	fills := tr.Step(at(10, 0), []model.Quote{quote("7203.T", 1000)}, []model.Candidate{cand("7203.T", 1000, 80)})
+	if len(fills) != 1 {
+		t.Fatalf("fills = %v", fills)
+	}
+	f := fills[0]
+	// 20% = 200,000 円、1 呼値不利な 1001 円で 100 株単位
+	if f.Side != paper.Buy || f.Price != 1001 || f.Shares != 100 || f.Commission != 100 {
+		t.Errorf("buy fill: %+v", f)
+	}
+	acct := tr.Account()
+	if acct.Cash != 1_000_000-100_100-100 {
+		t.Errorf("cash = %v", acct.Cash)
+	}
+	if p := acct.Positions[0]; len(p.Signals) != 1 || p.Signals[0] != "出来高急増" {
+		t.Errorf("signals: %v", p.Signals)
+	}
+	if !strings.Contains(f.String(), "📝 買 7203.T") {
+		t.Errorf("String() = %q", f.String())
+	}
+}
+
+func TestTrader_TakeProfitAndStopLoss(t *testing.T) {
+	tr := paper.New(testConfig(), paper.NewAccount(1_000_000))
+	tr.Step(at(10, 0), nil, []model.Candidate{cand("7203.T", 1000, 80), cand("6758.T", 1000, 70)})
+
+	fills := tr.Step(at(10, 30), []model.Quote{quote("7203.T", 1040), quote("6758.T", 980)}, nil)
+	if len(fills) != 2 {
+		t.Fatalf("fills = %v", fills)
+	}
+	byReason := map[string]paper.Fill{}
+	for _, f := range fills {
+		byReason[f.Reason] = f
+	}
+	// 利確: 1039 円で売却、損益 = 103,900 − 100 − 100,100 − 100
+	if f := byReason[paper.ExitTakeProfit]; f.Symbol != "7203.T" || f.Price != 1039 || f.PnL != 3600 {
+		t.Errorf("take profit: %+v", f)
+	}
+	if f := byReason[paper.ExitStopLoss]; f.Symbol != "6758.T" || f.Price != 979 {
+		t.Errorf("stop loss: %+v", f)
+	}
+
+	acct := tr.Account()
+	if len(acct.Positions) != 0 || len(acct.Trades) != 2 {
+		t.Fatalf("positions %d, trades %d", len(acct.Positions), len(acct.Trades))
+	}
+	if len(acct.Days) != 1 || acct.Days[0].Buys != 2 || acct.Days[0].Sells != 2 {
+		t.Fatalf("days: %+v", acct.Days)
+	}
+	d := acct.Days[0]
+	if d.Realized != acct.Trades[0].PnL+acct.Trades[1].PnL || d.PnL() != acct.Equity()-1_000_000 {
+		t.Errorf("day: %+v, equity %v", d, acct.Equity())
+	}
+}
+
+func TestTrader_EntryFilters(t *testing.T) {
+	cfg := testConfig()
+	cfg.MaxPositions = 2
+	tr := paper.New(cfg, paper.NewAccount(1_000_000))
+
+	stopHigh := cand("1111.T", 1150, 95)
+	stopHigh.StopHigh = 1150
+	plunge := cand("2222.T", 900, 90)
+	plunge.Plunge = true
+	fills := tr.Step(at(10, 0), nil, []model.Candidate{
+		stopHigh, plunge, cand("3333.T", 1000, 85), cand("4444.T", 1000, 75), cand("5555.T", 1000, 65),
+	})
+	var bought []string
+	for _, f := range fills {
+		bought = append(bought, f.Symbol)
+	}
+	if strings.Join(bought, ",") != "3333.T,4444.T" {
+		t.Errorf("bought %v, want 3333.T,4444.T", bought)
+	}
+
+	// 昼休みと閾値未満は発注しない
+	tr = paper.New(cfg, paper.NewAccount(1_000_000))
+	if fills := tr.Step(at(11, 45), nil, []model.Candidate{cand("3333.T", 1000, 85)}); len(fills) != 0 {
+		t.Errorf("lunch: %v", fills)
+	}
+	if fills := tr.Step(at(13, 0), nil, []model.Candidate{cand("3333.T", 1000, 50)}); len(fills) != 0 {
+		t.Errorf("below min score: %v", fills)
+	}
+
+	// クロージング・オークション（2024/11/5 以降の 15:25–15:30）でも発注しない
+	auction := time.Date(2025, 6, 13, 15, 27, 0, 0, calendar.JST)
+	if fills := tr.Step(auction, nil, []model.Candidate{cand("3333.T", 1000, 85)}); len(fills) != 0 {
+		t.Errorf("closing auction: %v", fills)
+	}
+	if fills := tr.Step(auction.Add(-5*time.Minute), nil, []model.Candidate{cand("3333.T", 1000, 85)}); len(fills) != 1 {
+		t.Errorf("afternoon session: want 1 fill, got %v", fills)
+	}
+}
+
+func TestTrader_CloseAtEndAndNextDay(t *testing.T) {
+	tr := paper.New(testConfig(), paper.NewAccount(1_000_000))
+	tr.Step(at(14, 0), nil, []model.Candidate{cand("7203.T", 1000, 80)})
+	tr.Step(at(14, 30), []model.Quote{quote("7203.T", 1010)}, nil)
+
+	fills := tr.Step(at(15, 10), []model.Quote{quote("7203.T", 1012)}, nil)
+	if len(fills) != 1 || fills[0].Reason != paper.ExitClose || fills[0].Price != 1011 {
+		t.Fatalf("close at end: %v", fills)
+	}
+	closeEquity := tr.Account().Equity()
+
+	// 休場日のスキャンは日次損益に記録しない
+	tr.Step(time.Date(2024, 6, 15, 10, 0, 0, 0, calendar.JST), nil, nil)
+	monday := time.Date(2024, 6, 17, 9, 30, 0, 0, calendar.JST)
+	tr.Step(monday, nil, []model.Candidate{cand("6758.T", 1000, 80)})
+	days := tr.Account().Days
+	if len(days) != 2 || days[1].Date != "2024-06-17" || days[1].Start != closeEquity {
+		t.Errorf("days: %+v (close equity %v)", days, closeEquity)
+	}
+}
+
+func TestAccount_SaveLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sub", "paper.json")
+	acct, err := paper.LoadAccount(path, 500_000)
+	if err != nil || acct.Cash != 500_000 || acct.Initial != 500_000 {
+		t.Fatalf("new account: %+v, %v", acct, err)
+	}
+	tr := paper.New(testConfig(), acct)
+	// 評価額の 20% = 100,000 円で買える単元に収める
+	tr.Step(at(10, 0), nil, []model.Candidate{cand("7203.T", 900, 80)})
+	if err := acct.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := paper.LoadAccount(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Cash != acct.Cash || len(got.Positions) != 1 || got.Positions[0].EntryPrice != 901 ||
+		!got.Positions[0].EntryAt.Equal(at(10, 0)) || len(got.Days) != 1 {
+		t.Errorf("round trip: %+v", got)
+	}
+
+	if err := os.WriteFile(path, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := paper.LoadAccount(path, 0); err == nil {
+		t.Error("want parse error")
+	}
+}
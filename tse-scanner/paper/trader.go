@@ -0,0 +1,268 @@
+package paper
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/exchange"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+)
+
+// Exit reasons (the first three match the backtest package).
+const (
+	ExitTakeProfit = "利確"
+	ExitStopLoss   = "損切"
+	ExitTimeStop   = "時間切れ"
+	ExitClose      = "大引け"
+)
+
+// Side is the direction of a fill.
+type Side string
+
+const (
+	Buy  Side = "買"
+	Sell Side = "売"
+)
+
+// Fill is one simulated execution.
+type Fill struct {
+	At         time.Time
+	Side       Side
+	Symbol     string
+	Name       string
+	Shares     int
+	Price      float64 // 約定値（スリッページ込み）
+	Commission float64
+	Score      float64 // 買いのみ: エントリー時の急騰スコア
+	Reason     string  // 売りのみ: 決済理由
+	PnL        float64 // 売りのみ: 手数料控除後の損益
+}
+
+// String formats the fill for the log, e.g.
+// "📝 買 7203.T トヨタ自動車 100株 @3,205（スコア 72、手数料 55円）".
+func (f Fill) String() string {
+	head := fmt.Sprintf("📝 %s %s %s %d株 @%s", f.Side, f.Symbol, f.Name, f.Shares, exchange.Format(f.Price))
+	if f.Side == Buy {
+		return fmt.Sprintf("%s（スコア %.0f、手数料 %s）", head, f.Score, portfolio.Yen(f.Commission))
+	}
+	return fmt.Sprintf("%s %s %s（手数料 %s）", head, f.Reason, portfolio.SignedYen(f.PnL), portfolio.Yen(f.Commission))
+}
+
+// Trader applies Config's rules to an Account, one scan at a time.
+type Trader struct {
+	cfg  Config
+	acct *Account
+}
+
+// New returns a Trader trading acct under cfg.
+func New(cfg Config, acct *Account) *Trader {
+	return &Trader{cfg: cfg, acct: acct}
+}
+
+// Account returns the traded account.
+func (t *Trader) Account() *Account { return t.acct }
+
+// Step processes one scan taken at at: open positions are marked to quotes
+// and closed on an exit rule, then cands (sorted by score, as returned by
+// the analyzer) are bought while slots and cash remain. Orders only fill
+// while the market is trading, except the close_at_end exit, which sells at
+// the closing price on the first scan after the close. No entries are made
+// during the closing auction, whose price is not known until the close.
+func (t *Trader) Step(at time.Time, quotes []model.Quote, cands []model.Candidate) []Fill {
+	cal := calendar.Default()
+	phase := cal.PhaseAt(at)
+	day := t.day(at, cal)
+
+	latest := make(map[string]model.Quote, len(quotes))
+	for _, q := range quotes {
+		if q.Valid && q.Price > 0 {
+			latest[q.Symbol] = q
+		}
+	}
+
+	// 1. 保有ポジションの時価評価と決済
+	var fills []Fill
+	closed := make(map[string]bool)
+	kept := make([]Position, 0, len(t.acct.Positions))
+	for _, p := range t.acct.Positions {
+		q, ok := latest[p.Symbol]
+		if ok {
+			p.Last, p.LastAt = q.Price, at
+		}
+		reason := ""
+		if ok {
+			reason = t.exitReason(p, at, phase)
+		}
+		if reason == "" {
+			kept = append(kept, p)
+			continue
+		}
+		f := t.sell(p, q, at, reason)
+		if day != nil {
+			day.Realized += f.PnL
+			day.Commission += f.Commission
+			day.Sells++
+		}
+		closed[p.Symbol] = true
+		fills = append(fills, f)
+	}
+	t.acct.Positions = kept
+
+	// 2. 新規エントリー（クロージング・オークション中は約定値が決まらないため見送る）
+	if phase.Trading() && phase != calendar.PhaseClosingAuction {
+		for _, c := range cands {
+			if len(t.acct.Positions) >= t.cfg.MaxPositions || c.SurgeScore < t.cfg.MinScore {
+				break
+			}
+			if c.Plunge || !c.Valid || c.Price <= 0 || closed[c.Symbol] || t.acct.Holds(c.Symbol) {
+				continue
+			}
+			if c.StopHigh > 0 && c.Price >= c.StopHigh {
+				continue // ストップ高張り付きでは買えない
+			}
+			f, ok := t.buy(c, at)
+			if !ok {
+				continue
+			}
+			if day != nil {
+				day.Commission += f.Commission
+				day.Buys++
+			}
+			fills = append(fills, f)
+		}
+	}
+
+	if day != nil {
+		day.Equity = t.acct.Equity()
+	}
+	t.acct.Updated = at
+	return fills
+}
+
+// day returns the Day row for at, starting one on the first scan of a
+// trading day. Scans on holidays are not recorded (nil).
+func (t *Trader) day(at time.Time, cal *calendar.Calendar) *Day {
+	if !cal.IsTradingDay(at) {
+		return nil
+	}
+	date := at.In(calendar.JST).Format("2006-01-02")
+	days := t.acct.Days
+	if n := len(days); n > 0 && days[n-1].Date == date {
+		return &t.acct.Days[n-1]
+	}
+	// 前日末の評価額（= 前回スキャン時点の時価）を当日の起点にする
+	eq := t.acct.Equity()
+	t.acct.Days = append(t.acct.Days, Day{Date: date, Start: eq, Equity: eq})
+	return &t.acct.Days[len(t.acct.Days)-1]
+}
+
+func (t *Trader) exitReason(p Position, at time.Time, phase calendar.Phase) string {
+	if !at.After(p.EntryAt) {
+		return ""
+	}
+	if phase == calendar.PhaseClosed && t.cfg.CloseAtEnd {
+		return ExitClose
+	}
+	if !phase.Trading() {
+		return ""
+	}
+	ret := (p.Last - p.EntryPrice) / p.EntryPrice * 100
+	switch {
+	case t.cfg.TakeProfit > 0 && ret >= t.cfg.TakeProfit:
+		return ExitTakeProfit
+	case t.cfg.StopLoss > 0 && ret <= -t.cfg.StopLoss:
+		return ExitStopLoss
+	case t.cfg.TimeStop > 0 && at.Sub(p.EntryAt) >= time.Duration(t.cfg.TimeStop):
+		return ExitTimeStop
+	}
+	return ""
+}
+
+func (t *Trader) buy(c model.Candidate, at time.Time) (Fill, bool) {
+	price := t.fillPrice(c.Price, c.PrevClose, Buy)
+	lot := float64(t.cfg.Lot)
+	budget := math.Min(t.cfg.PositionSize.Amount(t.acct.Equity()), t.acct.Cash)
+	shares := math.Floor(budget/(price*lot)) * lot
+	for shares > 0 && shares*price+t.cfg.Commission.Fee(shares*price) > t.acct.Cash {
+		shares -= lot
+	}
+	if shares <= 0 {
+		return Fill{}, false // 1 単元も買えない
+	}
+	value := shares * price
+	fee := t.cfg.Commission.Fee(value)
+	t.acct.Cash -= value + fee
+	t.acct.Positions = append(t.acct.Positions, Position{
+		Symbol:     c.Symbol,
+		Name:       c.Name,
+		Shares:     int(shares),
+		EntryAt:    at,
+		EntryPrice: price,
+		Commission: fee,
+		Score:      c.SurgeScore,
+		Signals:    signalLabels(c.Signals),
+		Last:       c.Price,
+		LastAt:     at,
+	})
+	return Fill{At: at, Side: Buy, Symbol: c.Symbol, Name: c.Name, Shares: int(shares),
+		Price: price, Commission: fee, Score: c.SurgeScore}, true
+}
+
+func (t *Trader) sell(p Position, q model.Quote, at time.Time, reason string) Fill {
+	price := t.fillPrice(q.Price, q.PrevClose, Sell)
+	value := price * float64(p.Shares)
+	fee := t.cfg.Commission.Fee(value)
+	cost := p.EntryPrice * float64(p.Shares)
+	pnl := value - fee - cost - p.Commission
+	t.acct.Cash += value - fee
+	t.acct.Trades = append(t.acct.Trades, Trade{
+		Symbol:     p.Symbol,
+		Name:       p.Name,
+		Shares:     p.Shares,
+		EntryAt:    p.EntryAt,
+		ExitAt:     at,
+		EntryPrice: p.EntryPrice,
+		ExitPrice:  price,
+		Commission: p.Commission + fee,
+		PnL:        pnl,
+		Return:     pnl / cost * 100,
+		Reason:     reason,
+		Score:      p.Score,
+		Signals:    p.Signals,
+	})
+	return Fill{At: at, Side: Sell, Symbol: p.Symbol, Name: p.Name, Shares: p.Shares,
+		Price: price, Commission: fee, Reason: reason, PnL: pnl}
+}
+
+// fillPrice moves price SlippageTicks 呼値 against the order, within the
+// day's price-limit band. Ticks follow the standard table (not TOPIX500),
+// which is the coarser and so the more conservative assumption.
+func (t *Trader) fillPrice(price, prevClose float64, side Side) float64 {
+	p := exchange.Round(price, false)
+	for i := 0; i < t.cfg.SlippageTicks; i++ {
+		if side == Buy {
+			p += exchange.TickSize(p+1, false) // 次の呼値の刻みで上がる（3000 → 3005）
+		} else {
+			p -= exchange.TickSize(p, false)
+		}
+	}
+	if prevClose > 0 {
+		lower, upper := exchange.Band(prevClose)
+		p = math.Max(lower, math.Min(upper, p))
+	}
+	return math.Max(p, 1)
+}
+
+// signalLabels returns the labels of signals that contributed points.
+func signalLabels(signals []model.Signal) []string {
+	var labels []string
+	for _, s := range signals {
+		if s.Score > 0 {
+			labels = append(labels, s.Label)
+		}
+	}
+	return labels
+}
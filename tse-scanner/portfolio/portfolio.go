@@ -0,0 +1,360 @@
+// Package portfolio loads the user's holdings and values them against each
+// scan's quotes: unrealised and intraday P&L, the tax owed on gains by
+// account type, and whether a holding has reached its take-profit (利確) or
+// stop-loss (損切) level.
+//
+// Holdings live in a local CSV or YAML file, chosen by extension:
+//
+//	CSV : header "symbol,shares,avg_cost,account,take_profit,stop_loss,name"
+//	      （日本語見出し コード,株数,取得単価,口座,利確,損切,銘柄名 も可）
+//	YAML: positions: [{symbol, shares, avg_cost, account, take_profit, stop_loss, name}]
+//
+// take_profit and stop_loss are either a price ("3500") or a change from the
+// average cost ("+20%", "-8%"); either may be left empty.
+package portfolio
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tse-scanner/exchange"
+	"tse-scanner/model"
+	"tse-scanner/watchlist"
+)
+
+// TaxRate is the tax on realised gains in a taxable account (所得税・住民税・
+// 復興特別所得税の合計 20.315%).
+const TaxRate = 0.20315
+
+// Account is the kind of brokerage account a position is held in.
+type Account string
+
+const (
+	AccountSpecific Account = "特定"   // 特定口座（既定）
+	AccountGeneral  Account = "一般"   // 一般口座
+	AccountNISA     Account = "NISA" // NISA 口座（非課税）
+)
+
+// ParseAccount accepts the Japanese names and their romanised forms. An
+// empty value is a 特定口座.
+func ParseAccount(s string) (Account, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "特定", "特定口座", "tokutei", "specific":
+		return AccountSpecific, nil
+	case "一般", "一般口座", "ippan", "general":
+		return AccountGeneral, nil
+	case "nisa", "新nisa", "nisa口座", "つみたてnisa", "成長投資枠":
+		return AccountNISA, nil
+	default:
+		return "", fmt.Errorf("未知の口座区分です: %s（特定 / 一般 / NISA）", s)
+	}
+}
+
+// Taxable reports whether gains in the account are taxed.
+func (a Account) Taxable() bool { return a != AccountNISA }
+
+// Level is a take-profit or stop-loss level: an absolute price or a change
+// from the average cost. The zero Level is unset.
+type Level struct {
+	Price   float64 // 指値（円）
+	Percent float64 // 取得単価からの変化率（%）。Price が 0 の時のみ使う
+}
+
+// ParseLevel parses "3500", "+20%" or "-8%". An empty string is unset.
+func ParseLevel(s string) (Level, error) {
+	s = strings.TrimSpace(strings.ReplaceAll(s, ",", ""))
+	if s == "" {
+		return Level{}, nil
+	}
+	if pct, ok := strings.CutSuffix(s, "%"); ok {
+		v, err := strconv.ParseFloat(pct, 64)
+		if err != nil || v == 0 {
+			return Level{}, fmt.Errorf("水準 %q を解釈できません（例: 3500, +20%%, -8%%）", s)
+		}
+		return Level{Percent: v}, nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil || v <= 0 {
+		return Level{}, fmt.Errorf("水準 %q を解釈できません（例: 3500, +20%%, -8%%）", s)
+	}
+	return Level{Price: v}, nil
+}
+
+// IsZero reports whether the level is unset.
+func (l Level) IsZero() bool { return l.Price == 0 && l.Percent == 0 }
+
+// At returns the level's price for a position bought at avgCost (0 if
+// unset). A percentage level is rounded to the nearest 呼値.
+func (l Level) At(avgCost float64) float64 {
+	switch {
+	case l.Price > 0:
+		return l.Price
+	case l.Percent != 0:
+		return exchange.Round(avgCost*(1+l.Percent/100), true)
+	default:
+		return 0
+	}
+}
+
+// String formats the level as it is written in the portfolio file.
+func (l Level) String() string {
+	switch {
+	case l.Price > 0:
+		return strconv.FormatFloat(l.Price, 'f', -1, 64)
+	case l.Percent != 0:
+		return fmt.Sprintf("%+g%%", l.Percent)
+	default:
+		return ""
+	}
+}
+
+// Position is one holding in the portfolio file.
+type Position struct {
+	Symbol     string
+	Name       string // 省略時は気配値の銘柄名
+	Shares     int64
+	AvgCost    float64 // 平均取得単価（円）
+	Account    Account
+	TakeProfit Level // 利確ライン（未設定ならゼロ値）
+	StopLoss   Level // 損切ライン（未設定ならゼロ値）
+}
+
+// Portfolio is the list of holdings. A symbol may appear once per account.
+type Portfolio struct {
+	Positions []Position
+}
+
+// Validate checks every position and rejects a symbol held twice in the
+// same account.
+func (p Portfolio) Validate() error {
+	seen := make(map[string]bool, len(p.Positions))
+	var errs []error
+	for i, pos := range p.Positions {
+		if err := pos.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("%d 件目: %w", i+1, err))
+			continue
+		}
+		key := pos.Symbol + "\x00" + string(pos.Account)
+		if seen[key] {
+			errs = append(errs, fmt.Errorf("%d 件目: %s（%s口座）が重複しています", i+1, pos.Symbol, pos.Account))
+		}
+		seen[key] = true
+	}
+	return errors.Join(errs...)
+}
+
+func (pos Position) validate() error {
+	if err := watchlist.ValidateSymbol(pos.Symbol); err != nil {
+		return err
+	}
+	if pos.Shares <= 0 {
+		return fmt.Errorf("%s: 株数は正の整数で指定してください", pos.Symbol)
+	}
+	if pos.AvgCost <= 0 {
+		return fmt.Errorf("%s: 取得単価は正の値で指定してください", pos.Symbol)
+	}
+	if tp := pos.TakeProfit; !tp.IsZero() && tp.At(pos.AvgCost) <= pos.AvgCost {
+		return fmt.Errorf("%s: 利確ライン %s が取得単価以下です", pos.Symbol, tp)
+	}
+	if sl := pos.StopLoss; !sl.IsZero() && sl.At(pos.AvgCost) >= pos.AvgCost {
+		return fmt.Errorf("%s: 損切ライン %s が取得単価以上です", pos.Symbol, sl)
+	}
+	return nil
+}
+
+// Holds reports whether symbol is in the portfolio.
+func (p Portfolio) Holds(symbol string) bool {
+	for _, pos := range p.Positions {
+		if pos.Symbol == symbol {
+			return true
+		}
+	}
+	return false
+}
+
+// Stocks returns the held symbols (once each, in file order) so they can be
+// fetched with the watchlist.
+func (p Portfolio) Stocks() []model.Stock {
+	var stocks []model.Stock
+	seen := make(map[string]bool, len(p.Positions))
+	for _, pos := range p.Positions {
+		if !seen[pos.Symbol] {
+			seen[pos.Symbol] = true
+			stocks = append(stocks, model.Stock{Symbol: pos.Symbol, Name: pos.Name})
+		}
+	}
+	return stocks
+}
+
+// MarkHeld sets Held on every candidate in the portfolio.
+func (p Portfolio) MarkHeld(cands []model.Candidate) {
+	for i := range cands {
+		cands[i].Held = p.Holds(cands[i].Symbol)
+	}
+}
+
+// Load reads and validates a portfolio file (.csv / .yaml / .yml).
+func Load(path string) (Portfolio, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("ポートフォリオを開けません: %w", err)
+	}
+	var p Portfolio
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		p, err = parseCSV(bytes.NewReader(b))
+	case ".yaml", ".yml":
+		p, err = parseYAML(b)
+	default:
+		return Portfolio{}, fmt.Errorf("未対応のポートフォリオ形式です: %s（.csv / .yaml）", path)
+	}
+	if err == nil {
+		err = p.Validate()
+	}
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ---- file document types ----
+
+// entry is one position as written in either file format.
+type entry struct {
+	Symbol     string `yaml:"symbol"`
+	Name       string `yaml:"name"`
+	Shares     string `yaml:"shares"`
+	AvgCost    string `yaml:"avg_cost"`
+	Account    string `yaml:"account"`
+	TakeProfit string `yaml:"take_profit"`
+	StopLoss   string `yaml:"stop_loss"`
+}
+
+func (e entry) position() (Position, error) {
+	pos := Position{Symbol: watchlist.NormalizeSymbol(e.Symbol), Name: strings.TrimSpace(e.Name)}
+	var err error
+	if pos.Shares, err = strconv.ParseInt(strings.ReplaceAll(strings.TrimSpace(e.Shares), ",", ""), 10, 64); err != nil {
+		return Position{}, fmt.Errorf("%s: 株数 %q を解釈できません", pos.Symbol, e.Shares)
+	}
+	if pos.AvgCost, err = strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(e.AvgCost), ",", ""), 64); err != nil {
+		return Position{}, fmt.Errorf("%s: 取得単価 %q を解釈できません", pos.Symbol, e.AvgCost)
+	}
+	if pos.Account, err = ParseAccount(e.Account); err != nil {
+		return Position{}, fmt.Errorf("%s: %w", pos.Symbol, err)
+	}
+	if pos.TakeProfit, err = ParseLevel(e.TakeProfit); err != nil {
+		return Position{}, fmt.Errorf("%s: 利確: %w", pos.Symbol, err)
+	}
+	if pos.StopLoss, err = ParseLevel(e.StopLoss); err != nil {
+		return Position{}, fmt.Errorf("%s: 損切: %w", pos.Symbol, err)
+	}
+	return pos, nil
+}
+
+func parseYAML(b []byte) (Portfolio, error) {
+	var doc struct {
+		Positions []entry `yaml:"positions"`
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(&doc); err != nil && err != io.EOF {
+		return Portfolio{}, fmt.Errorf("パースエラー: %w", err)
+	}
+	return fromEntries(doc.Positions)
+}
+
+// csvColumns maps accepted header names to entry fields.
+var csvColumns = map[string]string{
+	"symbol": "symbol", "コード": "symbol", "銘柄コード": "symbol",
+	"name": "name", "銘柄名": "name",
+	"shares": "shares", "株数": "shares", "数量": "shares",
+	"avg_cost": "avg_cost", "取得単価": "avg_cost", "平均取得単価": "avg_cost",
+	"account": "account", "口座": "account", "口座区分": "account",
+	"take_profit": "take_profit", "利確": "take_profit",
+	"stop_loss": "stop_loss", "損切": "stop_loss",
+}
+
+func parseCSV(r io.Reader) (Portfolio, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Portfolio{}, fmt.Errorf("CSV パースエラー: %w", err)
+	}
+	if len(records) == 0 {
+		return Portfolio{}, nil
+	}
+
+	// 数値列は順序に依存しやすいため、ヘッダー行を必須とする
+	cols := make([]string, len(records[0]))
+	for i, h := range records[0] {
+		cols[i] = csvColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))]
+	}
+	for _, required := range []string{"symbol", "shares", "avg_cost"} {
+		if !contains(cols, required) {
+			return Portfolio{}, fmt.Errorf("CSV の見出しに %s 列がありません", required)
+		}
+	}
+
+	entries := make([]entry, 0, len(records)-1)
+	for _, rec := range records[1:] {
+		var e entry
+		for i, v := range rec {
+			if i >= len(cols) {
+				break
+			}
+			switch cols[i] {
+			case "symbol":
+				e.Symbol = v
+			case "name":
+				e.Name = v
+			case "shares":
+				e.Shares = v
+			case "avg_cost":
+				e.AvgCost = v
+			case "account":
+				e.Account = v
+			case "take_profit":
+				e.TakeProfit = v
+			case "stop_loss":
+				e.StopLoss = v
+			}
+		}
+		entries = append(entries, e)
+	}
+	return fromEntries(entries)
+}
+
+func fromEntries(entries []entry) (Portfolio, error) {
+	p := Portfolio{Positions: make([]Position, 0, len(entries))}
+	var errs []error
+	for i, e := range entries {
+		pos, err := e.position()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%d 件目: %w", i+1, err))
+			continue
+		}
+		p.Positions = append(p.Positions, pos)
+	}
+	return p, errors.Join(errs...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
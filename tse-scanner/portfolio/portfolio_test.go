@@ -0,0 +1,146 @@
+package portfolio_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+)
+
+// ---- helpers ----
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func quote(symbol string, price, prevClose float64) model.Quote {
+	return model.Quote{Symbol: symbol, Name: "銘柄" + symbol, Price: price, PrevClose: prevClose, Valid: true}
+}
+
+// ---- tests ----
+
+func TestLoad_CSVWithJapaneseHeader(t *testing.T) {
+	path := writeFile(t, "holdings.csv", "\ufeffコード,株数,取得単価,口座,利確,損切\n"+
+		"7203.t,100,\"3,000\",特定,+20%,2700\n"+
+		"# コメント行\n"+
+		"9984.T,200,8000.5,NISA,,-10%\n")
+	p, err := portfolio.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(p.Positions) != 2 {
+		t.Fatalf("want 2 positions, got %+v", p.Positions)
+	}
+	toyota := p.Positions[0]
+	if toyota.Symbol != "7203.T" || toyota.Shares != 100 || toyota.AvgCost != 3000 || toyota.Account != portfolio.AccountSpecific {
+		t.Errorf("unexpected position: %+v", toyota)
+	}
+	if toyota.TakeProfit.At(toyota.AvgCost) != 3600 || toyota.StopLoss.At(toyota.AvgCost) != 2700 {
+		t.Errorf("levels: tp %v sl %v", toyota.TakeProfit, toyota.StopLoss)
+	}
+	if sb := p.Positions[1]; sb.Account != portfolio.AccountNISA || !sb.TakeProfit.IsZero() || sb.StopLoss.Percent != -10 {
+		t.Errorf("unexpected position: %+v", sb)
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	path := writeFile(t, "holdings.yaml", `positions:
+  - {symbol: 6758.T, name: ソニーG, shares: 50, avg_cost: 12000, account: 一般, take_profit: 15000}
+`)
+	p, err := portfolio.Load(path)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if pos := p.Positions[0]; pos.Name != "ソニーG" || pos.Account != portfolio.AccountGeneral || pos.TakeProfit.Price != 15000 {
+		t.Errorf("unexpected position: %+v", pos)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	cases := map[string]string{
+		"bad symbol":      "symbol,shares,avg_cost\n7203,100,3000\n",
+		"zero shares":     "symbol,shares,avg_cost\n7203.T,0,3000\n",
+		"unknown account": "symbol,shares,avg_cost,account\n7203.T,100,3000,iDeCo\n",
+		"tp below cost":   "symbol,shares,avg_cost,take_profit\n7203.T,100,3000,2900\n",
+		"sl above cost":   "symbol,shares,avg_cost,stop_loss\n7203.T,100,3000,+5%\n",
+		"duplicate":       "symbol,shares,avg_cost\n7203.T,100,3000\n7203.T,100,3100\n",
+		"no header":       "7203.T,100,3000\n",
+	}
+	for name, content := range cases {
+		if _, err := portfolio.Load(writeFile(t, "p.csv", content)); err == nil {
+			t.Errorf("%s: want error", name)
+		}
+	}
+	// 同じ銘柄でも口座が違えば別ポジション
+	if _, err := portfolio.Load(writeFile(t, "p.csv", "symbol,shares,avg_cost,account\n7203.T,100,3000,特定\n7203.T,100,3100,NISA\n")); err != nil {
+		t.Errorf("same symbol in two accounts: %v", err)
+	}
+}
+
+func TestValuate(t *testing.T) {
+	p := portfolio.Portfolio{Positions: []portfolio.Position{
+		{Symbol: "7203.T", Shares: 100, AvgCost: 3000, Account: portfolio.AccountSpecific,
+			TakeProfit: portfolio.Level{Percent: 10}},
+		{Symbol: "9984.T", Shares: 10, AvgCost: 9000, Account: portfolio.AccountNISA,
+			StopLoss: portfolio.Level{Price: 8000}},
+		{Symbol: "6758.T", Shares: 10, AvgCost: 12000, Account: portfolio.AccountSpecific},
+	}}
+	s := p.Valuate([]model.Quote{quote("7203.T", 3300, 3200), quote("9984.T", 7900, 8100)})
+
+	if s.Valid != 2 || len(s.Holdings) != 3 || s.Holdings[2].Valid {
+		t.Fatalf("unexpected holdings: %+v", s.Holdings)
+	}
+	toyota, sb := s.Holdings[0], s.Holdings[1]
+	if toyota.PnL != 30000 || toyota.PnLPercent != 10 || toyota.DayPnL != 10000 || toyota.Name != "銘柄7203.T" {
+		t.Errorf("toyota: %+v", toyota)
+	}
+	if toyota.Hit != portfolio.HitTakeProfit || toyota.HitPrice != 3300 {
+		t.Errorf("toyota hit: %v %v", toyota.Hit, toyota.HitPrice)
+	}
+	if sb.PnL != -11000 || sb.Tax != 0 || sb.Hit != portfolio.HitStopLoss {
+		t.Errorf("softbank: %+v", sb)
+	}
+	// 課税口座の利益のみ課税、取得失敗の銘柄は合計に含めない
+	if s.Cost != 390000 || s.PnL != 19000 || s.DayPnL != 8000 || s.Tax != 30000*portfolio.TaxRate {
+		t.Errorf("totals: %+v", s)
+	}
+	if hits := s.Hits(); len(hits) != 2 {
+		t.Errorf("want 2 hits, got %d", len(hits))
+	}
+}
+
+func TestMarkHeldAndStocks(t *testing.T) {
+	p := portfolio.Portfolio{Positions: []portfolio.Position{
+		{Symbol: "7203.T", Account: portfolio.AccountSpecific},
+		{Symbol: "7203.T", Account: portfolio.AccountNISA},
+	}}
+	if stocks := p.Stocks(); len(stocks) != 1 || stocks[0].Symbol != "7203.T" {
+		t.Errorf("Stocks = %+v", stocks)
+	}
+	cands := []model.Candidate{{Quote: model.Quote{Symbol: "7203.T"}}, {Quote: model.Quote{Symbol: "6758.T"}}}
+	p.MarkHeld(cands)
+	if !cands[0].Held || cands[1].Held {
+		t.Errorf("Held = %v, %v", cands[0].Held, cands[1].Held)
+	}
+}
+
+func TestYen(t *testing.T) {
+	for v, want := range map[float64]string{
+		0: "0円", 999: "999円", 1000: "1,000円", -1234567.4: "-1,234,567円",
+	} {
+		if got := portfolio.Yen(v); got != want {
+			t.Errorf("Yen(%v) = %q, want %q", v, got, want)
+		}
+	}
+	if got := portfolio.SignedYen(12000); !strings.HasPrefix(got, "+12,000") {
+		t.Errorf("SignedYen = %q", got)
+	}
+}
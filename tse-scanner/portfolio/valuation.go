@@ -0,0 +1,135 @@
+package portfolio
+
+import (
+	"math"
+	"strconv"
+
+	"tse-scanner/model"
+)
+
+// Hit is a take-profit or stop-loss level a holding has reached.
+type Hit string
+
+const (
+	HitNone       Hit = ""
+	HitTakeProfit Hit = "利確"
+	HitStopLoss   Hit = "損切"
+)
+
+// Holding is a Position valued at one scan's quote.
+type Holding struct {
+	Position
+	Price      float64 // 現在値（気配値なしなら 0）
+	Valid      bool    // 有効な気配値がある
+	Cost       float64 // 取得金額
+	Value      float64 // 評価額
+	PnL        float64 // 含み損益
+	PnLPercent float64 // 含み損益率（%）
+	DayPnL     float64 // 前日終値からの損益
+	Tax        float64 // 売却した場合の税額の見込み（課税口座の利益のみ）
+	Hit        Hit     // 到達した利確・損切ライン
+	HitPrice   float64 // Hit の水準（円）
+}
+
+// Summary is the whole portfolio valued at one scan. Totals only include
+// holdings with a valid quote.
+type Summary struct {
+	Holdings   []Holding
+	Valid      int // 評価できた保有銘柄数
+	Cost       float64
+	Value      float64
+	PnL        float64
+	PnLPercent float64
+	DayPnL     float64
+	Tax        float64
+}
+
+// Valuate values every position at the matching quote. Positions without a
+// valid quote are listed but left out of the totals.
+func (p Portfolio) Valuate(quotes []model.Quote) Summary {
+	bySymbol := make(map[string]model.Quote, len(quotes))
+	for _, q := range quotes {
+		if q.Valid {
+			bySymbol[q.Symbol] = q
+		}
+	}
+
+	s := Summary{Holdings: make([]Holding, 0, len(p.Positions))}
+	for _, pos := range p.Positions {
+		h := Holding{Position: pos, Cost: pos.AvgCost * float64(pos.Shares)}
+		q, ok := bySymbol[pos.Symbol]
+		if !ok {
+			s.Holdings = append(s.Holdings, h)
+			continue
+		}
+		if h.Name == "" {
+			h.Name = q.Name
+		}
+		shares := float64(pos.Shares)
+		h.Price, h.Valid = q.Price, true
+		h.Value = q.Price * shares
+		h.PnL = h.Value - h.Cost
+		h.PnLPercent = h.PnL / h.Cost * 100
+		if q.PrevClose > 0 {
+			h.DayPnL = (q.Price - q.PrevClose) * shares
+		}
+		if pos.Account.Taxable() && h.PnL > 0 {
+			h.Tax = h.PnL * TaxRate
+		}
+		h.Hit, h.HitPrice = pos.hit(q.Price)
+
+		s.Valid++
+		s.Cost += h.Cost
+		s.Value += h.Value
+		s.PnL += h.PnL
+		s.DayPnL += h.DayPnL
+		s.Tax += h.Tax
+		s.Holdings = append(s.Holdings, h)
+	}
+	if s.Cost > 0 {
+		s.PnLPercent = s.PnL / s.Cost * 100
+	}
+	return s
+}
+
+// Hits returns the holdings that reached a take-profit or stop-loss level.
+func (s Summary) Hits() []Holding {
+	var hits []Holding
+	for _, h := range s.Holdings {
+		if h.Hit != HitNone {
+			hits = append(hits, h)
+		}
+	}
+	return hits
+}
+
+// hit reports the level reached at price, if any.
+func (pos Position) hit(price float64) (Hit, float64) {
+	if tp := pos.TakeProfit; !tp.IsZero() && price >= tp.At(pos.AvgCost) {
+		return HitTakeProfit, tp.At(pos.AvgCost)
+	}
+	if sl := pos.StopLoss; !sl.IsZero() && price <= sl.At(pos.AvgCost) {
+		return HitStopLoss, sl.At(pos.AvgCost)
+	}
+	return HitNone, 0
+}
+
+// Yen formats an amount as whole yen with thousands separators ("1,234,000円").
+func Yen(v float64) string {
+	n := strconv.FormatFloat(math.Abs(math.Round(v)), 'f', 0, 64)
+	for i := len(n) - 3; i > 0; i -= 3 {
+		n = n[:i] + "," + n[i:]
+	}
+	if math.Round(v) < 0 {
+		n = "-" + n
+	}
+	return n + "円"
+}
+
+// SignedYen is Yen with an explicit sign, for P&L ("+12,000円").
+func SignedYen(v float64) string {
+	if math.Round(v) > 0 {
+		return "+" + Yen(v)
+	}
+	return Yen(v)
+}
@@ -0,0 +1,213 @@
+// Package sector aggregates each scan by 業種 (sector): average and median
+// change, breadth (share of advancing issues) and volume-weighted strength,
+// and tracks how each sector's strength moves over the session so rotating
+// sectors can be ranked.
+//
+// Strength weights each stock's change by its traded value (price × volume),
+// so a sector led by its heavyweights ranks above one lifted by a few thinly
+// traded names.
+package sector
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/model"
+)
+
+// DefaultWindow is the look-back of Momentum when NewTracker is given 0.
+const DefaultWindow = 15 * time.Minute
+
+// Stats aggregates one sector within a scan.
+type Stats struct {
+	Sector       string
+	Count        int     // 有効な気配値のある銘柄数
+	Advancers    int     // 上昇銘柄数
+	Decliners    int     // 下落銘柄数
+	AvgChange    float64 // 平均騰落率（%）
+	MedianChange float64 // 騰落率の中央値（%）
+	MaxChange    float64 // 最大騰落率（%）
+	AvgScore     float64 // 平均急騰スコア（AggregateCandidates のみ）
+	Breadth      float64 // 上昇銘柄の割合（%）
+	Strength     float64 // 売買代金加重の平均騰落率（%）
+	Rank         int     // Strength の順位（1 始まり）
+
+	// Tracker.Update が設定する（未追跡ならゼロ値）
+	Momentum     float64 // 直近 window の Strength の変化（%pt）
+	MomentumRank int     // Momentum の順位（1 始まり）
+	HasMomentum  bool
+}
+
+// Aggregate groups valid quotes by sector, sorted by Strength (strongest
+// first) with Rank set. Quotes without a sector are ignored.
+func Aggregate(quotes []model.Quote) []Stats {
+	agg := make(aggregator)
+	for _, q := range quotes {
+		if q.Valid {
+			agg.add(q, 0)
+		}
+	}
+	return agg.stats()
+}
+
+// AggregateCandidates is Aggregate over scored candidates, additionally
+// setting AvgScore. Candidates are scored from valid quotes only, so every
+// candidate with a sector is counted.
+func AggregateCandidates(cands []model.Candidate) []Stats {
+	agg := make(aggregator)
+	for _, c := range cands {
+		agg.add(c.Quote, c.SurgeScore)
+	}
+	return agg.stats()
+}
+
+// aggregator accumulates Stats per sector.
+type aggregator map[string]*acc
+
+type acc struct {
+	Stats
+	changes     []float64
+	value, wsum float64 // 売買代金の合計、売買代金 × 騰落率の合計
+}
+
+func (agg aggregator) add(q model.Quote, score float64) {
+	if q.Sector == "" {
+		return
+	}
+	a := agg[q.Sector]
+	if a == nil {
+		a = &acc{Stats: Stats{Sector: q.Sector, MaxChange: q.ChangePercent}}
+		agg[q.Sector] = a
+	}
+	a.Count++
+	a.AvgChange += q.ChangePercent
+	a.AvgScore += score
+	a.MaxChange = max(a.MaxChange, q.ChangePercent)
+	a.changes = append(a.changes, q.ChangePercent)
+	switch {
+	case q.ChangePercent > 0:
+		a.Advancers++
+	case q.ChangePercent < 0:
+		a.Decliners++
+	}
+	if v := q.Price * float64(q.Volume); v > 0 {
+		a.value += v
+		a.wsum += v * q.ChangePercent
+	}
+}
+
+func (agg aggregator) stats() []Stats {
+	out := make([]Stats, 0, len(agg))
+	for _, a := range agg {
+		n := float64(a.Count)
+		a.AvgChange /= n
+		a.AvgScore /= n
+		a.MedianChange = median(a.changes)
+		a.Breadth = float64(a.Advancers) / n * 100
+		a.Strength = a.AvgChange // 出来高がなければ単純平均で代用
+		if a.value > 0 {
+			a.Strength = a.wsum / a.value
+		}
+		out = append(out, a.Stats)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Strength != out[j].Strength {
+			return out[i].Strength > out[j].Strength
+		}
+		return out[i].Sector < out[j].Sector
+	})
+	for i := range out {
+		out[i].Rank = i + 1
+	}
+	return out
+}
+
+// median returns the median of v, reordering it in place.
+func median(v []float64) float64 {
+	if len(v) == 0 {
+		return 0
+	}
+	sort.Float64s(v)
+	mid := len(v) / 2
+	if len(v)%2 == 1 {
+		return v[mid]
+	}
+	return (v[mid-1] + v[mid]) / 2
+}
+
+// Tracker remembers each sector's Strength over the current session so the
+// change over a window (sector momentum) can be ranked. It is safe for
+// concurrent use.
+type Tracker struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	day    string             // 記録中の立会日（"2006-01-02"）
+	series map[string][]point // 業種 → 当日の Strength（古い順）
+}
+
+type point struct {
+	at       time.Time
+	strength float64
+}
+
+// NewTracker returns a Tracker measuring momentum over window
+// (DefaultWindow when zero).
+func NewTracker(window time.Duration) *Tracker {
+	if window <= 0 {
+		window = DefaultWindow
+	}
+	return &Tracker{window: window, series: make(map[string][]point)}
+}
+
+// Update records stats observed at at and fills in Momentum: the change in
+// Strength since the latest observation at least one window old, or since
+// the first scan of the day early in the session. History resets at the
+// start of each day. MomentumRank orders sectors by Momentum (1 = fastest
+// rising); stats keeps its Strength order.
+func (t *Tracker) Update(at time.Time, stats []Stats) []Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if day := at.In(calendar.JST).Format("2006-01-02"); day != t.day {
+		t.day, t.series = day, make(map[string][]point)
+	}
+
+	out := make([]Stats, len(stats))
+	copy(out, stats)
+	var tracked []int
+	for i := range out {
+		s := &out[i]
+		series := t.series[s.Sector]
+		if len(series) > 0 {
+			ref := series[0]
+			for _, p := range series {
+				if p.at.After(at.Add(-t.window)) {
+					break
+				}
+				ref = p
+			}
+			s.Momentum, s.HasMomentum = s.Strength-ref.strength, true
+			tracked = append(tracked, i)
+		}
+		t.series[s.Sector] = append(trim(series, at.Add(-2*t.window)), point{at, s.Strength})
+	}
+
+	sort.SliceStable(tracked, func(a, b int) bool { return out[tracked[a]].Momentum > out[tracked[b]].Momentum })
+	for rank, i := range tracked {
+		out[i].MomentumRank = rank + 1
+	}
+	return out
+}
+
+// trim drops points older than cutoff, keeping the newest of them so a
+// reference point at least one window old remains available.
+func trim(series []point, cutoff time.Time) []point {
+	i := 0
+	for i+1 < len(series) && !series[i+1].at.After(cutoff) {
+		i++
+	}
+	return series[i:]
+}
@@ -0,0 +1,117 @@
+package sector_test
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"tse-scanner/calendar"
+	"tse-scanner/model"
+	"tse-scanner/sector"
+)
+
+// ---- helpers ----
+
+func quote(sym, sec string, change, price float64, volume int64) model.Quote {
+	return model.Quote{Symbol: sym, Sector: sec, ChangePercent: change, Price: price, Volume: volume, Valid: true}
+}
+
+func near(a, b float64) bool { return math.Abs(a-b) < 1e-9 }
+
+var open = time.Date(2025, 6, 13, 9, 0, 0, 0, calendar.JST)
+
+// ---- tests ----
+
+func TestAggregate_StatsAndRanking(t *testing.T) {
+	stats := sector.Aggregate([]model.Quote{
+		quote("A", "銀行業", 1, 100, 100),
+		quote("B", "銀行業", -1, 100, 100),
+		quote("C", "電気機器", 4, 1000, 900), // 売買代金の大半を占める
+		quote("D", "電気機器", 0, 100, 100),
+		quote("E", "電気機器", 1, 100, 100),
+		quote("F", "電気機器", -2, 100, 0), // 出来高なしは Strength に寄与しない
+		{Symbol: "G", Sector: "電気機器", ChangePercent: 9},
+		quote("H", "", 5, 100, 100),
+	})
+	if len(stats) != 2 {
+		t.Fatalf("want 2 sectors, got %+v", stats)
+	}
+	el := stats[0]
+	if el.Sector != "電気機器" || el.Rank != 1 || stats[1].Rank != 2 {
+		t.Fatalf("want 電気機器 ranked first, got %+v", stats)
+	}
+	if el.Count != 4 || el.Advancers != 2 || el.Decliners != 1 || !near(el.Breadth, 50) {
+		t.Errorf("breadth: %+v", el)
+	}
+	if !near(el.AvgChange, 0.75) || !near(el.MedianChange, 0.5) {
+		t.Errorf("avg %v median %v, want 0.75 / 0.5", el.AvgChange, el.MedianChange)
+	}
+	// (900000×4 + 10000×0 + 10000×1) / 920000
+	if want := 3610000.0 / 920000; !near(el.Strength, want) {
+		t.Errorf("strength: got %v, want %v", el.Strength, want)
+	}
+	if bank := stats[1]; !near(bank.Strength, 0) || !near(bank.MedianChange, 0) {
+		t.Errorf("bank: %+v", bank)
+	}
+}
+
+func TestAggregate_StrengthFallsBackToAverage(t *testing.T) {
+	stats := sector.Aggregate([]model.Quote{quote("A", "銀行業", 1, 0, 0), quote("B", "銀行業", 2, 0, 0)})
+	if !near(stats[0].Strength, 1.5) {
+		t.Errorf("want average change without volume, got %v", stats[0].Strength)
+	}
+}
+
+func TestAggregateCandidates_ScoreAndMaxChange(t *testing.T) {
+	stats := sector.AggregateCandidates([]model.Candidate{
+		{Quote: quote("A", "銀行業", -1, 100, 100), SurgeScore: 10},
+		{Quote: quote("B", "銀行業", -3, 100, 100), SurgeScore: 30},
+		{Quote: quote("C", "", 5, 100, 100), SurgeScore: 90},
+	})
+	if len(stats) != 1 {
+		t.Fatalf("want 1 sector, got %+v", stats)
+	}
+	if s := stats[0]; !near(s.AvgScore, 20) || !near(s.MaxChange, -1) || !near(s.AvgChange, -2) || s.Decliners != 2 {
+		t.Errorf("unexpected stats: %+v", s)
+	}
+}
+
+func TestTracker_MomentumOverWindow(t *testing.T) {
+	tr := sector.NewTracker(10 * time.Minute)
+	scan := func(at time.Time, bank, elec float64) []sector.Stats {
+		return tr.Update(at, sector.Aggregate([]model.Quote{
+			quote("A", "銀行業", bank, 100, 100),
+			quote("B", "電気機器", elec, 100, 100),
+		}))
+	}
+
+	first := scan(open, 1, 0)
+	for _, s := range first {
+		if s.HasMomentum || s.MomentumRank != 0 {
+			t.Errorf("first scan has no momentum: %+v", s)
+		}
+	}
+	// 1 window 未満なら当日最初のスキャンと比べる
+	early := scan(open.Add(5*time.Minute), 1.5, 1)
+	if early[0].Sector != "銀行業" || !near(early[0].Momentum, 0.5) || !near(early[1].Momentum, 1) {
+		t.Errorf("early: %+v", early)
+	}
+	if early[1].MomentumRank != 1 || early[0].MomentumRank != 2 {
+		t.Errorf("want 電気機器 rising fastest: %+v", early)
+	}
+	// 以降は window 前の直近の観測と比べる
+	later := scan(open.Add(16*time.Minute), 1, 3)
+	if later[0].Sector != "電気機器" || !near(later[0].Momentum, 2) || !near(later[1].Momentum, -0.5) {
+		t.Errorf("later: %+v", later)
+	}
+}
+
+func TestTracker_ResetsEachDay(t *testing.T) {
+	tr := sector.NewTracker(0)
+	qs := []model.Quote{quote("A", "銀行業", 1, 100, 100)}
+	tr.Update(open, sector.Aggregate(qs))
+	next := tr.Update(open.AddDate(0, 0, 3), sector.Aggregate(qs))
+	if next[0].HasMomentum {
+		t.Errorf("yesterday's scans should not count: %+v", next[0])
+	}
+}
@@ -0,0 +1,55 @@
+package tui
+
+import "strings"
+
+// blocks are the lower eighth blocks used to draw partial chart cells.
+var blocks = []rune(" ▁▂▃▄▅▆▇█")
+
+// Chart draws prices as a filled area chart of width columns and height
+// rows (top row first). Prices are resampled to one value per column; a flat
+// series is drawn at half height.
+func Chart(prices []float64, width, height int) []string {
+	if len(prices) == 0 || width <= 0 || height <= 0 {
+		return nil
+	}
+	cols := resample(prices, width)
+	lo, hi := cols[0], cols[0]
+	for _, p := range cols {
+		lo, hi = min(lo, p), max(hi, p)
+	}
+
+	// 各列の高さを 1/8 行単位で求める（最低 1 目盛りは描く）
+	levels := make([]int, len(cols))
+	steps := height * 8
+	for i, p := range cols {
+		if hi == lo {
+			levels[i] = steps / 2
+			continue
+		}
+		levels[i] = 1 + int((p-lo)/(hi-lo)*float64(steps-1)+0.5)
+	}
+
+	rows := make([]string, height)
+	for r := 0; r < height; r++ {
+		base := (height - 1 - r) * 8 // この行の下端の目盛り
+		var b strings.Builder
+		for _, l := range levels {
+			b.WriteRune(blocks[min(max(l-base, 0), 8)])
+		}
+		rows[r] = b.String()
+	}
+	return rows
+}
+
+// resample maps prices onto n columns, taking the last price in each bucket.
+// Series shorter than n are left unstretched.
+func resample(prices []float64, n int) []float64 {
+	if len(prices) <= n {
+		return prices
+	}
+	out := make([]float64, n)
+	for i := range out {
+		out[i] = prices[(i+1)*len(prices)/n-1]
+	}
+	return out
+}
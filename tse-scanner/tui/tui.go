@@ -0,0 +1,578 @@
+// Package tui is the interactive full-screen terminal UI (-tui).
+//
+// The scan loop publishes each completed scan to a Program; the UI keeps the
+// cursor on the same stock across scans and lets the user re-sort, filter by
+// sector and drill into one candidate's signals and recent price chart.
+// Rendering is delegated to Bubble Tea, which only repaints changed lines.
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+
+	"tse-scanner/calendar"
+	"tse-scanner/exchange"
+	"tse-scanner/history"
+	"tse-scanner/model"
+	"tse-scanner/portfolio"
+)
+
+// DefaultChartWindow is how far back the detail pane's price chart looks.
+const DefaultChartWindow = 8 * time.Hour
+
+// ANSI escape codes
+const (
+	reset   = "\033[0m"
+	bold    = "\033[1m"
+	dim     = "\033[2m"
+	reverse = "\033[7m"
+	red     = "\033[31m"
+	green   = "\033[32m"
+	yellow  = "\033[33m"
+	cyan    = "\033[36m"
+)
+
+// SortKey is a column the candidate table can be sorted by.
+type SortKey int
+
+const (
+	SortScore SortKey = iota
+	SortChange
+	SortVolumeRatio
+)
+
+var sortNames = [...]string{"スコア", "騰落率", "出来高比"}
+
+func (k SortKey) String() string { return sortNames[k] }
+
+// Scan is one completed scan as shown by the UI. It is also the message that
+// delivers the scan to the Model.
+type Scan struct {
+	At         time.Time
+	Universe   int // スキャン対象の銘柄数
+	Candidates []model.Candidate
+	Report     *model.ScanReport  // nil なら取得率を表示しない
+	Portfolio  *portfolio.Summary // nil なら保有損益を表示しない
+}
+
+type (
+	logMsg   string
+	chartMsg struct {
+		symbol string
+		at     time.Time // 取得時点のスキャン時刻（古い結果を捨てるため）
+		prices []float64
+		err    error
+	}
+)
+
+// Model is the Bubble Tea model of the UI.
+type Model struct {
+	hist        history.Reader // nil ならチャートなし
+	chartWindow time.Duration
+
+	scan     Scan
+	rows     []model.Candidate // ソート・絞り込み後の表示行
+	sortKey  SortKey
+	ascend   bool
+	sectors  []string // 現在のスキャンに含まれる業種
+	sector   string   // 絞り込み中の業種（空なら全業種）
+	cursor   int
+	offset   int    // 表の先頭に表示している行
+	selected string // カーソル位置の銘柄（スキャン間で維持）
+	detail   bool
+	width    int
+	height   int
+	status   string
+	charts   map[string]chartMsg
+}
+
+// New returns a Model. hist may be nil, in which case no chart is drawn.
+func New(hist history.Reader) Model {
+	return Model{
+		hist:        hist,
+		chartWindow: DefaultChartWindow,
+		detail:      true,
+		width:       100,
+		height:      30,
+		status:      "初回スキャン中...",
+		charts:      make(map[string]chartMsg),
+	}
+}
+
+// Init implements tea.Model.
+func (m Model) Init() tea.Cmd { return nil }
+
+// Update implements tea.Model.
+func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.clampOffset()
+	case Scan:
+		m.scan = msg
+		m.charts = make(map[string]chartMsg)
+		m.status = ""
+		m.collectSectors()
+		m.refresh()
+		return m, m.loadChart()
+	case logMsg:
+		m.status = string(msg)
+	case chartMsg:
+		if msg.at.Equal(m.scan.At) {
+			m.charts[msg.symbol] = msg
+		}
+	case tea.KeyMsg:
+		return m.handleKey(msg)
+	}
+	return m, nil
+}
+
+func (m Model) handleKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c", "esc":
+		return m, tea.Quit
+	case "up", "k":
+		m.move(-1)
+	case "down", "j":
+		m.move(1)
+	case "pgup", "ctrl+u":
+		m.move(-m.tableHeight())
+	case "pgdown", "ctrl+d":
+		m.move(m.tableHeight())
+	case "home", "g":
+		m.move(-len(m.rows))
+	case "end", "G":
+		m.move(len(m.rows))
+	case "1":
+		m.setSort(SortScore)
+	case "2":
+		m.setSort(SortChange)
+	case "3":
+		m.setSort(SortVolumeRatio)
+	case "s":
+		m.setSort((m.sortKey + 1) % SortKey(len(sortNames)))
+	case "r":
+		m.ascend = !m.ascend
+		m.refresh()
+	case "f":
+		m.cycleSector(1)
+	case "F":
+		m.cycleSector(-1)
+	case "a":
+		m.sector = ""
+		m.refresh()
+	case "enter", "tab", "d":
+		m.detail = !m.detail
+		m.clampOffset()
+	default:
+		return m, nil
+	}
+	return m, m.loadChart()
+}
+
+func (m *Model) move(delta int) {
+	if len(m.rows) == 0 {
+		return
+	}
+	m.cursor = min(max(m.cursor+delta, 0), len(m.rows)-1)
+	m.selected = m.rows[m.cursor].Symbol
+	m.clampOffset()
+}
+
+func (m *Model) setSort(k SortKey) {
+	if m.sortKey == k {
+		m.ascend = !m.ascend
+	} else {
+		m.sortKey, m.ascend = k, false
+	}
+	m.refresh()
+}
+
+// cycleSector steps the sector filter through "全業種" and every sector
+// present in the current scan.
+func (m *Model) cycleSector(step int) {
+	opts := append([]string{""}, m.sectors...)
+	i := 0
+	for j, s := range opts {
+		if s == m.sector {
+			i = j
+		}
+	}
+	m.sector = opts[(i+step+len(opts))%len(opts)]
+	m.refresh()
+}
+
+func (m *Model) collectSectors() {
+	seen := make(map[string]bool)
+	m.sectors = nil
+	for _, c := range m.scan.Candidates {
+		if c.Sector != "" && !seen[c.Sector] {
+			seen[c.Sector] = true
+			m.sectors = append(m.sectors, c.Sector)
+		}
+	}
+	sort.Strings(m.sectors)
+	if m.sector != "" && !seen[m.sector] {
+		m.sector = ""
+	}
+}
+
+// refresh rebuilds rows from the scan and keeps the cursor on the selected stock.
+func (m *Model) refresh() {
+	m.rows = make([]model.Candidate, 0, len(m.scan.Candidates))
+	for _, c := range m.scan.Candidates {
+		if m.sector == "" || c.Sector == m.sector {
+			m.rows = append(m.rows, c)
+		}
+	}
+	key := func(c model.Candidate) float64 {
+		switch m.sortKey {
+		case SortChange:
+			return c.ChangePercent
+		case SortVolumeRatio:
+			return c.VolumeRatio
+		default:
+			return c.SurgeScore
+		}
+	}
+	sort.SliceStable(m.rows, func(i, j int) bool {
+		if m.ascend {
+			return key(m.rows[i]) < key(m.rows[j])
+		}
+		return key(m.rows[i]) > key(m.rows[j])
+	})
+
+	m.cursor = 0
+	for i, c := range m.rows {
+		if c.Symbol == m.selected {
+			m.cursor = i
+		}
+	}
+	if len(m.rows) > 0 {
+		m.selected = m.rows[m.cursor].Symbol
+	}
+	m.clampOffset()
+}
+
+func (m *Model) clampOffset() {
+	h := m.tableHeight()
+	if m.cursor < m.offset {
+		m.offset = m.cursor
+	}
+	if m.cursor >= m.offset+h {
+		m.offset = m.cursor - h + 1
+	}
+	m.offset = max(min(m.offset, len(m.rows)-h), 0)
+}
+
+// loadChart fetches the selected stock's recent prices unless already cached.
+func (m Model) loadChart() tea.Cmd {
+	if m.hist == nil || !m.detail || m.selected == "" {
+		return nil
+	}
+	if _, ok := m.charts[m.selected]; ok {
+		return nil
+	}
+	hist, symbol, at, window := m.hist, m.selected, m.scan.At, m.chartWindow
+	return func() tea.Msg {
+		points, err := hist.Range(symbol, at.Add(-window), at)
+		prices := make([]float64, len(points))
+		for i, p := range points {
+			prices[i] = p.Price
+		}
+		return chartMsg{symbol: symbol, at: at, prices: prices, err: err}
+	}
+}
+
+// ---- layout ----
+
+const (
+	headerLines = 3 // タイトル・状態行・列見出し
+	footerLines = 1
+	detailLines = 14
+)
+
+func (m Model) tableHeight() int {
+	h := m.height - headerLines - footerLines
+	if m.detail {
+		h -= detailLines
+	}
+	return max(h, 1)
+}
+
+// View implements tea.Model.
+func (m Model) View() string {
+	var b strings.Builder
+	m.viewHeader(&b)
+	m.viewTable(&b)
+	if m.detail {
+		m.viewDetail(&b)
+	}
+	b.WriteString(dim + fit("↑↓/jk 移動  1/2/3/s 並べ替え  r 昇順/降順  f/F 業種  a 全業種  Enter 詳細  q 終了", m.width) + reset)
+	return b.String()
+}
+
+func (m Model) viewHeader(b *strings.Builder) {
+	ts, status := "-", ""
+	if !m.scan.At.IsZero() {
+		ts = m.scan.At.In(calendar.JST).Format("2006-01-02 15:04:05")
+		status = calendar.Default().Status(m.scan.At)
+	}
+	order := "降順"
+	if m.ascend {
+		order = "昇順"
+	}
+	sector := m.sector
+	if sector == "" {
+		sector = "全業種"
+	}
+	b.WriteString(bold + cyan + fit(fmt.Sprintf("🔥 東証急騰スキャナー  %s  %s  スキャン: %d 銘柄  候補: %d 銘柄",
+		ts, status, m.scan.Universe, len(m.rows)), m.width) + reset + "\n")
+	line := fmt.Sprintf("並べ替え: %s（%s）  業種: %s", m.sortKey, order, sector)
+	if r := m.scan.Report; r != nil {
+		line += fmt.Sprintf("  取得: %d/%d（%.0f%%）", r.Valid, r.Requested, r.Coverage()*100)
+	}
+	if p := m.scan.Portfolio; p != nil {
+		line += fmt.Sprintf("  保有損益: %s（本日 %s）", portfolio.SignedYen(p.PnL), portfolio.SignedYen(p.DayPnL))
+	}
+	if m.status != "" {
+		line += "  " + m.status
+	}
+	b.WriteString(fit(line, m.width) + "\n")
+	b.WriteString(bold + fit(fmt.Sprintf("  %6s  %s  %s  %s  %s  %s  %s",
+		"SCORE", pad("コード", 6), pad("銘柄名", 18), pad("業種", 10),
+		padLeft("現在値", 10), padLeft("騰落率", 8), padLeft("出来高比", 8)), m.width) + reset + "\n")
+}
+
+func (m Model) viewTable(b *strings.Builder) {
+	h := m.tableHeight()
+	for i := m.offset; i < m.offset+h; i++ {
+		if i >= len(m.rows) {
+			if i == 0 {
+				b.WriteString(yellow + fit("  急騰候補が見つかりませんでした。", m.width) + reset)
+			}
+			b.WriteString("\n")
+			continue
+		}
+		c := m.rows[i]
+		vol := "N/A"
+		if c.AvgVolume3M > 0 {
+			vol = fmt.Sprintf("%.1fx", c.VolumeRatio)
+		}
+		code := strings.TrimSuffix(c.Symbol, ".T")
+		if c.Held {
+			code = "★" + code // 保有銘柄
+		}
+		row := fit(fmt.Sprintf("  %6.1f  %-6s  %s  %s  %10s  %+7.2f%%  %8s",
+			c.SurgeScore, code, pad(c.Name, 18), pad(c.Sector, 10),
+			exchange.Format(c.Price), c.ChangePercent, vol), m.width)
+		switch {
+		case i == m.cursor:
+			row = reverse + pad(row, m.width) + reset
+		case c.ChangePercent < 0:
+			row = red + row + reset
+		case c.SurgeScore >= 60:
+			row = green + row + reset
+		}
+		b.WriteString(row + "\n")
+	}
+}
+
+func (m Model) viewDetail(b *strings.Builder) {
+	lines := make([]string, 0, detailLines)
+	if len(m.rows) == 0 {
+		lines = append(lines, strings.Repeat("─", m.width))
+	} else {
+		c := m.rows[m.cursor]
+		lines = append(lines, bold+fit(fmt.Sprintf("── %s %s（%s）", c.Symbol, c.Name, c.Sector)+" "+
+			strings.Repeat("─", m.width), m.width)+reset)
+		quote := fmt.Sprintf("  現在値 %s  前日比 %+.1f (%+.2f%%)  高値 %s  安値 %s  出来高 %d  52週高値 %s",
+			exchange.Format(c.Price), c.Change, c.ChangePercent, exchange.Format(c.DayHigh), exchange.Format(c.DayLow),
+			c.Volume, exchange.Format(c.WeekHigh52))
+		if c.StopHigh > 0 {
+			quote += fmt.Sprintf("  値幅 %s–%s", exchange.Format(c.StopLow), exchange.Format(c.StopHigh))
+		}
+		lines = append(lines, fit(quote, m.width))
+
+		left := append(append(append(m.holdingLines(c.Symbol), eventLines(c)...), newsLines(c)...), signalLines(c)...)
+		right := m.chartLines(c.Symbol, m.width/2-2, detailLines-3)
+		leftWidth := m.width - m.width/2
+		for i := 0; i < detailLines-2; i++ {
+			var l, r string
+			if i < len(left) {
+				l = left[i]
+			}
+			if i < len(right) {
+				r = right[i]
+			}
+			lines = append(lines, pad(fit(l, leftWidth), leftWidth)+r)
+		}
+	}
+	for _, l := range lines[:min(len(lines), detailLines)] {
+		b.WriteString(l + "\n")
+	}
+	for i := len(lines); i < detailLines; i++ {
+		b.WriteString("\n")
+	}
+}
+
+// signalLines lists every signal with its score contribution. Emoji signals
+// are display-only and contribute nothing.
+func signalLines(c model.Candidate) []string {
+	lines := []string{fmt.Sprintf("  シグナル（合計 %.1f）", c.SurgeScore)}
+	if len(c.Signals) == 0 {
+		return append(lines, "    なし")
+	}
+	for _, s := range c.Signals {
+		score := "   表示のみ"
+		if s.Score != 0 {
+			score = fmt.Sprintf("%+10.1f", s.Score)
+		}
+		lines = append(lines, "  "+score+"  "+s.Label)
+	}
+	return lines
+}
+
+// eventLines lists the candidate's events of the day with their titles.
+func eventLines(c model.Candidate) []string {
+	var lines []string
+	for _, e := range c.Events {
+		at := e.At.In(calendar.JST)
+		when := at.Format("01/02")
+		if at.Hour() != 0 || at.Minute() != 0 {
+			when = at.Format("01/02 15:04")
+		}
+		line := fmt.Sprintf("  %s %s", e.Kind, when)
+		if e.Title != "" {
+			line += "  " + e.Title
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// newsLines lists the candidate's latest headlines, newest first.
+func newsLines(c model.Candidate) []string {
+	var lines []string
+	for _, h := range c.News {
+		when := ""
+		if !h.Published.IsZero() {
+			when = h.Published.In(calendar.JST).Format("15:04") + " "
+		}
+		lines = append(lines, fmt.Sprintf("  📰 %s%s（%s）", when, h.Title, h.Source))
+	}
+	return lines
+}
+
+// holdingLines describes the user's positions in symbol, if any.
+func (m Model) holdingLines(symbol string) []string {
+	if m.scan.Portfolio == nil {
+		return nil
+	}
+	var lines []string
+	for _, h := range m.scan.Portfolio.Holdings {
+		if h.Symbol != symbol || !h.Valid {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("  保有 %d株 @%s（%s口座）  損益 %s（%+.2f%%）",
+			h.Shares, strconv.FormatFloat(h.AvgCost, 'f', -1, 64), h.Account, portfolio.SignedYen(h.PnL), h.PnLPercent))
+	}
+	return lines
+}
+
+func (m Model) chartLines(symbol string, width, height int) []string {
+	if m.hist == nil {
+		return []string{dim + "  履歴データベースが無効です（-history）" + reset}
+	}
+	ch, ok := m.charts[symbol]
+	switch {
+	case !ok:
+		return []string{dim + "  チャート読み込み中..." + reset}
+	case ch.err != nil:
+		return []string{red + "  " + ch.err.Error() + reset}
+	case len(ch.prices) < 2:
+		return []string{dim + "  履歴が不足しています" + reset}
+	}
+	lo, hi := ch.prices[0], ch.prices[0]
+	for _, p := range ch.prices {
+		lo, hi = min(lo, p), max(hi, p)
+	}
+	lines := []string{fmt.Sprintf("  直近 %s  高 %.1f / 安 %.1f", shortDuration(m.chartWindow), hi, lo)}
+	color := green
+	if ch.prices[len(ch.prices)-1] < ch.prices[0] {
+		color = red
+	}
+	for _, row := range Chart(ch.prices, width, height-1) {
+		lines = append(lines, "  "+color+row+reset)
+	}
+	return lines
+}
+
+// ---- helpers ----
+
+// fit truncates s to width display columns.
+func fit(s string, width int) string {
+	return runewidth.Truncate(s, width, "…")
+}
+
+// pad right-pads s with spaces to width display columns (CJK is 2 columns).
+func pad(s string, width int) string {
+	return runewidth.FillRight(runewidth.Truncate(s, width, "…"), width)
+}
+
+// padLeft left-pads s with spaces to width display columns.
+func padLeft(s string, width int) string {
+	return runewidth.FillLeft(s, width)
+}
+
+// shortDuration formats d without zero minutes/seconds (8h rather than 8h0m0s).
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
+
+// ---- program ----
+
+// Program runs the UI and accepts scans from the scan loop.
+type Program struct {
+	p *tea.Program
+}
+
+// NewProgram returns a Program drawing m on the alternate screen.
+func NewProgram(ctx context.Context, m Model) *Program {
+	return &Program{p: tea.NewProgram(m, tea.WithAltScreen(), tea.WithContext(ctx))}
+}
+
+// Publish shows a completed scan. It is safe to call from any goroutine.
+func (p *Program) Publish(s Scan) {
+	p.p.Send(s)
+}
+
+// Write shows the last line written as the status line, so log output does
+// not corrupt the screen. Use it as the log package's output.
+func (p *Program) Write(b []byte) (int, error) {
+	p.p.Send(logMsg(strings.TrimSpace(string(b))))
+	return len(b), nil
+}
+
+// Run blocks until the user quits or the context is cancelled.
+func (p *Program) Run() error {
+	_, err := p.p.Run()
+	if err == tea.ErrProgramKilled {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,167 @@
+package tui_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"tse-scanner/history"
+	"tse-scanner/model"
+	"tse-scanner/tui"
+)
+
+// ---- helpers ----
+
+var at = time.Date(2024, 6, 14, 10, 0, 0, 0, time.FixedZone("JST", 9*60*60))
+
+type fakeHistory struct{ prices []float64 }
+
+func (f fakeHistory) Range(_ string, from, _ time.Time) ([]history.Point, error) {
+	pts := make([]history.Point, len(f.prices))
+	for i, p := range f.prices {
+		pts[i] = history.Point{At: from.Add(time.Duration(i) * time.Minute), Price: p}
+	}
+	return pts, nil
+}
+
+func (f fakeHistory) Latest(string, time.Time) (history.Point, bool, error) {
+	return history.Point{}, false, nil
+}
+
+func cand(symbol, sector string, score, change, ratio float64) model.Candidate {
+	return model.Candidate{
+		Quote:       model.Quote{Symbol: symbol, Name: "銘柄" + symbol, Sector: sector, Price: 1000, ChangePercent: change, AvgVolume3M: 1},
+		SurgeScore:  score,
+		VolumeRatio: ratio,
+		Signals:     []model.Signal{{Label: "前日比", Score: score}, {Label: "🚀大幅上昇"}},
+	}
+}
+
+func scan() tui.Scan {
+	return tui.Scan{At: at, Universe: 3, Candidates: []model.Candidate{
+		cand("1111.T", "銀行業", 80, 2, 1.5),
+		cand("2222.T", "電気機器", 60, 9, 3),
+		cand("3333.T", "電気機器", 40, 5, 8),
+	}}
+}
+
+// send feeds msgs to m, running any command it returns once.
+func send(t *testing.T, m tea.Model, msgs ...tea.Msg) tea.Model {
+	t.Helper()
+	for _, msg := range msgs {
+		var cmd tea.Cmd
+		m, cmd = m.Update(msg)
+		if cmd != nil {
+			if next := cmd(); next != nil {
+				m, _ = m.Update(next)
+			}
+		}
+	}
+	return m
+}
+
+func key(s string) tea.KeyMsg {
+	switch s {
+	case "down":
+		return tea.KeyMsg{Type: tea.KeyDown}
+	case "enter":
+		return tea.KeyMsg{Type: tea.KeyEnter}
+	}
+	return tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)}
+}
+
+// order returns the symbols in table order as rendered by View.
+func order(view string) []string {
+	var syms []string
+	for _, line := range strings.Split(view, "\n") {
+		for _, s := range []string{"1111", "2222", "3333"} {
+			if strings.Contains(line, " "+s+" ") && !strings.Contains(line, "──") {
+				syms = append(syms, s)
+			}
+		}
+	}
+	return syms
+}
+
+// ---- tests ----
+
+func TestSortKeys(t *testing.T) {
+	m := send(t, tui.New(nil), tea.WindowSizeMsg{Width: 120, Height: 40}, scan())
+	cases := []struct {
+		key  string
+		want string
+	}{
+		{"1", "3333 2222 1111"}, // 同じキーで昇順に切替
+		{"2", "2222 3333 1111"},
+		{"3", "3333 2222 1111"},
+		{"r", "1111 2222 3333"},
+	}
+	for _, tc := range cases {
+		m = send(t, m, key(tc.key))
+		if got := strings.Join(order(m.View())[:3], " "); got != tc.want {
+			t.Errorf("after %q: order %s, want %s", tc.key, got, tc.want)
+		}
+	}
+}
+
+func TestSectorFilter(t *testing.T) {
+	m := send(t, tui.New(nil), tea.WindowSizeMsg{Width: 120, Height: 40}, scan())
+	m = send(t, m, key("f")) // 業種は名前順: 銀行業 → 電気機器
+	m = send(t, m, key("f"))
+	view := m.View()
+	if !strings.Contains(view, "業種: 電気機器") {
+		t.Fatalf("filter not shown:\n%s", view)
+	}
+	if got := order(view); len(got) != 2 || got[0] != "2222" {
+		t.Errorf("filtered rows = %v", got)
+	}
+	m = send(t, m, key("a"))
+	if got := order(m.View()); len(got) < 3 {
+		t.Errorf("after clearing filter rows = %v", got)
+	}
+}
+
+func TestCursorFollowsStockAcrossScans(t *testing.T) {
+	m := send(t, tui.New(nil), tea.WindowSizeMsg{Width: 120, Height: 40}, scan())
+	m = send(t, m, key("down")) // 2222 を選択
+
+	next := scan()
+	next.At = at.Add(time.Minute)
+	next.Candidates[1].SurgeScore = 99 // 2222 が先頭へ
+	m = send(t, m, next)
+	if !strings.Contains(m.View(), "── 2222.T") {
+		t.Errorf("detail pane should still show 2222.T:\n%s", m.View())
+	}
+}
+
+func TestDetailPaneShowsSignalsAndChart(t *testing.T) {
+	h := fakeHistory{prices: []float64{1000, 1010, 1005, 1030, 1050}}
+	m := send(t, tui.New(h), tea.WindowSizeMsg{Width: 120, Height: 40}, scan())
+	view := m.View()
+	for _, want := range []string{"── 1111.T", "+80.0  前日比", "表示のみ  🚀大幅上昇", "高 1050.0 / 安 1000.0", "█"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("detail pane missing %q:\n%s", want, view)
+		}
+	}
+
+	m = send(t, m, key("enter"))
+	if strings.Contains(m.View(), "── 1111.T") {
+		t.Error("enter should hide the detail pane")
+	}
+}
+
+func TestChart(t *testing.T) {
+	rows := tui.Chart([]float64{1, 2, 3, 4}, 4, 2)
+	if len(rows) != 2 {
+		t.Fatalf("want 2 rows, got %d", len(rows))
+	}
+	// 最安値は最下段の 1 目盛り、最高値は 2 行とも満たす
+	if []rune(rows[1])[0] != '▁' || []rune(rows[0])[3] != '█' || []rune(rows[1])[3] != '█' {
+		t.Errorf("chart =\n%s", strings.Join(rows, "\n"))
+	}
+	if got := tui.Chart(make([]float64, 100), 10, 1); len([]rune(got[0])) != 10 {
+		t.Errorf("resampled width = %d, want 10", len([]rune(got[0])))
+	}
+}
@@ -0,0 +1,194 @@
+package watchlist
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"tse-scanner/model"
+)
+
+// extensions lists the file extensions probed when looking up a named watchlist.
+var extensions = []string{".json", ".yaml", ".yml", ".csv"}
+
+// ErrNotFound is returned when a named watchlist does not exist in the Store.
+var ErrNotFound = errors.New("ウォッチリストが見つかりません")
+
+// Store manages named watchlists kept as files in a single directory.
+type Store struct {
+	Dir string
+}
+
+// DefaultDir returns the per-user watchlist directory
+// (e.g. ~/.config/tse-scanner/watchlists on Linux).
+func DefaultDir() string {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return filepath.Join(".", "watchlists")
+	}
+	return filepath.Join(base, "tse-scanner", "watchlists")
+}
+
+// NewStore returns a Store rooted at dir.
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// Names returns the names of all watchlists in the store, sorted.
+func (s *Store) Names() ([]string, error) {
+	entries, err := os.ReadDir(s.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ウォッチリストディレクトリの読み込みエラー: %w", err)
+	}
+	seen := make(map[string]bool)
+	names := []string{}
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		ext := filepath.Ext(e.Name())
+		if _, err := FormatFromPath(e.Name()); err != nil {
+			continue
+		}
+		name := strings.TrimSuffix(e.Name(), ext)
+		if !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// path returns the existing file for name, or "" if none exists.
+func (s *Store) path(name string) string {
+	for _, ext := range extensions {
+		p := filepath.Join(s.Dir, name+ext)
+		if _, err := os.Stat(p); err == nil {
+			return p
+		}
+	}
+	return ""
+}
+
+// Load returns the named watchlist. The built-in list is returned for
+// DefaultName unless the store contains a file of that name.
+func (s *Store) Load(name string) (Watchlist, error) {
+	if err := validateName(name); err != nil {
+		return Watchlist{}, err
+	}
+	p := s.path(name)
+	if p == "" {
+		if name == DefaultName {
+			return Default(), nil
+		}
+		return Watchlist{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	w, err := Load(p)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	w.Name = name
+	return w, nil
+}
+
+// Save writes w back to its existing file (keeping that file's format),
+// or to <Dir>/<name>.json when it is new.
+func (s *Store) Save(w Watchlist) error {
+	if err := validateName(w.Name); err != nil {
+		return err
+	}
+	p := s.path(w.Name)
+	if p == "" {
+		p = filepath.Join(s.Dir, w.Name+".json")
+	}
+	return Save(p, w)
+}
+
+// Add inserts or replaces stock in the named watchlist, creating the list
+// if it does not exist yet.
+func (s *Store) Add(name string, stock model.Stock) error {
+	stock.Symbol = NormalizeSymbol(stock.Symbol)
+	if err := ValidateSymbol(stock.Symbol); err != nil {
+		return err
+	}
+	w, err := s.Load(name)
+	if errors.Is(err, ErrNotFound) {
+		w, err = Watchlist{Name: name}, nil
+	}
+	if err != nil {
+		return err
+	}
+	if i := w.Index(stock.Symbol); i >= 0 {
+		w.Stocks[i] = stock
+	} else {
+		w.Stocks = append(w.Stocks, stock)
+	}
+	return s.Save(w)
+}
+
+// Remove deletes symbol from the named watchlist.
+func (s *Store) Remove(name, symbol string) error {
+	symbol = NormalizeSymbol(symbol)
+	w, err := s.Load(name)
+	if err != nil {
+		return err
+	}
+	i := w.Index(symbol)
+	if i < 0 {
+		return fmt.Errorf("%s に %s は登録されていません", name, symbol)
+	}
+	w.Stocks = append(w.Stocks[:i], w.Stocks[i+1:]...)
+	return s.Save(w)
+}
+
+// Resolve turns a comma-separated -watchlist spec into a merged stock list.
+// Each element is either a path to a watchlist file or the name of a list in
+// the store. When a symbol appears in more than one list the first wins.
+func (s *Store) Resolve(spec string) ([]model.Stock, error) {
+	var stocks []model.Stock
+	seen := make(map[string]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		var (
+			w   Watchlist
+			err error
+		)
+		if _, statErr := os.Stat(item); statErr == nil {
+			w, err = Load(item)
+		} else {
+			w, err = s.Load(item)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, st := range w.Stocks {
+			if !seen[st.Symbol] {
+				seen[st.Symbol] = true
+				stocks = append(stocks, st)
+			}
+		}
+	}
+	if len(stocks) == 0 {
+		return nil, fmt.Errorf("ウォッチリスト %q に銘柄がありません", spec)
+	}
+	return stocks, nil
+}
+
+// validateName rejects names that would escape the store directory.
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("ウォッチリスト名 %q は使用できません", name)
+	}
+	return nil
+}
@@ -0,0 +1,346 @@
+// Package watchlist loads, saves and validates stock watchlists.
+//
+// A watchlist is a named list of model.Stock entries stored as CSV, YAML or
+// JSON. The format is chosen from the file extension:
+//
+//	CSV : header row "symbol,name,sector,tags"（日本語見出し コード,銘柄名,業種,タグ も可）
+//	      tags are separated by ";"
+//	YAML: {name: ..., stocks: [{symbol, name, sector, tags: [...]}]} or a bare list
+//	JSON: same shape as YAML
+//
+// Named watchlists live in a Store directory; the built-in list shipped with
+// the binary is available as Default().
+package watchlist
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"tse-scanner/model"
+)
+
+// DefaultName is the name under which the built-in watchlist is resolved.
+const DefaultName = "default"
+
+//go:embed default.csv
+var defaultCSV []byte
+
+// Format is a watchlist file format.
+type Format string
+
+const (
+	FormatCSV  Format = "csv"
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+// FormatFromPath infers the format from a file extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return FormatCSV, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".json":
+		return FormatJSON, nil
+	default:
+		return "", fmt.Errorf("未対応のウォッチリスト形式です: %s（.csv / .yaml / .json）", path)
+	}
+}
+
+// Watchlist is a named list of stocks.
+type Watchlist struct {
+	Name   string
+	Stocks []model.Stock
+}
+
+// symbolPattern matches TSE codes such as "7203.T" or the newer
+// alphanumeric codes such as "130A.T".
+var symbolPattern = regexp.MustCompile(`^[0-9][0-9A-Z]{3}\.T$`)
+
+// NormalizeSymbol trims and upper-cases a symbol.
+func NormalizeSymbol(symbol string) string {
+	return strings.ToUpper(strings.TrimSpace(symbol))
+}
+
+// ValidateSymbol reports whether symbol is a TSE code with the ".T" suffix.
+func ValidateSymbol(symbol string) error {
+	if !symbolPattern.MatchString(symbol) {
+		if !strings.HasSuffix(symbol, ".T") {
+			return fmt.Errorf("銘柄コード %q には .T サフィックスが必要です（例: 7203.T）", symbol)
+		}
+		return fmt.Errorf("銘柄コード %q の形式が不正です（例: 7203.T, 130A.T）", symbol)
+	}
+	return nil
+}
+
+// Validate checks every entry and rejects duplicate symbols.
+func (w Watchlist) Validate() error {
+	seen := make(map[string]bool, len(w.Stocks))
+	var errs []error
+	for i, s := range w.Stocks {
+		if err := ValidateSymbol(s.Symbol); err != nil {
+			errs = append(errs, fmt.Errorf("%d 行目: %w", i+1, err))
+			continue
+		}
+		if seen[s.Symbol] {
+			errs = append(errs, fmt.Errorf("%d 行目: 銘柄コード %s が重複しています", i+1, s.Symbol))
+		}
+		seen[s.Symbol] = true
+	}
+	return errors.Join(errs...)
+}
+
+// Index returns the position of symbol in the list, or -1.
+func (w Watchlist) Index(symbol string) int {
+	for i, s := range w.Stocks {
+		if s.Symbol == symbol {
+			return i
+		}
+	}
+	return -1
+}
+
+// Default returns the built-in watchlist (東証プライム中心).
+func Default() Watchlist {
+	w, err := Parse(bytes.NewReader(defaultCSV), FormatCSV, DefaultName)
+	if err != nil {
+		panic("watchlist: 組み込みウォッチリストが不正です: " + err.Error())
+	}
+	return w
+}
+
+// Load reads and validates a watchlist file. The watchlist name defaults to
+// the file name without its extension.
+func Load(path string) (Watchlist, error) {
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return Watchlist{}, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("ウォッチリストを開けません: %w", err)
+	}
+	defer f.Close()
+
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	w, err := Parse(f, format, name)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return w, nil
+}
+
+// Parse decodes and validates a watchlist. name is used unless the document
+// itself specifies one (YAML/JSON "name" key).
+func Parse(r io.Reader, format Format, name string) (Watchlist, error) {
+	var (
+		w   Watchlist
+		err error
+	)
+	switch format {
+	case FormatCSV:
+		w, err = parseCSV(r)
+	case FormatYAML:
+		w, err = parseDocument(r, yaml.Unmarshal)
+	case FormatJSON:
+		w, err = parseDocument(r, json.Unmarshal)
+	default:
+		return Watchlist{}, fmt.Errorf("未対応のウォッチリスト形式です: %s", format)
+	}
+	if err != nil {
+		return Watchlist{}, err
+	}
+	if w.Name == "" {
+		w.Name = name
+	}
+	if err := w.Validate(); err != nil {
+		return Watchlist{}, err
+	}
+	return w, nil
+}
+
+// Save writes w to path in the format implied by its extension.
+func Save(path string, w Watchlist) error {
+	if err := w.Validate(); err != nil {
+		return err
+	}
+	format, err := FormatFromPath(path)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, format, w); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("ディレクトリ作成エラー: %w", err)
+	}
+	// Write to a temp file and rename so a crash never leaves a half-written list.
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("ウォッチリスト書き込みエラー: %w", err)
+	}
+	return os.Rename(tmp, path)
+}
+
+// Encode writes w in the given format.
+func Encode(out io.Writer, format Format, w Watchlist) error {
+	switch format {
+	case FormatCSV:
+		cw := csv.NewWriter(out)
+		cw.Write([]string{"symbol", "name", "sector", "tags"}) //nolint:errcheck
+		for _, s := range w.Stocks {
+			cw.Write([]string{s.Symbol, s.Name, s.Sector, strings.Join(s.Tags, ";")}) //nolint:errcheck
+		}
+		cw.Flush()
+		return cw.Error()
+	case FormatYAML:
+		enc := yaml.NewEncoder(out)
+		enc.SetIndent(2)
+		if err := enc.Encode(toDocument(w)); err != nil {
+			return err
+		}
+		return enc.Close()
+	case FormatJSON:
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		return enc.Encode(toDocument(w))
+	default:
+		return fmt.Errorf("未対応のウォッチリスト形式です: %s", format)
+	}
+}
+
+// ---- file document types ----
+
+type entry struct {
+	Symbol string   `json:"symbol" yaml:"symbol"`
+	Name   string   `json:"name" yaml:"name"`
+	Sector string   `json:"sector" yaml:"sector"`
+	Tags   []string `json:"tags,omitempty" yaml:"tags,omitempty,flow"`
+}
+
+type document struct {
+	Name   string  `json:"name,omitempty" yaml:"name,omitempty"`
+	Stocks []entry `json:"stocks" yaml:"stocks"`
+}
+
+func toDocument(w Watchlist) document {
+	doc := document{Name: w.Name, Stocks: make([]entry, len(w.Stocks))}
+	for i, s := range w.Stocks {
+		doc.Stocks[i] = entry{Symbol: s.Symbol, Name: s.Name, Sector: s.Sector, Tags: s.Tags}
+	}
+	return doc
+}
+
+func (e entry) stock() model.Stock {
+	var tags []string
+	for _, t := range e.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	return model.Stock{
+		Symbol: NormalizeSymbol(e.Symbol),
+		Name:   strings.TrimSpace(e.Name),
+		Sector: strings.TrimSpace(e.Sector),
+		Tags:   tags,
+	}
+}
+
+// parseDocument decodes YAML or JSON: either {name, stocks} or a bare list.
+func parseDocument(r io.Reader, unmarshal func([]byte, any) error) (Watchlist, error) {
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("読み込みエラー: %w", err)
+	}
+	var doc document
+	if err := unmarshal(b, &doc); err != nil {
+		var list []entry
+		if lerr := unmarshal(b, &list); lerr != nil {
+			return Watchlist{}, fmt.Errorf("パースエラー: %w", err)
+		}
+		doc.Stocks = list
+	}
+	w := Watchlist{Name: doc.Name, Stocks: make([]model.Stock, len(doc.Stocks))}
+	for i, e := range doc.Stocks {
+		w.Stocks[i] = e.stock()
+	}
+	return w, nil
+}
+
+// csvColumns maps accepted header names to entry fields.
+var csvColumns = map[string]string{
+	"symbol": "symbol", "コード": "symbol", "銘柄コード": "symbol",
+	"name": "name", "銘柄名": "name",
+	"sector": "sector", "業種": "sector",
+	"tags": "tags", "タグ": "tags",
+}
+
+func parseCSV(r io.Reader) (Watchlist, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.Comment = '#'
+	records, err := cr.ReadAll()
+	if err != nil {
+		return Watchlist{}, fmt.Errorf("CSV パースエラー: %w", err)
+	}
+	if len(records) == 0 {
+		return Watchlist{}, nil
+	}
+
+	// Column order defaults to symbol,name,sector,tags when there is no header.
+	cols := []string{"symbol", "name", "sector", "tags"}
+	if header, ok := csvHeader(records[0]); ok {
+		cols = header
+		records = records[1:]
+	}
+
+	w := Watchlist{Stocks: make([]model.Stock, 0, len(records))}
+	for _, rec := range records {
+		var e entry
+		for i, v := range rec {
+			if i >= len(cols) {
+				break
+			}
+			switch cols[i] {
+			case "symbol":
+				e.Symbol = v
+			case "name":
+				e.Name = v
+			case "sector":
+				e.Sector = v
+			case "tags":
+				if v != "" {
+					e.Tags = strings.Split(v, ";")
+				}
+			}
+		}
+		w.Stocks = append(w.Stocks, e.stock())
+	}
+	return w, nil
+}
+
+// csvHeader maps a header row to field names. It reports false when the row
+// has no symbol column, i.e. it is data rather than a header.
+func csvHeader(row []string) ([]string, bool) {
+	cols := make([]string, len(row))
+	hasSymbol := false
+	for i, h := range row {
+		cols[i] = csvColumns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))]
+		hasSymbol = hasSymbol || cols[i] == "symbol"
+	}
+	return cols, hasSymbol
+}
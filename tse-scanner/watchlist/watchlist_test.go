@@ -0,0 +1,253 @@
+package watchlist_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"tse-scanner/model"
+	"tse-scanner/watchlist"
+)
+
+// ---- helpers ----
+
+func writeFile(t *testing.T, dir, name, body string) string {
+	t.Helper()
+	p := filepath.Join(dir, name)
+	if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// ---- parsing ----
+
+func TestLoad_CSVWithHeaderAndTags(t *testing.T) {
+	p := writeFile(t, t.TempDir(), "semis.csv",
+		"symbol,name,sector,tags\n8035.t, 東京エレクトロン,半導体,AI;大型\n6857.T,アドバンテスト,半導体,\n")
+	w, err := watchlist.Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Name != "semis" {
+		t.Errorf("want name semis, got %s", w.Name)
+	}
+	if len(w.Stocks) != 2 {
+		t.Fatalf("want 2 stocks, got %d", len(w.Stocks))
+	}
+	s := w.Stocks[0]
+	if s.Symbol != "8035.T" || s.Name != "東京エレクトロン" || s.Sector != "半導体" {
+		t.Errorf("unexpected stock: %+v", s)
+	}
+	if len(s.Tags) != 2 || s.Tags[0] != "AI" || s.Tags[1] != "大型" {
+		t.Errorf("unexpected tags: %v", s.Tags)
+	}
+	if len(w.Stocks[1].Tags) != 0 {
+		t.Errorf("want no tags, got %v", w.Stocks[1].Tags)
+	}
+}
+
+func TestLoad_CSVJapaneseHeaderReordered(t *testing.T) {
+	p := writeFile(t, t.TempDir(), "jp.csv", "銘柄名,コード,業種\nトヨタ自動車,7203.T,自動車\n")
+	w, err := watchlist.Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Stocks[0].Symbol != "7203.T" || w.Stocks[0].Name != "トヨタ自動車" {
+		t.Errorf("unexpected stock: %+v", w.Stocks[0])
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	p := writeFile(t, t.TempDir(), "list.yaml", `name: 自動車
+stocks:
+  - symbol: 7203.T
+    name: トヨタ自動車
+    sector: 自動車
+    tags: [大型, 輸出]
+  - symbol: 7267.T
+    name: 本田技研工業
+    sector: 自動車
+`)
+	w, err := watchlist.Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Name != "自動車" {
+		t.Errorf("document name should win, got %s", w.Name)
+	}
+	if len(w.Stocks) != 2 || len(w.Stocks[0].Tags) != 2 {
+		t.Errorf("unexpected stocks: %+v", w.Stocks)
+	}
+}
+
+func TestLoad_JSONBareList(t *testing.T) {
+	p := writeFile(t, t.TempDir(), "bare.json",
+		`[{"symbol":"9984.T","name":"ソフトバンクグループ","sector":"IT","tags":["AI"]}]`)
+	w, err := watchlist.Load(p)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if w.Name != "bare" || len(w.Stocks) != 1 || w.Stocks[0].Symbol != "9984.T" {
+		t.Errorf("unexpected watchlist: %+v", w)
+	}
+}
+
+func TestLoad_RejectsMissingSuffixAndDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	cases := map[string]string{
+		"nosuffix.csv": "symbol,name\n7203,トヨタ\n",
+		"badcode.csv":  "symbol,name\nTOYOTA.T,トヨタ\n",
+		"dup.csv":      "symbol,name\n7203.T,a\n7203.T,b\n",
+	}
+	for name, body := range cases {
+		if _, err := watchlist.Load(writeFile(t, dir, name, body)); err == nil {
+			t.Errorf("%s: want validation error", name)
+		}
+	}
+}
+
+func TestLoad_UnknownExtension(t *testing.T) {
+	p := writeFile(t, t.TempDir(), "list.txt", "7203.T")
+	if _, err := watchlist.Load(p); err == nil {
+		t.Error("want error for .txt")
+	}
+}
+
+func TestValidateSymbol(t *testing.T) {
+	for _, ok := range []string{"7203.T", "130A.T"} {
+		if err := watchlist.ValidateSymbol(ok); err != nil {
+			t.Errorf("%s: unexpected error %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"7203", "7203.TO", "AAPL", "72030.T", ""} {
+		if err := watchlist.ValidateSymbol(bad); err == nil {
+			t.Errorf("%s: want error", bad)
+		}
+	}
+}
+
+func TestDefault_IsValidBuiltIn(t *testing.T) {
+	w := watchlist.Default()
+	if len(w.Stocks) < 50 {
+		t.Errorf("want ~50 built-in stocks, got %d", len(w.Stocks))
+	}
+	if err := w.Validate(); err != nil {
+		t.Errorf("built-in watchlist invalid: %v", err)
+	}
+	if w.Stocks[0].Symbol != "7203.T" || w.Stocks[0].Name != "トヨタ自動車" {
+		t.Errorf("unexpected first entry: %+v", w.Stocks[0])
+	}
+}
+
+// ---- store ----
+
+func TestStore_AddRemoveRoundTrip(t *testing.T) {
+	store := watchlist.NewStore(t.TempDir())
+	if err := store.Add("semis", model.Stock{Symbol: "8035.t", Name: "東京エレクトロン", Tags: []string{"AI"}}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	if err := store.Add("semis", model.Stock{Symbol: "6857.T", Name: "アドバンテスト"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	// Re-adding replaces the entry instead of duplicating it
+	if err := store.Add("semis", model.Stock{Symbol: "8035.T", Name: "東エレク"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	w, err := store.Load("semis")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(w.Stocks) != 2 || w.Stocks[0].Name != "東エレク" {
+		t.Errorf("unexpected stocks: %+v", w.Stocks)
+	}
+
+	if err := store.Remove("semis", "8035.T"); err != nil {
+		t.Fatalf("remove: %v", err)
+	}
+	if err := store.Remove("semis", "8035.T"); err == nil {
+		t.Error("removing a missing symbol should fail")
+	}
+	w, _ = store.Load("semis")
+	if len(w.Stocks) != 1 || w.Stocks[0].Symbol != "6857.T" {
+		t.Errorf("unexpected stocks after remove: %+v", w.Stocks)
+	}
+}
+
+func TestStore_AddRejectsInvalidSymbol(t *testing.T) {
+	store := watchlist.NewStore(t.TempDir())
+	if err := store.Add("x", model.Stock{Symbol: "7203"}); err == nil {
+		t.Error("want error for symbol without .T")
+	}
+}
+
+func TestStore_SaveKeepsExistingFormat(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "mine.yaml", "stocks:\n  - symbol: 7203.T\n    name: トヨタ\n")
+	store := watchlist.NewStore(dir)
+	if err := store.Add("mine", model.Stock{Symbol: "7267.T", Name: "ホンダ"}); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	b, err := os.ReadFile(filepath.Join(dir, "mine.yaml"))
+	if err != nil {
+		t.Fatalf("yaml file should still exist: %v", err)
+	}
+	if !strings.Contains(string(b), "7267.T") {
+		t.Errorf("yaml not updated: %s", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "mine.json")); err == nil {
+		t.Error("should not create a second file in another format")
+	}
+}
+
+func TestStore_NamesAndNotFound(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "b.csv", "symbol\n7203.T\n")
+	writeFile(t, dir, "a.json", `[]`)
+	writeFile(t, dir, "notes.txt", "ignored")
+	store := watchlist.NewStore(dir)
+
+	names, err := store.Names()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if strings.Join(names, ",") != "a,b" {
+		t.Errorf("want [a b], got %v", names)
+	}
+	if _, err := store.Load("missing"); !errors.Is(err, watchlist.ErrNotFound) {
+		t.Errorf("want ErrNotFound, got %v", err)
+	}
+	if _, err := store.Load("../escape"); err == nil {
+		t.Error("path traversal should be rejected")
+	}
+}
+
+func TestStore_ResolveMergesNamesAndFiles(t *testing.T) {
+	dir := t.TempDir()
+	store := watchlist.NewStore(filepath.Join(dir, "lists"))
+	store.Add("mine", model.Stock{Symbol: "7203.T", Name: "マイ・トヨタ"}) //nolint:errcheck
+	file := writeFile(t, dir, "extra.csv", "symbol,name\n130A.T,新規上場\n")
+
+	stocks, err := store.Resolve("mine, " + file + ",default")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stocks[0].Name != "マイ・トヨタ" || stocks[1].Symbol != "130A.T" {
+		t.Errorf("unexpected order/merge: %+v", stocks[:2])
+	}
+	count := 0
+	for _, s := range stocks {
+		if s.Symbol == "7203.T" {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("7203.T should appear once, got %d", count)
+	}
+	if len(stocks) != len(watchlist.Default().Stocks)+1 {
+		t.Errorf("unexpected merged size %d", len(stocks))
+	}
+}